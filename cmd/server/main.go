@@ -1,22 +1,31 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"html/template"
 	"io/fs"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/vlazic/mcp-server-manager/internal/assets"
 	"github.com/vlazic/mcp-server-manager/internal/config"
 	"github.com/vlazic/mcp-server-manager/internal/handlers"
+	"github.com/vlazic/mcp-server-manager/internal/logging"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/server"
 	"github.com/vlazic/mcp-server-manager/internal/services"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(os.Args[2:]))
+	}
+
 	var configPath = flag.String("config", "", "Path to config file (default: smart resolution)")
 	var configShort = flag.String("c", "", "Path to config file (short form)")
 	flag.Parse()
@@ -27,14 +36,66 @@ func main() {
 		finalConfigPath = *configShort
 	}
 
+	// Log with the defaults until the config has been read
+	logging.Setup(nil, os.Stderr)
+
 	cfg, actualConfigPath, err := config.LoadConfig(finalConfigPath)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("Failed to load config", err)
+	}
+
+	logger, err := logging.Setup(cfg.Logging, os.Stderr)
+	if err != nil {
+		fatal("Invalid logging settings", err)
+	}
+	slog.Debug("Loaded config", "path", actualConfigPath)
+
+	if err := services.NewValidatorService().ValidateListenConfig(cfg); err != nil {
+		fatal("Refusing to start", err)
 	}
 
 	mcpManager := services.NewMCPManagerService(cfg, actualConfigPath)
 
-	r := gin.Default()
+	// Problems are reported but only stop the operations that save the config
+	for _, problem := range mcpManager.CheckConfig() {
+		level := slog.LevelWarn
+		if problem.Severity == models.SeverityInfo {
+			level = slog.LevelInfo
+		}
+		slog.Log(context.Background(), level, "Config problem", "severity", problem.Severity, "path", problem.Path, "error", problem.Err)
+	}
+
+	if cfg.Watch != nil && cfg.Watch.Enabled {
+		watcher := services.NewClientWatcher(mcpManager, cfg.Watch)
+		watcher.Start()
+		defer watcher.Stop()
+		slog.Info("Watching client config files for drift", "mode", cfg.Watch.Mode)
+	}
+
+	if cfg.Health != nil && cfg.Health.Enabled {
+		checker := services.NewHealthChecker(mcpManager, cfg.Health)
+		checker.Start()
+		defer checker.Stop()
+		slog.Info("Checking server health in the background")
+	}
+
+	// Keeps the Authorization headers of servers using OAuth current
+	refresher := services.NewOAuthRefresher(mcpManager)
+	refresher.Start()
+	defer refresher.Stop()
+
+	if cfg.Notifications != nil {
+		notifier := services.NewNotifier(mcpManager.Events(), cfg.Notifications)
+		notifier.Start()
+		defer notifier.Stop()
+	}
+
+	// Gin's own debug output (route list, warnings) only at the debug level
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	r := gin.New()
+	r.Use(gin.Recovery(), handlers.RequestID(), handlers.RequestLogger(), handlers.RequestMetrics(), handlers.HostGuard(cfg))
 
 	// Set up embedded templates
 	funcMap := template.FuncMap{
@@ -56,43 +117,108 @@ func main() {
 
 	tmpl, err := assets.ParseTemplates(funcMap)
 	if err != nil {
-		log.Fatalf("Failed to parse embedded templates: %v", err)
+		fatal("Failed to parse embedded templates", err)
 	}
 	r.SetHTMLTemplate(tmpl)
 
 	// Set up embedded static files
 	staticFS, err := fs.Sub(assets.GetStaticFS(), "web/static")
 	if err != nil {
-		log.Fatalf("Failed to create static subdirectory: %v", err)
+		fatal("Failed to create static subdirectory", err)
 	}
 	r.StaticFS("/static", http.FS(staticFS))
 
 	apiHandler := handlers.NewAPIHandler(mcpManager)
 	webHandler := handlers.NewWebHandler(mcpManager)
 	configHandler := handlers.NewConfigViewerHandler(mcpManager, actualConfigPath)
+	authHandler := handlers.NewAuthHandler(cfg.Auth)
+
+	// Prometheus scrapes with a bearer token when auth is enabled
+	r.GET("/metrics", authHandler.RequireAPI(), handlers.Metrics)
+
+	// The schemas describe the file format only, so editors can fetch them without credentials
+	r.GET("/schema/config.json", handlers.ConfigSchema)
+	r.GET("/schema/server.json", handlers.ServerSchema)
 
-	r.GET("/", webHandler.Index)
-	r.GET("/config/app", configHandler.GetAppConfig)
-	r.GET("/config/client/:client", configHandler.GetClientConfig)
+	// Authorization servers redirect here; the state parameter ties the request to
+	// a sign-in started from the UI, so it works without a session
+	r.GET(handlers.OAuthCallbackPath, webHandler.OAuthCallback)
 
-	api := r.Group("/api")
+	r.GET("/login", authHandler.LoginPage)
+	r.POST("/login", authHandler.Login)
+
+	ui := r.Group("/", authHandler.RequireSession())
+	{
+		ui.GET("/", webHandler.Index)
+		ui.POST("/logout", authHandler.Logout)
+		ui.GET("/config/app", configHandler.GetAppConfig)
+		ui.GET("/config/client/:client", configHandler.GetClientConfig)
+		ui.GET("/config/layers", configHandler.GetConfigLayers)
+		ui.GET("/history", webHandler.History)
+		ui.GET("/oauth/:server/start", webHandler.StartOAuth)
+	}
+
+	api := r.Group("/api", authHandler.RequireAPI(), handlers.RequireJSON())
 	{
 		api.GET("/servers", apiHandler.GetMCPServers)
 		api.POST("/servers", apiHandler.AddServer)
 		api.GET("/clients", apiHandler.GetClients)
 		api.POST("/clients/:client/servers/:server/toggle", apiHandler.ToggleClientServer)
 		api.GET("/servers/:server", apiHandler.GetServerStatus)
+		api.PUT("/servers/:server/tags", apiHandler.SetServerTags)
+		api.POST("/servers/order", apiHandler.ReorderServers)
+		api.POST("/bulk", apiHandler.BulkToggle)
 		api.POST("/sync", apiHandler.SyncAllClients)
+		api.GET("/drift", apiHandler.GetDriftEvents)
+		api.GET("/health", apiHandler.GetHealth)
+		api.GET("/events", apiHandler.Events)
+		api.GET("/audit", apiHandler.GetAuditLog)
+		api.GET("/undo", apiHandler.GetUndoStatus)
+		api.POST("/undo", apiHandler.Undo)
+		api.POST("/redo", apiHandler.Redo)
+		api.GET("/history", apiHandler.GetConfigHistory)
+		api.POST("/history/:rev/revert", apiHandler.RevertConfig)
+		api.GET("/config/layers", apiHandler.GetConfigLayers)
+		api.GET("/config/validate", apiHandler.ValidateConfig)
+		api.GET("/projects", apiHandler.GetProjects)
+		api.POST("/projects", apiHandler.AddProject)
+		api.POST("/projects/:project/servers/:server/toggle", apiHandler.ToggleProjectServer)
+		api.POST("/projects/:project/sync", apiHandler.SyncProject)
+		api.GET("/catalog", apiHandler.GetCatalog)
+		api.POST("/catalog/sync", apiHandler.SyncCatalog)
+		api.POST("/catalog/install", apiHandler.InstallFromCatalog)
+		api.GET("/templates", apiHandler.GetTemplates)
+		api.PUT("/templates/:name", apiHandler.SetTemplate)
+		api.DELETE("/templates/:name", apiHandler.DeleteTemplate)
+		api.POST("/templates/:name/servers", apiHandler.AddServerFromTemplate)
+		api.POST("/templates/:name/rerender", apiHandler.RerenderTemplate)
+		api.GET("/oauth", apiHandler.GetOAuthStatus)
+		api.POST("/servers/:server/oauth", apiHandler.StartOAuth)
+		api.DELETE("/servers/:server/oauth", apiHandler.SignOutOAuth)
+		api.GET("/profiles", apiHandler.GetProfiles)
+		api.POST("/profiles/:name/activate", apiHandler.ActivateProfile)
 	}
 
-	htmx := r.Group("/htmx")
+	htmx := r.Group("/htmx", authHandler.RequireSession())
 	{
+		htmx.GET("/servers/rows", webHandler.ServerRows)
+		htmx.GET("/catalog", webHandler.CatalogResults)
+		htmx.GET("/templates", webHandler.Templates)
 		htmx.POST("/clients/:client/servers/:server/toggle", webHandler.ToggleClientServerHTMX)
+		htmx.POST("/projects/:project/servers/:server/toggle", webHandler.ToggleProjectServerHTMX)
+		htmx.POST("/servers/:server/oauth/signout", webHandler.SignOutOAuthHTMX)
 	}
 
-	address := fmt.Sprintf(":%d", cfg.ServerPort)
-	log.Printf("Starting MCP Manager server on %s", address)
-	if err := r.Run(address); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	if !authHandler.Enabled() {
+		slog.Warn("Authentication is disabled; only local connections are accepted")
+	}
+	if err := server.Run(r, cfg, actualConfigPath); err != nil {
+		fatal("Failed to start server", err)
 	}
+}
+
+// fatal logs an error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
\ No newline at end of file
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services"
+)
+
+// runValidate implements "validate": it checks the config file and its overlays
+// without starting the server and prints one line per problem as
+// file:line:column: severity: path: message, the format editors and CI annotate.
+// It returns the exit code: 0 without errors (warnings and infos are only
+// printed), 1 with errors, 2 on usage errors.
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	configPath := flags.String("config", "", "Path to config file (default: smart resolution)")
+	configShort := flags.String("c", "", "Path to config file (short form)")
+	format := flags.String("format", "text", "Output format: text or json")
+	schema := flags.String("schema", "", "Print the JSON Schema of \"config\" or \"server\" entries instead")
+	deep := flags.Bool("deep", false, "Also check npx/uvx packages, docker images and command paths")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	switch *schema {
+	case "":
+	case "config":
+		os.Stdout.Write(config.Schema())
+		return 0
+	case "server":
+		os.Stdout.Write(append(config.ServerSchema(), '\n'))
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown schema '%s': must be config or server\n", *schema)
+		return 2
+	}
+
+	path := *configPath
+	if *configShort != "" {
+		path = *configShort
+	}
+	path, err := config.FindConfigPath(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	validator := services.NewValidatorService()
+	validator.SetDeepChecks(*deep)
+	diagnostics, err := validator.ValidateConfigFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if err := writeDiagnostics(os.Stdout, *format, diagnostics); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == models.SeverityError {
+			return 1
+		}
+	}
+	return 0
+}
+
+func writeDiagnostics(w io.Writer, format string, diagnostics []config.Diagnostic) error {
+	switch format {
+	case "json":
+		if diagnostics == nil {
+			diagnostics = []config.Diagnostic{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diagnostics)
+	case "text":
+		for _, diagnostic := range diagnostics {
+			fmt.Fprintln(w, diagnostic)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown format '%s': must be text or json", format)
+}
@@ -14,7 +14,8 @@ const MCPManager = {
     // State
     state: {
         isSubmitting: false,
-        currentTheme: 'system'
+        currentTheme: 'system',
+        currentTag: ''
     },
 
     // DOM element getters
@@ -25,7 +26,32 @@ const MCPManager = {
         get errorText() { return document.getElementById('error-text'); },
         get newServerForm() { return document.getElementById('new-server-form'); },
         get addServerForm() { return document.getElementById('add-server-form'); },
-        get themeOptions() { return document.querySelectorAll('.theme-option'); }
+        get themeOptions() { return document.querySelectorAll('.theme-option'); },
+        get profileSelect() { return document.getElementById('profile-select'); },
+        get tagChips() { return document.querySelectorAll('#tag-filter .tag-chip'); },
+        get bulkClientSelect() { return document.getElementById('bulk-client'); },
+        get bulkButtons() { return document.querySelectorAll('[data-bulk-enabled]'); },
+        get serverRows() { return document.getElementById('server-rows'); },
+        get undoToast() { return document.getElementById('undo-toast'); },
+        get undoToastText() { return document.getElementById('undo-toast-text'); },
+        get undoButton() { return document.getElementById('undo-toast-undo'); },
+        get redoButton() { return document.getElementById('undo-toast-redo'); },
+        get catalogResults() { return document.getElementById('catalog-results'); },
+        get catalogSearch() { return document.querySelector('#catalog-panel .catalog-search'); },
+        get catalogSyncButton() { return document.getElementById('catalog-sync'); },
+        get catalogStatus() { return document.getElementById('catalog-status'); },
+        get templatesContent() { return document.getElementById('templates-content'); },
+        get csrfToken() { return document.querySelector('meta[name="csrf-token"]')?.content || ''; }
+    },
+
+    /**
+     * Returns request headers including the session's CSRF token
+     * @param {Object} headers - Additional headers
+     * @returns {Object} - Headers for fetch
+     */
+    headers(headers = {}) {
+        const token = this.elements.csrfToken;
+        return token ? { ...headers, 'X-CSRF-Token': token } : headers;
     }
 };
 
@@ -241,9 +267,10 @@ const ServerAPI = {
     /**
      * Submits new server configuration to backend
      * @param {Object} serverConfig - Server configuration object
+     * @param {boolean} confirmed - Add a command outside the policy allowlist
      * @returns {Promise<Object>} - API response
      */
-    async submitNewServer(serverConfig) {
+    async submitNewServer(serverConfig, confirmed = false) {
         // Extract server name and prepare config for v2.0 API format
         const serverName = serverConfig.name;
         const { name, clients, ...config } = serverConfig;
@@ -252,32 +279,339 @@ const ServerAPI = {
         const requestBody = {
             mcpServers: {
                 [serverName]: config
-            }
+            },
+            confirm: confirmed
         };
 
         const response = await fetch('/api/servers', {
             method: 'POST',
-            headers: {
+            headers: MCPManager.headers({
                 'Content-Type': 'application/json',
-            },
+            }),
             body: JSON.stringify(requestBody)
         });
 
         if (!response.ok) {
             const text = await response.text();
+            let errorData;
             try {
-                const errorData = JSON.parse(text);
-                const errorMessage = errorData.error || 'Server error';
-                throw new Error(errorMessage);
+                errorData = JSON.parse(text);
             } catch (parseError) {
                 throw new Error(text || 'Server error');
             }
+
+            // The command is outside the policy allowlist: ask before adding it
+            if (errorData.confirmation_required && !confirmed &&
+                confirm(`${errorData.error}\n\nAdd this server anyway?`)) {
+                return this.submitNewServer(serverConfig, true);
+            }
+            throw new Error(errorData.error || 'Server error');
         }
 
         return await response.json();
     }
 };
 
+/**
+ * Profile switching
+ */
+const ProfileManager = {
+    /**
+     * Activates a profile and reloads the dashboard with the new enabled lists
+     * @param {Event} event - Change event from the profile dropdown
+     */
+    async handleProfileChange(event) {
+        const select = event.target;
+        const profile = select.value;
+        const previous = select.dataset.active || '';
+
+        if (!profile || !confirm(`Activate profile "${profile}"? This rewrites every client's enabled servers and syncs all clients.`)) {
+            select.value = previous;
+            return;
+        }
+
+        try {
+            const response = await fetch(`/api/profiles/${encodeURIComponent(profile)}/activate`, {
+                method: 'POST',
+                headers: MCPManager.headers({ 'Content-Type': 'application/json' })
+            });
+            if (!response.ok) {
+                const errorData = await response.json().catch(() => ({}));
+                throw new Error(errorData.error || 'Failed to activate profile');
+            }
+            globalThis.location.reload();
+        } catch (error) {
+            alert(error.message);
+            select.value = previous;
+        }
+    },
+
+    /**
+     * Initializes the profile dropdown
+     */
+    init() {
+        const select = MCPManager.elements.profileSelect;
+        if (!select) return;
+
+        select.dataset.active = select.value;
+        select.addEventListener('change', (event) => this.handleProfileChange(event));
+    }
+};
+
+/**
+ * Tag filtering and bulk enable/disable
+ */
+const TagFilter = {
+    /**
+     * Shows only server rows carrying the selected tag (all rows for '')
+     * @param {string} tag - Tag to filter by
+     */
+    applyFilter(tag) {
+        MCPManager.state.currentTag = tag;
+
+        MCPManager.elements.tagChips.forEach(chip => {
+            chip.classList.toggle('active', chip.dataset.tag === tag);
+        });
+
+        document.querySelectorAll('tr[data-tags]').forEach(row => {
+            const visible = tag === '' || row.dataset.tags.includes(` ${tag} `);
+            row.classList.toggle('hidden', !visible);
+        });
+    },
+
+    /**
+     * Enables or disables every server matching the current filter
+     * @param {boolean} enabled - Target state
+     */
+    async applyBulk(enabled) {
+        const tag = MCPManager.state.currentTag;
+        const client = MCPManager.elements.bulkClientSelect?.value || '';
+        const selector = tag ? `tag:${tag}` : '*';
+        const target = client || 'all clients';
+        const action = enabled ? 'Enable' : 'Disable';
+
+        if (!confirm(`${action} ${tag ? `all "${tag}" servers` : 'all servers'} for ${target}?`)) {
+            return;
+        }
+
+        try {
+            const response = await fetch('/api/bulk', {
+                method: 'POST',
+                headers: MCPManager.headers({ 'Content-Type': 'application/json' }),
+                body: JSON.stringify({ selector, clients: client ? [client] : [], enabled })
+            });
+            if (!response.ok) {
+                const errorData = await response.json().catch(() => ({}));
+                throw new Error(errorData.error || 'Bulk update failed');
+            }
+            globalThis.location.reload();
+        } catch (error) {
+            alert(error.message);
+        }
+    },
+
+    /**
+     * Initializes filter chips and bulk action buttons
+     */
+    init() {
+        MCPManager.elements.tagChips.forEach(chip => {
+            chip.addEventListener('click', () => this.applyFilter(chip.dataset.tag));
+        });
+
+        MCPManager.elements.bulkButtons.forEach(button => {
+            button.addEventListener('click', () => this.applyBulk(button.dataset.bulkEnabled === 'true'));
+        });
+    }
+};
+
+/**
+ * Drag-and-drop server ordering
+ */
+const ServerOrder = {
+    dragged: null,
+
+    /**
+     * Returns the server names in the order currently shown
+     * @returns {string[]} - Server names
+     */
+    currentOrder() {
+        return Array.from(MCPManager.elements.serverRows.querySelectorAll('tr[data-server]'))
+            .map(row => row.dataset.server);
+    },
+
+    /**
+     * Saves the order shown in the table; restores the previous order on failure
+     * @param {string[]} previous - Order before the drag
+     */
+    async saveOrder(previous) {
+        const order = this.currentOrder();
+        if (order.join('\n') === previous.join('\n')) return;
+
+        try {
+            const response = await fetch('/api/servers/order', {
+                method: 'POST',
+                headers: MCPManager.headers({ 'Content-Type': 'application/json' }),
+                body: JSON.stringify({ order })
+            });
+            if (!response.ok) {
+                const errorData = await response.json().catch(() => ({}));
+                throw new Error(errorData.error || 'Failed to save server order');
+            }
+        } catch (error) {
+            alert(error.message);
+            const tbody = MCPManager.elements.serverRows;
+            previous.forEach(name => tbody.appendChild(document.getElementById(`server-${name}`)));
+        }
+    },
+
+    /**
+     * Initializes drag-and-drop on the server table rows
+     */
+    init() {
+        const tbody = MCPManager.elements.serverRows;
+        if (!tbody) return;
+
+        let previous = [];
+
+        tbody.addEventListener('dragstart', (event) => {
+            const row = event.target.closest?.('tr[data-server]');
+            if (!row) return;
+            this.dragged = row;
+            previous = this.currentOrder();
+            row.classList.add('dragging');
+            event.dataTransfer.effectAllowed = 'move';
+            event.dataTransfer.setData('text/plain', row.dataset.server);
+        });
+
+        tbody.addEventListener('dragover', (event) => {
+            const row = event.target.closest('tr[data-server]');
+            if (!this.dragged || !row || row === this.dragged) return;
+            event.preventDefault();
+
+            const rect = row.getBoundingClientRect();
+            const after = event.clientY > rect.top + rect.height / 2;
+            tbody.insertBefore(this.dragged, after ? row.nextSibling : row);
+        });
+
+        tbody.addEventListener('drop', (event) => event.preventDefault());
+
+        // Rows are reloaded from the event stream; hold that off during a drag
+        tbody.addEventListener('htmx:beforeRequest', (event) => {
+            if (this.dragged && event.detail.elt === tbody) event.preventDefault();
+        });
+
+        tbody.addEventListener('htmx:afterSwap', (event) => {
+            if (event.detail.elt === tbody) TagFilter.applyFilter(MCPManager.state.currentTag);
+        });
+
+        tbody.addEventListener('dragend', () => {
+            if (!this.dragged) return;
+            this.dragged.classList.remove('dragging');
+            this.dragged = null;
+            this.saveOrder(previous);
+        });
+    }
+};
+
+/**
+ * Server-side undo/redo with a toast and keyboard shortcuts
+ */
+const UndoManager = {
+    storageKey: 'mcp-undo-toast',
+    toastDuration: 8000,
+    hideTimer: null,
+
+    /**
+     * Shows the toast for the current undo/redo status
+     * @param {Object} status - {undo?: string, redo?: string} from the server
+     * @param {string} message - Text to show instead of the undoable operation
+     */
+    show(status, message) {
+        const toast = MCPManager.elements.undoToast;
+        if (!toast || !status || (!status.undo && !status.redo)) return;
+
+        MCPManager.elements.undoToastText.textContent = message || `Changed: ${status.undo}`;
+        MCPManager.elements.undoButton.classList.toggle('hidden', !status.undo);
+        MCPManager.elements.redoButton.classList.toggle('hidden', !status.redo);
+        toast.classList.remove('hidden');
+
+        clearTimeout(this.hideTimer);
+        this.hideTimer = setTimeout(() => toast.classList.add('hidden'), this.toastDuration);
+    },
+
+    /**
+     * Undoes or redoes the last operation, then reloads to show the restored state
+     * @param {string} action - 'undo' or 'redo'
+     */
+    async apply(action) {
+        try {
+            const response = await fetch(`/api/${action}`, {
+                method: 'POST',
+                headers: MCPManager.headers({ 'Content-Type': 'application/json' })
+            });
+            const data = await response.json().catch(() => ({}));
+            if (!response.ok) {
+                throw new Error(data.error || `Failed to ${action}`);
+            }
+
+            const verb = action === 'undo' ? 'Undone' : 'Redone';
+            sessionStorage.setItem(this.storageKey, JSON.stringify({
+                status: data.status,
+                message: `${verb}: ${data.operation}`
+            }));
+            globalThis.location.reload();
+        } catch (error) {
+            MCPManager.elements.undoToastText.textContent = error.message;
+            MCPManager.elements.undoToast?.classList.remove('hidden');
+        }
+    },
+
+    /**
+     * Ctrl+Z undoes; Ctrl+Shift+Z and Ctrl+Y redo. Text fields keep their own undo.
+     * @param {KeyboardEvent} event - Keydown event
+     */
+    handleKeydown(event) {
+        if (!(event.ctrlKey || event.metaKey) || event.altKey) return;
+
+        const target = event.target;
+        const isTextEntry = target.isContentEditable || target.tagName === 'TEXTAREA' ||
+            (target.tagName === 'INPUT' && !['checkbox', 'radio', 'button', 'submit'].includes(target.type));
+        if (isTextEntry) return;
+
+        const key = event.key.toLowerCase();
+        if (key === 'z') {
+            event.preventDefault();
+            this.apply(event.shiftKey ? 'redo' : 'undo');
+        } else if (key === 'y') {
+            event.preventDefault();
+            this.apply('redo');
+        }
+    },
+
+    /**
+     * Listens for changes made through HTMX and restores the toast after a reload
+     */
+    init() {
+        if (!MCPManager.elements.undoToast) return;
+
+        document.body.addEventListener('undoAvailable', (event) => this.show(event.detail));
+        MCPManager.elements.undoButton.addEventListener('click', () => this.apply('undo'));
+        MCPManager.elements.redoButton.addEventListener('click', () => this.apply('redo'));
+        document.addEventListener('keydown', (event) => this.handleKeydown(event));
+
+        const pending = sessionStorage.getItem(this.storageKey);
+        if (pending) {
+            sessionStorage.removeItem(this.storageKey);
+            try {
+                const { status, message } = JSON.parse(pending);
+                this.show(status, message);
+            } catch (error) {
+                // Ignore a malformed entry
+            }
+        }
+    }
+};
+
 /**
  * Theme management
  */
@@ -395,6 +729,240 @@ const ThemeManager = {
     }
 };
 
+/**
+ * Server catalog: install entries and sync from the registry
+ */
+const CatalogBrowser = {
+    /**
+     * Installs a catalog entry from its install form
+     * @param {HTMLFormElement} form - Install form of the entry
+     * @param {boolean} confirmed - Add a command outside the policy allowlist
+     */
+    async install(form, confirmed = false) {
+        const errorText = form.querySelector('.catalog-install-error');
+        errorText.textContent = '';
+
+        const values = {};
+        form.querySelectorAll('[data-input]').forEach(input => {
+            if (input.value.trim()) {
+                values[input.dataset.input] = input.value.trim();
+            }
+        });
+
+        try {
+            const response = await fetch('/api/catalog/install', {
+                method: 'POST',
+                headers: MCPManager.headers({ 'Content-Type': 'application/json' }),
+                body: JSON.stringify({
+                    entry: form.dataset.entry,
+                    name: form.elements.name.value.trim(),
+                    values,
+                    confirm: confirmed
+                })
+            });
+
+            if (!response.ok) {
+                const errorData = await response.json().catch(() => ({}));
+                if (errorData.confirmation_required && !confirmed &&
+                    confirm(`${errorData.error}\n\nAdd this server anyway?`)) {
+                    return this.install(form, true);
+                }
+                throw new Error(errorData.error || 'Failed to install server');
+            }
+
+            document.body.dispatchEvent(new CustomEvent('configChanged'));
+            globalThis.location.reload();
+        } catch (error) {
+            errorText.textContent = error.message;
+        }
+    },
+
+    /**
+     * Merges the registry index into the catalog and refreshes the results
+     */
+    async sync() {
+        const button = MCPManager.elements.catalogSyncButton;
+        const status = MCPManager.elements.catalogStatus;
+        button.disabled = true;
+        status.textContent = 'Syncing...';
+
+        try {
+            const response = await fetch('/api/catalog/sync', {
+                method: 'POST',
+                headers: MCPManager.headers({ 'Content-Type': 'application/json' })
+            });
+            const data = await response.json().catch(() => ({}));
+            if (!response.ok) {
+                throw new Error(data.error || 'Failed to sync catalog');
+            }
+
+            status.textContent = `${data.result.registry} servers from the registry, ${data.result.total} in the catalog`;
+            htmx.trigger(MCPManager.elements.catalogSearch, 'search');
+        } catch (error) {
+            status.textContent = error.message;
+        } finally {
+            button.disabled = false;
+        }
+    },
+
+    /**
+     * Handles install forms in the (swapped) results and the sync button
+     */
+    init() {
+        const results = MCPManager.elements.catalogResults;
+        if (!results) return;
+
+        results.addEventListener('submit', (event) => {
+            if (event.target.matches('form.catalog-install')) {
+                event.preventDefault();
+                this.install(event.target);
+            }
+        });
+
+        MCPManager.elements.catalogSyncButton?.addEventListener('click', () => this.sync());
+    }
+};
+
+/**
+ * Server templates: create servers, edit templates and re-render their servers
+ */
+const TemplateManager = {
+    /**
+     * Sends a JSON request and throws the API error on failure
+     * @param {string} url - API endpoint
+     * @param {string} method - HTTP method
+     * @param {Object} body - Request body
+     * @returns {Promise<Object>} - Response data
+     */
+    async request(url, method, body) {
+        const response = await fetch(url, {
+            method,
+            headers: MCPManager.headers({ 'Content-Type': 'application/json' }),
+            body: body === undefined ? undefined : JSON.stringify(body)
+        });
+        const data = await response.json().catch(() => ({}));
+        if (!response.ok) {
+            const error = new Error(data.error || 'Request failed');
+            error.confirmationRequired = data.confirmation_required;
+            throw error;
+        }
+        return data;
+    },
+
+    /**
+     * Creates a server from the template of the form
+     * @param {HTMLFormElement} form - Create form
+     * @param {string} template - Template name
+     * @param {boolean} confirmed - Add a command outside the policy allowlist
+     */
+    async createServer(form, template, confirmed = false) {
+        const params = {};
+        form.querySelectorAll('[data-param]').forEach(input => {
+            if (input.value.trim()) {
+                params[input.dataset.param] = input.value.trim();
+            }
+        });
+
+        try {
+            await this.request(`/api/templates/${encodeURIComponent(template)}/servers`, 'POST', {
+                name: form.elements.name.value.trim(),
+                params,
+                confirm: confirmed
+            });
+            globalThis.location.reload();
+        } catch (error) {
+            if (error.confirmationRequired && !confirmed && confirm(`${error.message}\n\nAdd this server anyway?`)) {
+                return this.createServer(form, template, true);
+            }
+            throw error;
+        }
+    },
+
+    /**
+     * Sends a request, asking once to confirm a command outside the policy allowlist
+     * @param {Function} send - Sends the request, given whether it is confirmed
+     * @param {string} question - What confirming does
+     */
+    async withConfirmation(send, question) {
+        try {
+            return await send(false);
+        } catch (error) {
+            if (error.confirmationRequired && confirm(`${error.message}\n\n${question}`)) {
+                return send(true);
+            }
+            throw error;
+        }
+    },
+
+    /**
+     * Saves a template, then offers to re-render the servers made from it
+     * @param {HTMLFormElement} form - Edit form
+     * @param {string} template - Template name
+     */
+    async saveTemplate(form, template) {
+        let definition;
+        try {
+            definition = JSON.parse(form.elements.definition.value);
+        } catch (parseError) {
+            throw new Error(`Invalid JSON: ${parseError.message}`);
+        }
+
+        const url = `/api/templates/${encodeURIComponent(template)}`;
+        const data = await this.withConfirmation(
+            confirmed => this.request(confirmed ? `${url}?confirm=true` : url, 'PUT', definition),
+            'Save this template anyway?');
+        const servers = data.servers || [];
+        if (servers.length > 0 &&
+            confirm(`Re-render ${servers.length} server(s) made from "${template}" (${servers.join(', ')})? Their client files are rewritten.`)) {
+            await this.withConfirmation(
+                confirmed => this.request(`${url}/rerender`, 'POST', { servers, confirm: confirmed }),
+                'Re-render these servers anyway?');
+        }
+        document.body.dispatchEvent(new CustomEvent('configChanged'));
+    },
+
+    /**
+     * Handles the forms of the (swapped) template panel
+     */
+    init() {
+        const content = MCPManager.elements.templatesContent;
+        if (!content) return;
+
+        content.addEventListener('submit', async (event) => {
+            const form = event.target;
+            const entry = form.closest('[data-template]');
+            event.preventDefault();
+
+            const errorText = form.querySelector('.template-error');
+            errorText.textContent = '';
+            try {
+                if (form.matches('.template-create')) {
+                    await this.createServer(form, entry.dataset.template);
+                } else if (form.matches('.template-new')) {
+                    await this.saveTemplate(form, form.elements.template.value.trim());
+                } else if (form.matches('.template-edit')) {
+                    await this.saveTemplate(form, entry.dataset.template);
+                }
+            } catch (error) {
+                errorText.textContent = error.message;
+            }
+        });
+
+        content.addEventListener('click', async (event) => {
+            if (!event.target.matches('.template-delete')) return;
+
+            const template = event.target.closest('[data-template]').dataset.template;
+            if (!confirm(`Delete template "${template}"?`)) return;
+            try {
+                await this.request(`/api/templates/${encodeURIComponent(template)}`, 'DELETE');
+                document.body.dispatchEvent(new CustomEvent('configChanged'));
+            } catch (error) {
+                alert(error.message);
+            }
+        });
+    }
+};
+
 /**
  * Example configurations for different transport types
  */
@@ -520,9 +1088,31 @@ const EventHandlers = {
         // Initialize theme system
         ThemeManager.init();
 
+        // Initialize profile dropdown
+        ProfileManager.init();
+
+        // Initialize tag filter and bulk actions
+        TagFilter.init();
+
+        // Initialize drag-and-drop server ordering
+        ServerOrder.init();
+
+        // Initialize undo toast and keyboard shortcuts
+        UndoManager.init();
+
+        // Initialize the server catalog
+        CatalogBrowser.init();
+
+        // Initialize server templates
+        TemplateManager.init();
+
         // HTMX configuration
         document.body.addEventListener('htmx:configRequest', function(evt) {
-            evt.detail.headers['Content-Type'] = 'application/x-www-form-urlencoded';
+            // The API only accepts JSON for requests that change state
+            evt.detail.headers['Content-Type'] = evt.detail.path.startsWith('/api/')
+                ? 'application/json'
+                : 'application/x-www-form-urlencoded';
+            Object.assign(evt.detail.headers, MCPManager.headers());
         });
 
         // Re-highlight code after HTMX updates
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 )
@@ -16,7 +17,7 @@ func resolveConfigPath(configPath string) (string, error) {
 			if err := createDefaultConfig(expanded); err != nil {
 				return "", fmt.Errorf("specified config file not found and could not create: %s", expanded)
 			}
-			fmt.Printf("Created config file at: %s\n", expanded)
+			slog.Info("Created config file", "path", expanded)
 		}
 		return expanded, nil
 	}
@@ -27,16 +28,8 @@ func resolveConfigPath(configPath string) (string, error) {
 	// 3. configs/config.yaml (relative to binary)
 	// 4. Auto-create user config if none found
 
-	candidates := []string{
-		ExpandPath("~/.config/mcp-server-manager/config.yaml"),
-		"./config.yaml",
-		DefaultConfigPath,
-	}
-
-	for _, path := range candidates {
-		if _, err := os.Stat(path); err == nil {
-			return path, nil
-		}
+	if path, found := findConfig(); found {
+		return path, nil
 	}
 
 	// No config found, auto-create user config
@@ -45,12 +38,44 @@ func resolveConfigPath(configPath string) (string, error) {
 		return "", fmt.Errorf("failed to create default config: %w", err)
 	}
 
-	fmt.Printf("Created default config file at: %s\n", userConfigPath)
-	fmt.Println("Please edit this file to configure your MCP servers and clients.")
+	slog.Info("Created default config file; edit it to configure your MCP servers and clients", "path", userConfigPath)
 
 	return userConfigPath, nil
 }
 
+// FindConfigPath resolves the config file like LoadConfig, but never creates
+// one: an explicit path must exist, otherwise the usual locations are searched
+func FindConfigPath(configPath string) (string, error) {
+	if configPath != "" {
+		expanded := ExpandPath(configPath)
+		if _, err := os.Stat(expanded); err != nil {
+			return "", fmt.Errorf("config file '%s' not found: %w", expanded, err)
+		}
+		return expanded, nil
+	}
+
+	if path, found := findConfig(); found {
+		return path, nil
+	}
+	return "", fmt.Errorf("no config file found; pass one with -config")
+}
+
+// findConfig returns the first existing config file in the usual locations
+func findConfig() (string, bool) {
+	candidates := []string{
+		ExpandPath("~/.config/mcp-server-manager/config.yaml"),
+		"./config.yaml",
+		DefaultConfigPath,
+	}
+
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
 // createDefaultConfig creates a default config file with example configuration
 func createDefaultConfig(configPath string) error {
 	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
@@ -60,8 +85,135 @@ func createDefaultConfig(configPath string) error {
 	defaultConfig := `# MCP Server Manager Configuration v2.0
 # This matches standard MCP client config format for maximum compatibility
 # Edit this file to configure your MCP servers and clients
+#
+# Per-machine overrides: config.d/*.yaml (in lexical order) and then
+# config.<hostname>.yaml next to this file are merged over it. Mappings merge
+# key by key, lists and values are replaced. Changes made in the manager are
+# written back to the file a value came from; new servers go to this file.
+#
+# Check this file and its overlays with "mcp-server-manager validate", which
+# prints file:line:column for every problem. Editors with YAML language support
+# can use the JSON Schema from "mcp-server-manager validate -schema config" or
+# http://127.0.0.1:6543/schema/config.json while the manager runs.
 
 server_port: 6543
+bind_address: 127.0.0.1   # Listen on this machine only
+
+# Authentication (required when bind_address is not a loopback address).
+# API clients send "Authorization: Bearer <token>"; the web UI signs in with
+# the password (or a token) and keeps a session cookie.
+# auth:
+#   tokens:
+#     - "replace-with-a-long-random-token"
+#   password_hash: "$2y$10$..."   # bcrypt, e.g. htpasswd -nbBC 10 "" secret | tr -d ':\n'
+#   session_ttl_hours: 24
+
+# HTTPS (optional). Without cert_file/key_file a self-signed certificate is
+# created in a "tls" directory next to this file.
+# tls:
+#   enabled: true
+#   cert_file: "~/.config/mcp-server-manager/cert.pem"
+#   key_file: "~/.config/mcp-server-manager/key.pem"
+
+# Unix domain socket (optional) - replaces the TCP listener; access is controlled
+# by the socket's file mode, e.g. curl --unix-socket <path> http://localhost/api/servers
+# unix_socket:
+#   path: "~/.config/mcp-server-manager/manager.sock"
+#   mode: "0600"
+
+# Public URL (optional) - where browsers reach the manager when that is not the
+# bind address, e.g. behind a reverse proxy or on a Unix socket. OAuth sign-ins
+# return to <public_url>/oauth/callback, and requests must address its host.
+# public_url: "https://mcp.example.com"
+
+# Git versioning (optional) - commits config.yaml after every change, with a
+# message describing it. The directory holding this file becomes a git
+# repository unless it is already inside one; no remote is needed.
+# git:
+#   enabled: true
+#   author_name: "MCP Server Manager"        # Only used when git has no user.name
+#   author_email: "mcp@localhost"            # Only used when git has no user.email
+
+# Logging (optional) - structured logs on stderr, e.g. for journalctl
+# logging:
+#   level: info       # debug, info, warn or error; debug also logs every file read and write
+#   format: text      # text or json
+
+# Server catalog (optional) - known servers offered under "Browse Catalog" in
+# the web UI. Without a catalog file a small built-in catalog is used. A sync
+# merges the servers of a registry-format index (the MCP registry's /v0/servers
+# API or a file in the same format) into the catalog file.
+# catalog:
+#   path: "~/.config/mcp-server-manager/catalog.yaml"   # YAML or JSON
+#   registry_url: "https://registry.modelcontextprotocol.io/v0/servers"
+
+# Server templates (optional) - reusable server configs with typed parameters
+# (string, int, bool, path, enum). Values use Go template syntax; a value that
+# is only "{{.param}}" keeps the parameter's type. Servers created from a
+# template remember it and can be re-rendered after the template changes.
+# templates:
+#   git-repo:
+#     description: "Git server for one repository"
+#     params:
+#       - name: repo
+#         type: path
+#         required: true
+#     config:
+#       command: "uvx"
+#       args: ["mcp-server-git", "--repository", "{{.repo}}"]
+
+# Variables (optional) - ${NAME} references in command, args, cwd, url, httpUrl
+# and env are expanded when writing client files, so one config works on
+# machines with different paths. ${HOME} is built in; ${workspace} is the
+# project directory in project files (a workspace var is the default elsewhere).
+# A leading "~" in command and cwd is expanded too. Other references,
+# e.g. "${API_KEY}", are written as is for the client to resolve.
+# vars:
+#   code_dir: "${HOME}/code"
+#   workspace: "${HOME}/code/main-project"
+
+# Health checks (optional) - stdio servers are healthy when their command is
+# found, HTTP servers when their URL answers without a server error. Changes are
+# shown in the web UI and published on /api/events.
+# health:
+#   enabled: true
+#   interval_seconds: 60
+#   timeout_seconds: 5
+
+# Notifications (optional) - events are POSTed as JSON to webhooks and shown as
+# desktop notifications via notify-send. Health changes are only sent when a
+# server starts failing or recovers. Event types: server_added, server_toggled,
+# synced, drift_detected, health_changed, config_reloaded.
+# notifications:
+#   webhooks:
+#     - url: "https://hooks.example.com/mcp"
+#       events: [health_changed, drift_detected]   # Default: all events
+#       secret: "shared-secret"    # Adds X-MCP-Signature-256: sha256=<HMAC of body>
+#       max_retries: 3             # Retried with backoff on errors, 429 and 5xx
+#   desktop:
+#     enabled: true                # Default events: health_changed, drift_detected
+
+# Validation (optional) - a stdio command that is not installed on this machine
+# is a warning by default: the server is flagged in the web UI and by
+# "mcp-server-manager validate", but every other change keeps working. Set
+# missing_commands to error to refuse saving such a config, or info to hush it.
+# deep_checks also looks at what a command starts: whether the npx or uvx
+# package is well-formed and already cached, whether a docker image is present
+# locally and why a command path cannot be run ("validate -deep" for one run).
+# validation:
+#   missing_commands: warning   # error, warning or info
+#   deep_checks: false
+
+# Command policy (optional) for stdio servers added through the web UI or API.
+# Commands must match an allowed entry exactly ("npx", not "/tmp/npx"); anything
+# else is only added after an explicit confirmation. Arguments matching a denied
+# pattern (regular expressions) are always rejected.
+# policy:
+#   allowed_commands: [npx, uvx, docker]
+#   denied_args:
+#     - "^--privileged$"
+#     - "docker\\.sock"
+#     - "^-(e|c|-eval)$"
 
 # MCP Servers - Standard format matching MCP clients
 # Server names are keys; configurations are values (pass through to clients)
@@ -74,6 +226,7 @@ mcpServers:
       NODE_ENV: "production"
     timeout: 30000  # Optional: request timeout in ms
     trust: false    # Optional: bypass tool confirmations
+    tags: [local]   # Optional: manager-only labels for filtering and bulk actions (never written to clients)
 
   # HTTP Transport Example (with type field for VS Code compatibility)
   context7-vscode:
@@ -98,6 +251,18 @@ mcpServers:
   #     Authorization: "Bearer YOUR_TOKEN"
   #   timeout: 15000
 
+  # OAuth Example (uncomment to use) - for remote servers that require signing
+  # in. Click "Sign in" next to the server in the web UI: the manager discovers
+  # the authorization server, registers itself, runs the browser login with PKCE
+  # and writes "Authorization: Bearer <token>" to global client files, renewing
+  # it before it expires. Tokens are kept in oauth-tokens.json next to this file.
+  # remote_server:
+  #   type: "http"
+  #   url: "https://mcp.example.com/mcp"
+  #   oauth:
+  #     scopes: [read]          # Optional: default from the server's metadata
+  #     client_id: "my-client"  # Optional: skips dynamic client registration
+
   # Advanced STDIO Example with tool filtering
   # git_server:
   #   command: "npx"
@@ -125,6 +290,39 @@ clients:
       # - context7-gemini
       # - filesystem
 
+  # Settings files with // comments or trailing commas (VS Code, Zed, Cursor) are
+  # detected automatically and keep their comments; set format: json to reject them
+  # cursor:
+  #   config_path: "~/.cursor/mcp.json"
+  #   format: jsonc
+
+# Projects (optional) - project-scoped client files written into a repository:
+# claude_code -> .mcp.json, cursor -> .cursor/mcp.json, vscode -> .vscode/mcp.json
+# projects:
+#   my-repo:
+#     path: "~/src/my-repo"
+#     clients: [claude_code, vscode]
+#     enabled:
+#       - filesystem
+
+# Profiles (optional) - named sets of enabled servers per client, switchable
+# from the header dropdown or POST /api/profiles/<name>/activate
+# profiles:
+#   work:
+#     claude_code: [filesystem, context7-vscode]
+#     gemini_cli: [context7-gemini]
+#   demo:
+#     claude_code: [filesystem]
+
+# Client file watcher (optional) - detects when a client rewrites its config
+# and drops or changes a managed server entry. It also reloads this file when
+# it is edited while the manager is running.
+# watch:
+#   enabled: true
+#   mode: notify        # notify (record drift) or auto_heal (re-apply desired state)
+#   interval_ms: 2000   # How often client files are checked
+#   debounce_ms: 1500   # Wait until a file is quiet before reacting
+
 # Notes:
 # - ALL fields in mcpServers are passed through to client configs (no filtering)
 # - Supports any MCP spec fields: type, url, httpUrl, command, args, env, headers, etc.
@@ -0,0 +1,462 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// hostname names the host-specific layer; replaced in tests
+var hostname = os.Hostname
+
+// layer is one file contributing to the configuration
+type layer struct {
+	path string
+	doc  *yaml.Node // Top-level mapping
+}
+
+// ConfigLayers describes the files a configuration is merged from
+type ConfigLayers struct {
+	Files   []string          `json:"files"`   // Base file first, then the overlays in merge order
+	Origins map[string]string `json:"origins"` // Dotted value path -> file that set it
+}
+
+// LayerPaths returns the files merged into the configuration of basePath, in
+// merge order: the base file, the drop-ins of <name>.d/*.yaml in lexical order,
+// then <name>.<hostname>.yaml. Overlays that do not exist are left out.
+func LayerPaths(basePath string) ([]string, error) {
+	dir := filepath.Dir(basePath)
+	ext := filepath.Ext(basePath)
+	stem := strings.TrimSuffix(filepath.Base(basePath), ext)
+
+	paths := []string{basePath}
+
+	dropIns, err := filepath.Glob(filepath.Join(DropInDir(basePath), "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list drop-ins of '%s': %w", basePath, err)
+	}
+	paths = append(paths, dropIns...)
+
+	if host, err := hostname(); err == nil && host != "" {
+		hostPath := filepath.Join(dir, stem+"."+host+ext)
+		if _, err := os.Stat(hostPath); err == nil {
+			paths = append(paths, hostPath)
+		}
+	}
+
+	return paths, nil
+}
+
+// DropInDir returns the drop-in directory of a config file, e.g. config.d
+func DropInDir(basePath string) string {
+	return strings.TrimSuffix(basePath, filepath.Ext(basePath)) + ".d"
+}
+
+// ReadConfigData returns the configuration of basePath as YAML content: the
+// base file as is when it has no overlays, otherwise all layers merged
+func ReadConfigData(basePath string) ([]byte, error) {
+	data, err := os.ReadFile(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return MergeOverlays(data, basePath)
+}
+
+// MergeOverlays merges the current overlays of basePath over base content, e.g.
+// config.yaml from an earlier revision. Mappings are merged key by key with later
+// layers winning; sequences and scalars are replaced as a whole. The result is
+// in the form MarshalConfig writes, so it equals what the manager last saved
+// when nothing changed outside it.
+func MergeOverlays(baseData []byte, basePath string) ([]byte, error) {
+	paths, err := LayerPaths(basePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 1 {
+		return baseData, nil
+	}
+
+	base, err := parseLayer(basePath, baseData)
+	if err != nil {
+		return nil, err
+	}
+	overlays, err := readLayers(paths[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := yaml.Marshal(mergeLayers(append([]*layer{base}, overlays...)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge config layers: %w", err)
+	}
+	merged, err := ParseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalConfig(merged)
+}
+
+// ReadConfigLayers lists the layers of basePath and the file each value comes
+// from. Paths are relative to the directory of the base file.
+func ReadConfigLayers(basePath string) (*ConfigLayers, error) {
+	paths, err := LayerPaths(basePath)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := readLayers(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(basePath)
+	result := &ConfigLayers{Origins: make(map[string]string)}
+	for _, l := range layers {
+		name, err := filepath.Rel(dir, l.path)
+		if err != nil {
+			name = l.path
+		}
+		result.Files = append(result.Files, name)
+		walkLeaves(l.doc, nil, func(path []string) {
+			result.Origins[strings.Join(path, ".")] = name
+		})
+	}
+
+	// A later layer may have replaced a mapping of an earlier one with a value
+	merged := mergeLayers(layers)
+	for path := range result.Origins {
+		if lookupNode(merged, strings.Split(path, ".")) == nil {
+			delete(result.Origins, path)
+		}
+	}
+	return result, nil
+}
+
+// ServerOverlays groups the fields of a server that come from overlays by file
+func (l *ConfigLayers) ServerOverlays(serverName string) map[string][]string {
+	prefix := "mcpServers." + serverName + "."
+	overlays := make(map[string][]string)
+	seen := make(map[string]bool)
+	for path, file := range l.Origins {
+		if file == l.Files[0] || !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		field := strings.SplitN(strings.TrimPrefix(path, prefix), ".", 2)[0]
+		if !seen[file+"\x00"+field] {
+			seen[file+"\x00"+field] = true
+			overlays[file] = append(overlays[file], field)
+		}
+	}
+	for _, fields := range overlays {
+		sort.Strings(fields)
+	}
+	return overlays
+}
+
+// saveLayered writes new config content back to the given layers. A
+// changed value is written to the layer it came from; a new key to the first
+// layer defining its parent (so new servers go to the base file); a removed key
+// is deleted from every layer. Keys no layer has are left out while their value
+// is the one loading fills in, e.g. the default bind_address. Only files whose
+// content changes are written.
+func saveLayered(data []byte, paths []string) error {
+	var updated yaml.Node
+	if err := yaml.Unmarshal(data, &updated); err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	layers, err := readLayers(paths)
+	if err != nil {
+		return err
+	}
+	before := make([][]byte, len(layers))
+	for i, l := range layers {
+		if before[i], err = yaml.Marshal(l.doc); err != nil {
+			return fmt.Errorf("failed to encode '%s': %w", l.path, err)
+		}
+	}
+
+	current := mergeLayers(layers)
+	applyChanges(layers, current, loadedForm(current), updated.Content[0], nil)
+	orderServers(layers, updated.Content[0])
+
+	for i, l := range layers {
+		after, err := yaml.Marshal(l.doc)
+		if err != nil {
+			return fmt.Errorf("failed to encode '%s': %w", l.path, err)
+		}
+		if bytes.Equal(after, before[i]) {
+			continue
+		}
+		if err := os.WriteFile(l.path, after, 0644); err != nil {
+			return fmt.Errorf("failed to write config layer '%s': %w", l.path, err)
+		}
+	}
+	return nil
+}
+
+// readLayers reads layer files; a missing base file counts as empty
+func readLayers(paths []string) ([]*layer, error) {
+	layers := make([]*layer, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read config layer '%s': %w", path, err)
+		}
+		l, err := parseLayer(path, data)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, l)
+	}
+	return layers, nil
+}
+
+func parseLayer(path string, data []byte) (*layer, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config layer '%s': %w", path, err)
+	}
+
+	if len(doc.Content) == 0 || (doc.Content[0].Kind == yaml.ScalarNode && doc.Content[0].Tag == "!!null") {
+		return &layer{path: path, doc: &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}}, nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config layer '%s' must be a YAML mapping", path)
+	}
+	return &layer{path: path, doc: doc.Content[0]}, nil
+}
+
+// mergeLayers returns a new mapping with the layers merged in order
+func mergeLayers(layers []*layer) *yaml.Node {
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, l := range layers {
+		mergeNode(merged, l.doc)
+	}
+	return merged
+}
+
+func mergeNode(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		existing := mappingValue(dst, key.Value)
+		if existing != nil && existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			mergeNode(existing, value)
+			continue
+		}
+		setMappingValue(dst, key.Value, copyNode(value))
+	}
+}
+
+// loadedForm returns merged layer content as the loader turns it into a
+// config, with defaults filled in, or nil when it does not load
+func loadedForm(merged *yaml.Node) *yaml.Node {
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil
+	}
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return nil
+	}
+	if data, err = MarshalConfig(cfg); err != nil {
+		return nil
+	}
+
+	var doc yaml.Node
+	if yaml.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	return doc.Content[0]
+}
+
+// applyChanges updates the layers so that they merge to updated instead of
+// current; loaded is current with the loader's defaults filled in
+func applyChanges(layers []*layer, current, loaded, updated *yaml.Node, path []string) {
+	for i := 0; i+1 < len(updated.Content); i += 2 {
+		key, value := updated.Content[i].Value, updated.Content[i+1]
+		keyPath := append(path[:len(path):len(path)], key)
+		old := mappingValue(current, key)
+
+		switch {
+		case old == nil:
+			if filled := mappingValue(loaded, key); filled != nil && nodesEqual(filled, value) {
+				continue // Loading yields this value without it being written anywhere
+			}
+			setNode(firstDefining(layers, path).doc, keyPath, value)
+		case old.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			applyChanges(layers, old, mappingValue(loaded, key), value, keyPath)
+		case !nodesEqual(old, value):
+			// Earlier layers keep their shadowed values, unless parts of a
+			// replaced mapping would show through
+			owner := lastDefining(layers, keyPath)
+			if old.Kind == yaml.MappingNode {
+				for _, l := range layers {
+					deleteNode(l.doc, keyPath)
+				}
+			}
+			setNode(owner.doc, keyPath, value)
+		}
+	}
+
+	for i := 0; i+1 < len(current.Content); i += 2 {
+		key := current.Content[i].Value
+		if mappingValue(updated, key) == nil {
+			keyPath := append(path[:len(path):len(path)], key)
+			for _, l := range layers {
+				deleteNode(l.doc, keyPath)
+			}
+		}
+	}
+}
+
+// orderServers sorts the servers of every layer in the order of the updated
+// config; servers only defined in overlays still merge after those of the base
+func orderServers(layers []*layer, updated *yaml.Node) {
+	servers := mappingValue(updated, "mcpServers")
+	if servers == nil {
+		return
+	}
+	position := make(map[string]int)
+	for i := 0; i+1 < len(servers.Content); i += 2 {
+		position[servers.Content[i].Value] = i
+	}
+
+	for _, l := range layers {
+		node := mappingValue(l.doc, "mcpServers")
+		if node == nil || node.Kind != yaml.MappingNode {
+			continue
+		}
+		pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+		}
+		for i := 1; i < len(pairs); i++ {
+			for j := i; j > 0 && position[pairs[j][0].Value] < position[pairs[j-1][0].Value]; j-- {
+				pairs[j], pairs[j-1] = pairs[j-1], pairs[j]
+			}
+		}
+		node.Content = node.Content[:0]
+		for _, pair := range pairs {
+			node.Content = append(node.Content, pair[0], pair[1])
+		}
+	}
+}
+
+// firstDefining returns the first layer containing path; the base for the root
+func firstDefining(layers []*layer, path []string) *layer {
+	for len(path) > 0 {
+		for _, l := range layers {
+			if lookupNode(l.doc, path) != nil {
+				return l
+			}
+		}
+		path = path[:len(path)-1]
+	}
+	return layers[0]
+}
+
+// lastDefining returns the last layer containing path, whose value wins the merge
+func lastDefining(layers []*layer, path []string) *layer {
+	for i := len(layers) - 1; i >= 0; i-- {
+		if lookupNode(layers[i].doc, path) != nil {
+			return layers[i]
+		}
+	}
+	return layers[0]
+}
+
+// walkLeaves calls fn with the path of every value that is not a non-empty mapping
+func walkLeaves(node *yaml.Node, path []string, fn func([]string)) {
+	if node.Kind != yaml.MappingNode || (len(node.Content) == 0 && len(path) > 0) {
+		fn(path)
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		walkLeaves(node.Content[i+1], append(path[:len(path):len(path)], node.Content[i].Value), fn)
+	}
+}
+
+func lookupNode(node *yaml.Node, path []string) *yaml.Node {
+	for _, key := range path {
+		if node = mappingValue(node, key); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// setNode stores a copy of value at path, creating mappings along the way
+func setNode(node *yaml.Node, path []string, value *yaml.Node) {
+	for _, key := range path[:len(path)-1] {
+		child := mappingValue(node, key)
+		if child == nil || child.Kind != yaml.MappingNode {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(node, key, child)
+		}
+		node = child
+	}
+	setMappingValue(node, path[len(path)-1], copyNode(value))
+}
+
+func deleteNode(node *yaml.Node, path []string) {
+	parent := lookupNode(node, path[:len(path)-1])
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == path[len(path)-1] {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+func copyNode(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = copyNode(child)
+	}
+	return &copied
+}
+
+// nodesEqual compares the values of two nodes, ignoring style and comments
+func nodesEqual(a, b *yaml.Node) bool {
+	var va, vb interface{}
+	if a.Decode(&va) != nil || b.Decode(&vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
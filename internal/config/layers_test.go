@@ -0,0 +1,262 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+const layerBase = `server_port: 6543
+mcpServers:
+  alpha:
+    command: echo
+    args: ["base"]
+    env:
+      SHARED: base
+  beta:
+    command: echo
+clients:
+  test_client:
+    config_path: "~/.test.json"
+    enabled: [alpha]
+`
+
+const layerDropIn = `mcpServers:
+  alpha:
+    env:
+      TEAM: team
+  gamma:
+    command: echo
+    args: ["team"]
+`
+
+const layerHost = `mcpServers:
+  alpha:
+    args: ["host"]
+clients:
+  test_client:
+    config_path: "/opt/client.json"
+`
+
+// setupLayers writes a base config, one drop-in and a host file for "testhost"
+func setupLayers(t *testing.T) (configPath, dropInPath, hostPath string) {
+	t.Helper()
+	original := hostname
+	hostname = func() (string, error) { return "testhost", nil }
+	t.Cleanup(func() { hostname = original })
+
+	tempDir := t.TempDir()
+	configPath = filepath.Join(tempDir, testutil.TestConfigYAML)
+	dropInPath = filepath.Join(tempDir, "config.d", "10-team.yaml")
+	hostPath = filepath.Join(tempDir, "config.testhost.yaml")
+
+	testutil.WriteTestFile(t, configPath, layerBase)
+	testutil.WriteTestFile(t, dropInPath, layerDropIn)
+	testutil.WriteTestFile(t, hostPath, layerHost)
+	return configPath, dropInPath, hostPath
+}
+
+func TestLayerPaths(t *testing.T) {
+	configPath, dropInPath, hostPath := setupLayers(t)
+	laterDropIn := filepath.Join(filepath.Dir(dropInPath), "20-more.yaml")
+	testutil.WriteTestFile(t, laterDropIn, "{}\n")
+	testutil.WriteTestFile(t, filepath.Join(filepath.Dir(dropInPath), "notes.txt"), "ignored")
+
+	paths, err := LayerPaths(configPath)
+	if err != nil {
+		t.Fatalf("LayerPaths failed: %v", err)
+	}
+	want := []string{configPath, dropInPath, laterDropIn, hostPath}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Unexpected layers:\n got %v\nwant %v", paths, want)
+	}
+
+	// Without overlays the base file is read as is
+	single := filepath.Join(t.TempDir(), testutil.TestConfigYAML)
+	testutil.WriteTestFile(t, single, layerBase)
+	data, err := ReadConfigData(single)
+	if err != nil || string(data) != layerBase {
+		t.Errorf("Expected the base content unchanged, got %q, %v", data, err)
+	}
+}
+
+func TestLoadConfig_Layers(t *testing.T) {
+	configPath, _, _ := setupLayers(t)
+
+	cfg, _, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf(testutil.ErrLoadConfigFailedFmt, err)
+	}
+
+	var names []string
+	for _, server := range cfg.MCPServers {
+		names = append(names, server.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"alpha", "beta", "gamma"}) {
+		t.Errorf("Expected base servers followed by overlay servers, got %v", names)
+	}
+
+	alpha := cfg.MCPServers[0].Config
+	if !reflect.DeepEqual(alpha["args"], []interface{}{"host"}) {
+		t.Errorf("Expected the host file to replace args, got %v", alpha["args"])
+	}
+	if !reflect.DeepEqual(alpha["env"], map[string]interface{}{"SHARED": "base", "TEAM": "team"}) {
+		t.Errorf("Expected env merged from base and drop-in, got %v", alpha["env"])
+	}
+
+	client := cfg.Clients[testutil.TestClientName]
+	if client.ConfigPath != "/opt/client.json" || !reflect.DeepEqual(client.Enabled, []string{"alpha"}) {
+		t.Errorf("Expected the host config_path and the base enabled list, got %+v", client)
+	}
+}
+
+func TestSaveConfig_Layers(t *testing.T) {
+	configPath, dropInPath, hostPath := setupLayers(t)
+
+	cfg, _, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf(testutil.ErrLoadConfigFailedFmt, err)
+	}
+
+	// args of alpha come from the host file, env.TEAM from the drop-in, beta from the base
+	cfg.MCPServers[0].Config["args"] = []interface{}{"host", "changed"}
+	cfg.MCPServers[0].Config["env"].(map[string]interface{})["TEAM"] = "team-changed"
+	cfg.MCPServers[1].Config["args"] = []interface{}{"beta"}
+	cfg.MCPServers = append(cfg.MCPServers[:2], models.MCPServer{Name: "delta", Config: map[string]interface{}{"command": "echo"}})
+	cfg.Clients[testutil.TestClientName].Enabled = []string{"alpha", "beta"}
+
+	if err := SaveConfig(cfg, configPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	read := func(path string) string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+		return string(data)
+	}
+	base, dropIn, host := read(configPath), read(dropInPath), read(hostPath)
+
+	for _, check := range []struct {
+		file, content, want string
+		present             bool
+	}{
+		{"host", host, "changed", true},
+		{"host", host, "/opt/client.json", true},
+		{"base", base, "changed", false},
+		{"base", base, `args: ["base"]`, true}, // Shadowed args stay as written
+		{"base", base, "/opt/client.json", false},
+		{"base", base, "delta", true},
+		{"base", base, "- beta", true},
+		{"drop-in", dropIn, "team-changed", true},
+		{"drop-in", dropIn, "gamma", false},
+		{"base", base, "bind_address", false}, // Loader defaults are not persisted
+	} {
+		if strings.Contains(check.content, check.want) != check.present {
+			t.Errorf("%s file: expected %q present=%v, got:\n%s", check.file, check.want, check.present, check.content)
+		}
+	}
+
+	// The layers merge back to the saved config
+	reloaded, _, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf(testutil.ErrLoadConfigFailedFmt, err)
+	}
+	want, _ := MarshalConfig(cfg)
+	got, _ := MarshalConfig(reloaded)
+	if string(got) != string(want) {
+		t.Errorf("Reloaded config differs:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestSaveConfig_LayersReorderServers(t *testing.T) {
+	configPath, _, _ := setupLayers(t)
+
+	cfg, _, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf(testutil.ErrLoadConfigFailedFmt, err)
+	}
+	cfg.MCPServers[0], cfg.MCPServers[1] = cfg.MCPServers[1], cfg.MCPServers[0]
+
+	if err := SaveConfig(cfg, configPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	reloaded, _, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf(testutil.ErrLoadConfigFailedFmt, err)
+	}
+	if reloaded.MCPServers[0].Name != "beta" || reloaded.MCPServers[1].Name != "alpha" {
+		t.Errorf("Expected the new order to be kept, got %s, %s", reloaded.MCPServers[0].Name, reloaded.MCPServers[1].Name)
+	}
+}
+
+func TestSaveConfig_LayersChangedDefault(t *testing.T) {
+	configPath, _, _ := setupLayers(t)
+
+	cfg, _, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf(testutil.ErrLoadConfigFailedFmt, err)
+	}
+	cfg.BindAddress = "::1"
+
+	if err := SaveConfig(cfg, configPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	if data, _ := os.ReadFile(configPath); !strings.Contains(string(data), "bind_address: ::1") {
+		t.Errorf("Expected a changed default to be written, got:\n%s", data)
+	}
+}
+
+func TestReadConfigLayers(t *testing.T) {
+	configPath, _, _ := setupLayers(t)
+
+	layers, err := ReadConfigLayers(configPath)
+	if err != nil {
+		t.Fatalf("ReadConfigLayers failed: %v", err)
+	}
+
+	wantFiles := []string{testutil.TestConfigYAML, filepath.Join("config.d", "10-team.yaml"), "config.testhost.yaml"}
+	if !reflect.DeepEqual(layers.Files, wantFiles) {
+		t.Errorf("Unexpected files: %v", layers.Files)
+	}
+
+	for path, want := range map[string]string{
+		"server_port":                     testutil.TestConfigYAML,
+		"mcpServers.alpha.command":        testutil.TestConfigYAML,
+		"mcpServers.alpha.args":           "config.testhost.yaml",
+		"mcpServers.alpha.env.TEAM":       filepath.Join("config.d", "10-team.yaml"),
+		"mcpServers.gamma.args":           filepath.Join("config.d", "10-team.yaml"),
+		"clients.test_client.config_path": "config.testhost.yaml",
+	} {
+		if layers.Origins[path] != want {
+			t.Errorf("Origin of %s: expected %s, got %s", path, want, layers.Origins[path])
+		}
+	}
+
+	overlays := layers.ServerOverlays("alpha")
+	want := map[string][]string{
+		filepath.Join("config.d", "10-team.yaml"): {"env"},
+		"config.testhost.yaml":                    {"args"},
+	}
+	if !reflect.DeepEqual(overlays, want) {
+		t.Errorf("Unexpected overlays of alpha: %v", overlays)
+	}
+	if len(layers.ServerOverlays("beta")) != 0 {
+		t.Errorf("Expected no overlays for beta, got %v", layers.ServerOverlays("beta"))
+	}
+}
+
+func TestLoadConfig_InvalidLayer(t *testing.T) {
+	configPath, dropInPath, _ := setupLayers(t)
+	testutil.WriteTestFile(t, dropInPath, "- not a mapping\n")
+
+	_, _, err := LoadConfig(configPath)
+	testutil.AssertErrorContains(t, err, "must be a YAML mapping")
+}
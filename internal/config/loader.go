@@ -12,21 +12,36 @@ import (
 
 const DefaultConfigPath = "configs/config.yaml"
 
+// DefaultBindAddress keeps the manager reachable from this machine only
+const DefaultBindAddress = "127.0.0.1"
+
+// LoadConfig resolves and loads the configuration, merging the overlays of the
+// config file (see LayerPaths) over it
 func LoadConfig(configPath string) (*models.Config, string, error) {
 	actualPath, err := resolveConfigPath(configPath)
 	if err != nil {
 		return nil, "", err
 	}
 
-	data, err := os.ReadFile(actualPath)
+	data, err := ReadConfigData(actualPath)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to read config file: %w", err)
+		return nil, "", err
 	}
 
+	config, err := ParseConfig(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return config, actualPath, nil
+}
+
+// ParseConfig builds a config from YAML content and applies defaults
+func ParseConfig(data []byte) (*models.Config, error) {
 	// Parse YAML with order preservation
 	rawConfig, serverOrder, err := parseYAMLConfig(data)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
 	// Build final config structure
@@ -34,15 +49,43 @@ func LoadConfig(configPath string) (*models.Config, string, error) {
 		MCPServers: buildOrderedServers(serverOrder, rawConfig.MCPServers),
 		Clients:    rawConfig.Clients,
 		ServerPort: rawConfig.ServerPort,
+		Watch:      rawConfig.Watch,
+
+		BindAddress: rawConfig.BindAddress,
+		PublicURL:   rawConfig.PublicURL,
+		Auth:        rawConfig.Auth,
+		TLS:         rawConfig.TLS,
+		UnixSocket:  rawConfig.UnixSocket,
+		Policy:      rawConfig.Policy,
+		Git:         rawConfig.Git,
+		Health:      rawConfig.Health,
+
+		Notifications: rawConfig.Notifications,
+		Logging:       rawConfig.Logging,
+		Catalog:       rawConfig.Catalog,
+		Validation:    rawConfig.Validation,
+
+		Templates: rawConfig.Templates,
+		Vars:      rawConfig.Vars,
+
+		Projects:      rawConfig.Projects,
+		Profiles:      rawConfig.Profiles,
+		ActiveProfile: rawConfig.ActiveProfile,
 	}
 
 	if config.ServerPort == 0 {
 		config.ServerPort = 6543
 	}
 
-	return config, actualPath, nil
+	if config.BindAddress == "" {
+		config.BindAddress = DefaultBindAddress
+	}
+
+	return config, nil
 }
 
+// SaveConfig writes the configuration. With overlays, each change is written
+// to the layer it belongs to instead of the config file.
 func SaveConfig(config *models.Config, configPath string) error {
 	if configPath == "" {
 		configPath = DefaultConfigPath
@@ -52,34 +95,143 @@ func SaveConfig(config *models.Config, configPath string) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	// Convert ordered slice back to map for standard YAML marshaling
-	// This preserves order through yaml.v3's MapSlice or custom marshaling
-	serversMap := make(map[string]interface{})
-	for _, server := range config.MCPServers {
-		serversMap[server.Name] = server.Config
+	data, err := MarshalConfig(config)
+	if err != nil {
+		return err
+	}
+
+	paths, err := LayerPaths(configPath)
+	if err != nil {
+		return err
+	}
+	if len(paths) > 1 {
+		return saveLayered(data, paths)
+	}
+
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+// MarshalConfig encodes the config as config.yaml content, keeping server order
+func MarshalConfig(config *models.Config) ([]byte, error) {
+	// Build the mcpServers mapping node by hand: a Go map would be written
+	// in alphabetical order and lose the order of the MCPServers slice
+	serversNode, err := buildServersNode(config.MCPServers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
 	}
 
 	// Create temporary struct for marshaling with proper order
 	type ConfigForSave struct {
-		ServerPort int                    `yaml:"server_port"`
-		MCPServers map[string]interface{} `yaml:"mcpServers"`
+		ServerPort int                       `yaml:"server_port"`
+		MCPServers *yaml.Node                `yaml:"mcpServers"`
 		Clients    map[string]*models.Client `yaml:"clients"`
+		Watch      *models.WatchConfig       `yaml:"watch,omitempty"`
+
+		BindAddress string                   `yaml:"bind_address,omitempty"`
+		PublicURL   string                   `yaml:"public_url,omitempty"`
+		Auth        *models.AuthConfig       `yaml:"auth,omitempty"`
+		TLS         *models.TLSConfig        `yaml:"tls,omitempty"`
+		UnixSocket  *models.UnixSocketConfig `yaml:"unix_socket,omitempty"`
+		Policy      *models.PolicyConfig     `yaml:"policy,omitempty"`
+		Git         *models.GitConfig        `yaml:"git,omitempty"`
+		Health      *models.HealthConfig     `yaml:"health,omitempty"`
+
+		Notifications *models.NotificationsConfig `yaml:"notifications,omitempty"`
+		Logging       *models.LoggingConfig       `yaml:"logging,omitempty"`
+		Catalog       *models.CatalogConfig       `yaml:"catalog,omitempty"`
+		Validation    *models.ValidationConfig    `yaml:"validation,omitempty"`
+
+		Templates map[string]*models.ServerTemplate `yaml:"templates,omitempty"`
+		Vars      map[string]string                 `yaml:"vars,omitempty"`
+
+		Projects      map[string]*models.Project `yaml:"projects,omitempty"`
+		Profiles      map[string]models.Profile  `yaml:"profiles,omitempty"`
+		ActiveProfile string                     `yaml:"active_profile,omitempty"`
 	}
 
 	saveConfig := ConfigForSave{
 		ServerPort: config.ServerPort,
-		MCPServers: serversMap,
+		MCPServers: serversNode,
 		Clients:    config.Clients,
+		Watch:      config.Watch,
+
+		BindAddress: config.BindAddress,
+		PublicURL:   config.PublicURL,
+		Auth:        config.Auth,
+		TLS:         config.TLS,
+		UnixSocket:  config.UnixSocket,
+		Policy:      config.Policy,
+		Git:         config.Git,
+		Health:      config.Health,
+
+		Notifications: config.Notifications,
+		Logging:       config.Logging,
+		Catalog:       config.Catalog,
+		Validation:    config.Validation,
+
+		Templates: config.Templates,
+		Vars:      config.Vars,
+
+		Projects:      config.Projects,
+		Profiles:      config.Profiles,
+		ActiveProfile: config.ActiveProfile,
 	}
 
 	data, err := yaml.Marshal(saveConfig)
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
+	return data, nil
+}
+
+// buildServersNode encodes the servers as a YAML mapping in slice order
+func buildServersNode(servers []models.MCPServer) (*yaml.Node, error) {
+	serversNode := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+	for _, server := range servers {
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: server.Name}
+
+		valueNode := &yaml.Node{}
+		if err := valueNode.Encode(server.Config); err != nil {
+			return nil, fmt.Errorf("server '%s': %w", server.Name, err)
+		}
+		if valueNode.Kind != yaml.MappingNode {
+			valueNode = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+
+		// Manager-only fields such as tags go first, ahead of the passthrough config
+		if server.OAuth != nil {
+			oauthNode := &yaml.Node{}
+			if err := oauthNode.Encode(server.OAuth); err != nil {
+				return nil, fmt.Errorf("server '%s': %w", server.Name, err)
+			}
+			oauthKey := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: models.ServerOAuthKey}
+			valueNode.Content = append([]*yaml.Node{oauthKey, oauthNode}, valueNode.Content...)
+		}
+		if len(server.Tags) > 0 {
+			tagsNode := &yaml.Node{}
+			if err := tagsNode.Encode(server.Tags); err != nil {
+				return nil, fmt.Errorf("server '%s': %w", server.Name, err)
+			}
+			tagsKey := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: models.ServerTagsKey}
+			valueNode.Content = append([]*yaml.Node{tagsKey, tagsNode}, valueNode.Content...)
+		}
+		if server.Template != nil {
+			templateNode := &yaml.Node{}
+			if err := templateNode.Encode(server.Template); err != nil {
+				return nil, fmt.Errorf("server '%s': %w", server.Name, err)
+			}
+			templateKey := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: models.ServerTemplateKey}
+			valueNode.Content = append([]*yaml.Node{templateKey, templateNode}, valueNode.Content...)
+		}
+
+		serversNode.Content = append(serversNode.Content, keyNode, valueNode)
 	}
 
-	return nil
+	return serversNode, nil
 }
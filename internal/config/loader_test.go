@@ -14,9 +14,7 @@ import (
 // parsing to maintain declaration order. The test creates a YAML file with specific order
 // (server-b, server-a, server-c) and verifies the loaded MCPServers slice maintains that order.
 //
-// IMPORTANT: This only tests LoadConfig. SaveConfig has a known limitation where it uses
-// map[string]interface{} which loses order. See TestOrderPreservation_MultipleServers for
-// documentation of that limitation.
+// SaveConfig round trips are covered by TestSaveConfig_PreservesOrder.
 func TestLoadConfig_OrderPreservation(t *testing.T) {
 	// Create a temporary config file with specific server order
 	tempDir := t.TempDir()
@@ -197,6 +195,83 @@ func TestSaveConfig(t *testing.T) {
 	}
 }
 
+func TestSaveConfig_PreservesOrder(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "output.yaml")
+
+	names := []string{"zebra", "alpha", "middle"}
+	cfg := &models.Config{ServerPort: 8080, Clients: map[string]*models.Client{}}
+	for _, name := range names {
+		cfg.MCPServers = append(cfg.MCPServers, models.MCPServer{
+			Name:   name,
+			Tags:   []string{"local"},
+			Config: map[string]interface{}{"command": "echo"},
+		})
+	}
+
+	if err := SaveConfig(cfg, configPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	loadedCfg, _, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+
+	if len(loadedCfg.MCPServers) != len(names) {
+		t.Fatalf("Expected %d servers, got %d", len(names), len(loadedCfg.MCPServers))
+	}
+	for i, name := range names {
+		if loadedCfg.MCPServers[i].Name != name {
+			t.Errorf("Server[%d]: expected %s, got %s", i, name, loadedCfg.MCPServers[i].Name)
+		}
+		if !loadedCfg.MCPServers[i].HasTag("local") {
+			t.Errorf("Server '%s' lost its tags", name)
+		}
+	}
+}
+
+func TestSaveConfig_OAuth(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "output.yaml")
+	testutil.WriteTestFile(t, configPath, `mcpServers:
+  remote:
+    url: https://mcp.example.com/mcp
+    oauth:
+      scopes: [read, write]
+      client_id: manager
+  discovered:
+    url: https://other.example.com/mcp
+    oauth: true
+clients: {}
+`)
+
+	cfg, _, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	for _, server := range cfg.MCPServers {
+		if server.OAuth == nil {
+			t.Fatalf("Server '%s' lost its oauth settings", server.Name)
+		}
+		if _, leaked := server.Config[models.ServerOAuthKey]; leaked {
+			t.Errorf("oauth of '%s' must not be part of the passthrough config", server.Name)
+		}
+	}
+	if oauth := cfg.MCPServers[0].OAuth; oauth.ClientID != "manager" || len(oauth.Scopes) != 2 {
+		t.Errorf("Unexpected oauth settings %+v", oauth)
+	}
+
+	if err := SaveConfig(cfg, configPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	reloaded, _, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	if reloaded.MCPServers[0].OAuth == nil || reloaded.MCPServers[0].OAuth.ClientID != "manager" || reloaded.MCPServers[1].OAuth == nil {
+		t.Errorf("oauth settings did not survive a save: %+v, %+v", reloaded.MCPServers[0].OAuth, reloaded.MCPServers[1].OAuth)
+	}
+}
+
 func TestExpandPath(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 
@@ -13,6 +14,28 @@ type rawConfigData struct {
 	MCPServers map[string]map[string]interface{} `yaml:"mcpServers"`
 	Clients    map[string]*models.Client         `yaml:"clients"`
 	ServerPort int                               `yaml:"server_port"`
+	Watch      *models.WatchConfig               `yaml:"watch"`
+
+	BindAddress string                   `yaml:"bind_address"`
+	PublicURL   string                   `yaml:"public_url"`
+	Auth        *models.AuthConfig       `yaml:"auth"`
+	TLS         *models.TLSConfig        `yaml:"tls"`
+	UnixSocket  *models.UnixSocketConfig `yaml:"unix_socket"`
+	Policy      *models.PolicyConfig     `yaml:"policy"`
+	Git         *models.GitConfig        `yaml:"git"`
+	Health      *models.HealthConfig     `yaml:"health"`
+
+	Notifications *models.NotificationsConfig `yaml:"notifications"`
+	Logging       *models.LoggingConfig       `yaml:"logging"`
+	Catalog       *models.CatalogConfig       `yaml:"catalog"`
+	Validation    *models.ValidationConfig    `yaml:"validation"`
+
+	Templates map[string]*models.ServerTemplate `yaml:"templates"`
+	Vars      map[string]string                 `yaml:"vars"`
+
+	Projects      map[string]*models.Project `yaml:"projects"`
+	Profiles      map[string]models.Profile  `yaml:"profiles"`
+	ActiveProfile string                     `yaml:"active_profile"`
 }
 
 // extractServerOrder extracts the server order from YAML node structure
@@ -48,32 +71,114 @@ func buildOrderedServers(serverOrder []string, serversMap map[string]map[string]
 		// Use explicit order
 		for _, name := range serverOrder {
 			if serverConfig, exists := serversMap[name]; exists {
-				servers = append(servers, models.MCPServer{
-					Name:   name,
-					Config: serverConfig,
-				})
+				servers = append(servers, NewServer(name, serverConfig))
 			}
 		}
 	} else {
 		// Fallback: map iteration (order not guaranteed)
 		for name, serverConfig := range serversMap {
-			servers = append(servers, models.MCPServer{
-				Name:   name,
-				Config: serverConfig,
-			})
+			servers = append(servers, NewServer(name, serverConfig))
 		}
 	}
 
 	return servers
 }
 
+// NewServer builds a server, splitting manager-only keys out of the passthrough config
+func NewServer(name string, serverConfig map[string]interface{}) models.MCPServer {
+	server := models.MCPServer{Name: name, Config: serverConfig}
+
+	if rawTags, exists := serverConfig[models.ServerTagsKey]; exists {
+		server.Tags = ParseTags(rawTags)
+		delete(serverConfig, models.ServerTagsKey)
+	}
+
+	if rawTemplate, exists := serverConfig[models.ServerTemplateKey]; exists {
+		server.Template = ParseTemplateRef(rawTemplate)
+		delete(serverConfig, models.ServerTemplateKey)
+	}
+
+	if rawOAuth, exists := serverConfig[models.ServerOAuthKey]; exists {
+		server.OAuth = ParseOAuthConfig(rawOAuth)
+		delete(serverConfig, models.ServerOAuthKey)
+	}
+
+	return server
+}
+
+// ParseOAuthConfig converts a decoded oauth mapping into OAuth settings; an empty
+// mapping or true turns OAuth on with discovered settings, false or null leaves it off
+func ParseOAuthConfig(rawOAuth interface{}) *models.OAuthConfig {
+	switch v := rawOAuth.(type) {
+	case bool:
+		if v {
+			return &models.OAuthConfig{}
+		}
+		return nil
+	case map[string]interface{}:
+		oauth := &models.OAuthConfig{Scopes: ParseTags(v["scopes"])}
+		oauth.ClientID, _ = v["client_id"].(string)
+		oauth.ClientSecret, _ = v["client_secret"].(string)
+		oauth.AuthorizationServer, _ = v["authorization_server"].(string)
+		return oauth
+	}
+	return nil
+}
+
+// ParseTemplateRef converts a decoded {name: ..., params: {...}} mapping into a
+// template reference; nil when it names no template
+func ParseTemplateRef(rawTemplate interface{}) *models.TemplateRef {
+	fields, ok := rawTemplate.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	name, _ := fields["name"].(string)
+	if strings.TrimSpace(name) == "" {
+		return nil
+	}
+
+	ref := &models.TemplateRef{Name: strings.TrimSpace(name)}
+	if params, ok := fields["params"].(map[string]interface{}); ok {
+		ref.Params = make(map[string]string, len(params))
+		for key, value := range params {
+			ref.Params[key] = fmt.Sprint(value)
+		}
+	}
+	return ref
+}
+
+// ParseTags converts a decoded YAML/JSON tag list into strings, skipping empty entries
+func ParseTags(rawTags interface{}) []string {
+	var tags []string
+	switch v := rawTags.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if tag, ok := item.(string); ok && strings.TrimSpace(tag) != "" {
+				tags = append(tags, strings.TrimSpace(tag))
+			}
+		}
+	case []string:
+		for _, tag := range v {
+			if strings.TrimSpace(tag) != "" {
+				tags = append(tags, strings.TrimSpace(tag))
+			}
+		}
+	case string:
+		if strings.TrimSpace(v) != "" {
+			tags = append(tags, strings.TrimSpace(v))
+		}
+	}
+	return tags
+}
+
 // parseYAMLConfig parses YAML data and returns the config and server order
 func parseYAMLConfig(data []byte) (*rawConfigData, []string, error) {
 	var rawConfig rawConfigData
 
 	// Use yaml.v3 Node to preserve order
-	var node yaml.Node
-	if err := yaml.Unmarshal(data, &node); err != nil {
+	node, err := parseYAMLNode(data)
+	if err != nil {
 		return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
@@ -81,6 +186,16 @@ func parseYAMLConfig(data []byte) (*rawConfigData, []string, error) {
 		return nil, nil, fmt.Errorf("failed to decode config: %w", err)
 	}
 
-	serverOrder := extractServerOrder(&node)
+	serverOrder := extractServerOrder(node)
 	return &rawConfig, serverOrder, nil
+}
+
+// parseYAMLNode parses YAML data into a document node, which keeps key order
+// and the line and column of every value
+func parseYAMLNode(data []byte) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	return &node, nil
 }
\ No newline at end of file
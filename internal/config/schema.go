@@ -0,0 +1,458 @@
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// schemaJSON is the JSON Schema of config.yaml, also served for editors
+//
+//go:embed config.schema.json
+var schemaJSON []byte
+
+// Diagnostic is a problem found in a config file, with its location
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Path     string `json:"path"` // YAML path, e.g. mcpServers.fs.args[0]; empty for the document
+	Message  string `json:"message"`
+}
+
+// String formats the diagnostic as file:line:column: severity: message, which
+// editors and terminals recognize as a location
+func (d Diagnostic) String() string {
+	if d.Path == "" {
+		return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Path, d.Message)
+}
+
+// Schema returns the JSON Schema of config.yaml
+func Schema() []byte {
+	return schemaJSON
+}
+
+// ServerSchema returns a JSON Schema for a single entry of mcpServers, e.g. for
+// the JSON accepted when adding a server
+func ServerSchema() []byte {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(schemaJSON, &doc); err != nil {
+		panic(fmt.Sprintf("invalid embedded schema: %v", err))
+	}
+	server := map[string]interface{}{
+		"$schema": doc["$schema"],
+		"$id":     strings.Replace(doc["$id"].(string), "config.schema.json", "server.schema.json", 1),
+		"title":   "MCP server entry",
+		"$ref":    "#/$defs/server",
+		"$defs":   doc["$defs"],
+	}
+	data, _ := json.MarshalIndent(server, "", "  ")
+	return data
+}
+
+// schemaNode is the subset of JSON Schema used by config.schema.json
+type schemaNode struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 schemaTypes            `json:"type"`
+	Properties           map[string]*schemaNode `json:"properties"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+	PropertyNames        *schemaNode            `json:"propertyNames"`
+	Required             []string               `json:"required"`
+	Items                *schemaNode            `json:"items"`
+	Enum                 []interface{}          `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	MinLength            int                    `json:"minLength"`
+	Pattern              string                 `json:"pattern"`
+	Defs                 map[string]*schemaNode `json:"$defs"`
+
+	pattern    *regexp.Regexp
+	additional *schemaNode // Schema of other keys; nil allows any
+	closed     bool        // additionalProperties: false
+	compiled   bool
+}
+
+// schemaTypes accepts "type" as a string or a list of strings
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// configSchema is the compiled embedded schema
+var configSchema = mustCompileSchema(schemaJSON)
+
+func mustCompileSchema(data []byte) *schemaNode {
+	var root schemaNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		panic(fmt.Sprintf("invalid embedded schema: %v", err))
+	}
+	if err := root.compile(&root); err != nil {
+		panic(fmt.Sprintf("invalid embedded schema: %v", err))
+	}
+	return &root
+}
+
+// compile resolves references and parses patterns and additionalProperties
+func (s *schemaNode) compile(root *schemaNode) error {
+	if s.compiled {
+		return nil
+	}
+	s.compiled = true
+
+	if s.Ref != "" {
+		target := root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if target == nil {
+			return fmt.Errorf("unresolved reference '%s'", s.Ref)
+		}
+		if err := target.compile(root); err != nil {
+			return err
+		}
+		*s = *target
+		return nil
+	}
+
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern '%s': %w", s.Pattern, err)
+		}
+		s.pattern = pattern
+	}
+
+	switch raw := strings.TrimSpace(string(s.AdditionalProperties)); {
+	case raw == "false":
+		s.closed = true
+	case strings.HasPrefix(raw, "{"):
+		s.additional = &schemaNode{}
+		if err := json.Unmarshal(s.AdditionalProperties, s.additional); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sortedKeys(s.Defs) {
+		if err := s.Defs[name].compile(root); err != nil {
+			return err
+		}
+	}
+	for _, child := range []*schemaNode{s.additional, s.PropertyNames, s.Items} {
+		if child != nil {
+			if err := child.compile(root); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range sortedKeys(s.Properties) {
+		if err := s.Properties[name].compile(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]*schemaNode) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ValidateSchema checks YAML config content against the schema and returns
+// every problem found; a file that is not valid YAML yields one diagnostic. In
+// an overlay (partial) required keys may be missing, as another layer sets them.
+func ValidateSchema(file string, data []byte, partial bool) []Diagnostic {
+	node, err := parseYAMLNode(data)
+	if err != nil {
+		return []Diagnostic{syntaxDiagnostic(file, err)}
+	}
+	if len(node.Content) == 0 {
+		if partial {
+			return nil
+		}
+		return []Diagnostic{{File: file, Line: 1, Column: 1, Severity: models.SeverityError, Message: "config is empty"}}
+	}
+
+	checker := &schemaChecker{file: file, partial: partial}
+	checker.check(configSchema, node.Content[0], "")
+	return checker.diagnostics
+}
+
+// ValidateSchemaFile checks the config file at basePath and its overlays
+// against the schema
+func ValidateSchemaFile(basePath string) ([]Diagnostic, error) {
+	paths, err := LayerPaths(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var diagnostics []Diagnostic
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config layer '%s': %w", path, err)
+		}
+		diagnostics = append(diagnostics, ValidateSchema(path, data, i > 0)...)
+	}
+	return diagnostics, nil
+}
+
+// Locate returns a diagnostic for a YAML path of the config at basePath,
+// pointing at the key in the last layer that sets it. Paths that are not found
+// point at the start of the base file.
+func Locate(basePath, path, severity, message string) Diagnostic {
+	diagnostic := Diagnostic{File: basePath, Line: 1, Column: 1, Severity: severity, Path: path, Message: message}
+	if path == "" {
+		return diagnostic
+	}
+
+	paths, err := LayerPaths(basePath)
+	if err != nil {
+		return diagnostic
+	}
+	layers, err := readLayers(paths)
+	if err != nil {
+		return diagnostic
+	}
+
+	keys := strings.Split(path, ".")
+	for i := len(layers) - 1; i >= 0; i-- {
+		// The deepest key that exists, e.g. the server when a field is missing
+		for depth := len(keys); depth > 0; depth-- {
+			if key := lookupKey(layers[i].doc, keys[:depth]); key != nil {
+				diagnostic.File, diagnostic.Line, diagnostic.Column = layers[i].path, key.Line, key.Column
+				if depth == len(keys) || i == 0 {
+					return diagnostic
+				}
+				break
+			}
+		}
+	}
+	return diagnostic
+}
+
+// lookupKey returns the key node of the value at path
+func lookupKey(node *yaml.Node, path []string) *yaml.Node {
+	parent := lookupNode(node, path[:len(path)-1])
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == path[len(path)-1] {
+			return parent.Content[i]
+		}
+	}
+	return nil
+}
+
+// yamlLinePattern finds the line in yaml.v3 syntax errors ("yaml: line 3: ...")
+var yamlLinePattern = regexp.MustCompile(`^yaml: line (\d+): `)
+
+func syntaxDiagnostic(file string, err error) Diagnostic {
+	diagnostic := Diagnostic{File: file, Line: 1, Column: 1, Severity: models.SeverityError, Message: err.Error()}
+	if match := yamlLinePattern.FindStringSubmatch(err.Error()); match != nil {
+		diagnostic.Line, _ = strconv.Atoi(match[1])
+		diagnostic.Message = "invalid YAML: " + strings.TrimPrefix(err.Error(), match[0])
+	}
+	return diagnostic
+}
+
+// schemaChecker collects the diagnostics of one file
+type schemaChecker struct {
+	file        string
+	partial     bool
+	diagnostics []Diagnostic
+}
+
+func (c *schemaChecker) report(node *yaml.Node, path, format string, args ...interface{}) {
+	c.diagnostics = append(c.diagnostics, Diagnostic{
+		File:     c.file,
+		Line:     node.Line,
+		Column:   node.Column,
+		Severity: models.SeverityError,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *schemaChecker) check(s *schemaNode, node *yaml.Node, path string) {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	if len(s.Type) > 0 && !s.Type.matches(node) {
+		c.report(node, path, "must be %s, got %s", s.Type.describe(), nodeType(node))
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		c.checkEnum(s, node, path)
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		c.checkMapping(s, node, path)
+	case yaml.SequenceNode:
+		if s.Items != nil {
+			for i, item := range node.Content {
+				c.check(s.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case yaml.ScalarNode:
+		c.checkScalar(s, node, path)
+	}
+}
+
+func (c *schemaChecker) checkEnum(s *schemaNode, node *yaml.Node, path string) {
+	values := make([]string, len(s.Enum))
+	for i, value := range s.Enum {
+		values[i] = fmt.Sprint(value)
+		if node.Kind == yaml.ScalarNode && node.Value == values[i] {
+			return
+		}
+	}
+	c.report(node, path, "must be one of %s, got '%s'", strings.Join(values, ", "), node.Value)
+}
+
+func (c *schemaChecker) checkMapping(s *schemaNode, node *yaml.Node, path string) {
+	keyPath := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	present := make(map[string]bool, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		present[key.Value] = true
+
+		if s.PropertyNames != nil && s.PropertyNames.pattern != nil && !s.PropertyNames.pattern.MatchString(key.Value) {
+			c.report(key, keyPath(key.Value), "invalid name '%s'", key.Value)
+		}
+
+		switch property := s.Properties[key.Value]; {
+		case property != nil:
+			c.check(property, value, keyPath(key.Value))
+		case s.additional != nil:
+			c.check(s.additional, value, keyPath(key.Value))
+		case s.closed:
+			c.report(key, keyPath(key.Value), "unknown key '%s'", key.Value)
+		}
+	}
+
+	if c.partial {
+		return
+	}
+	for _, required := range s.Required {
+		if !present[required] {
+			c.report(node, path, "'%s' is required", required)
+		}
+	}
+}
+
+func (c *schemaChecker) checkScalar(s *schemaNode, node *yaml.Node, path string) {
+	if s.MinLength > 0 && node.Tag == "!!str" && len(node.Value) < s.MinLength {
+		if s.MinLength == 1 {
+			c.report(node, path, "cannot be empty")
+		} else {
+			c.report(node, path, "must be at least %d characters long", s.MinLength)
+		}
+	}
+
+	if s.pattern != nil && node.Tag == "!!str" && !s.pattern.MatchString(node.Value) {
+		c.report(node, path, "'%s' does not match %s", node.Value, s.Pattern)
+	}
+
+	if s.Minimum == nil && s.Maximum == nil {
+		return
+	}
+	number, err := strconv.ParseFloat(node.Value, 64)
+	if err != nil || (node.Tag != "!!int" && node.Tag != "!!float") {
+		return
+	}
+	if s.Minimum != nil && number < *s.Minimum {
+		c.report(node, path, "must be at least %s", formatNumber(*s.Minimum))
+	}
+	if s.Maximum != nil && number > *s.Maximum {
+		c.report(node, path, "must be at most %s", formatNumber(*s.Maximum))
+	}
+}
+
+func formatNumber(n float64) string {
+	if n == math.Trunc(n) {
+		return strconv.FormatInt(int64(n), 10)
+	}
+	return strconv.FormatFloat(n, 'g', -1, 64)
+}
+
+// matches reports whether a YAML node has one of the JSON types
+func (t schemaTypes) matches(node *yaml.Node) bool {
+	actual := nodeType(node)
+	for _, want := range t {
+		if want == actual || (want == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func (t schemaTypes) describe() string {
+	names := make([]string, len(t))
+	for i, name := range t {
+		switch name {
+		case "object":
+			names[i] = "a mapping"
+		case "array":
+			names[i] = "a list"
+		case "integer":
+			names[i] = "an integer"
+		default:
+			names[i] = "a " + name
+		}
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// nodeType returns the JSON type of a YAML node
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.Tag {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	}
+	return "string"
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+const schemaInvalid = `server_port: 70000
+mcpServers:
+  fs:
+    command: echo
+    timeout: soon
+    type: pipe
+    args: [a, {b: c}]
+clients:
+  claude:
+    format: yaml
+    enabled: [fs]
+typo: true
+`
+
+func TestValidateSchema(t *testing.T) {
+	diagnostics := ValidateSchema("config.yaml", []byte(schemaInvalid), false)
+
+	want := []Diagnostic{
+		{File: "config.yaml", Line: 1, Column: 14, Severity: models.SeverityError, Path: "server_port", Message: "must be at most 65535"},
+		{File: "config.yaml", Line: 5, Column: 14, Severity: models.SeverityError, Path: "mcpServers.fs.timeout", Message: "must be an integer, got string"},
+		{File: "config.yaml", Line: 6, Column: 11, Severity: models.SeverityError, Path: "mcpServers.fs.type", Message: "must be one of stdio, sse, http, streamable-http, got 'pipe'"},
+		{File: "config.yaml", Line: 7, Column: 15, Severity: models.SeverityError, Path: "mcpServers.fs.args[1]", Message: "must be a string, a number or a boolean, got object"},
+		{File: "config.yaml", Line: 10, Column: 13, Severity: models.SeverityError, Path: "clients.claude.format", Message: "must be one of json, jsonc, got 'yaml'"},
+		{File: "config.yaml", Line: 10, Column: 5, Severity: models.SeverityError, Path: "clients.claude", Message: "'config_path' is required"},
+		{File: "config.yaml", Line: 12, Column: 1, Severity: models.SeverityError, Path: "typo", Message: "unknown key 'typo'"},
+	}
+	if len(diagnostics) != len(want) {
+		t.Fatalf("Expected %d diagnostics, got %d: %v", len(want), len(diagnostics), diagnostics)
+	}
+	for i := range want {
+		if diagnostics[i] != want[i] {
+			t.Errorf("Diagnostic %d:\n got %v\nwant %v", i, diagnostics[i], want[i])
+		}
+	}
+
+	if got := want[0].String(); got != "config.yaml:1:14: error: server_port: must be at most 65535" {
+		t.Errorf("Unexpected format: %s", got)
+	}
+
+	syntax := ValidateSchema("config.yaml", []byte("mcpServers:\n  fs: [\n"), false)
+	if len(syntax) != 1 || syntax[0].Line != 2 {
+		t.Errorf("Expected one syntax error on line 2, got %v", syntax)
+	}
+}
+
+func TestValidateSchema_Overlay(t *testing.T) {
+	// Overlays may leave out required keys, but unknown keys are still reported
+	overlay := "clients:\n  claude:\n    enabled: [fs]\n"
+	if diagnostics := ValidateSchema("host.yaml", []byte(overlay), true); len(diagnostics) != 0 {
+		t.Errorf("Expected a valid overlay, got %v", diagnostics)
+	}
+	if diagnostics := ValidateSchema("host.yaml", []byte(overlay), false); len(diagnostics) != 2 {
+		t.Errorf("Expected missing mcpServers and config_path in a base file, got %v", diagnostics)
+	}
+
+	diagnostics := ValidateSchema("host.yaml", []byte("watch:\n  intervl_ms: 10\n"), true)
+	if len(diagnostics) != 1 || diagnostics[0].Path != "watch.intervl_ms" {
+		t.Errorf("Expected the unknown key, got %v", diagnostics)
+	}
+}
+
+func TestValidateSchema_Examples(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), testutil.TestConfigYAML)
+	if err := createDefaultConfig(configPath); err != nil {
+		t.Fatalf("createDefaultConfig failed: %v", err)
+	}
+
+	for _, path := range []string{configPath, filepath.Join("..", "..", DefaultConfigPath)} {
+		diagnostics, err := ValidateSchemaFile(path)
+		if err != nil {
+			t.Fatalf("ValidateSchemaFile failed: %v", err)
+		}
+		if len(diagnostics) != 0 {
+			t.Errorf("Expected %s to match the schema, got %v", path, diagnostics)
+		}
+	}
+}
+
+func TestLocate(t *testing.T) {
+	configPath, dropInPath, hostPath := setupLayers(t)
+
+	tests := []struct {
+		path      string
+		file      string
+		line, col int
+	}{
+		{path: "mcpServers.alpha.args", file: hostPath, line: 3, col: 5},
+		{path: "mcpServers.alpha.env.TEAM", file: dropInPath, line: 4, col: 7},
+		{path: "mcpServers.beta", file: configPath, line: 8, col: 3},
+		{path: "mcpServers.beta.cwd", file: configPath, line: 8, col: 3}, // Missing field: at the server
+		{path: "", file: configPath, line: 1, col: 1},
+	}
+	for _, tt := range tests {
+		got := Locate(configPath, tt.path, models.SeverityError, "problem")
+		if got.File != tt.file || got.Line != tt.line || got.Column != tt.col {
+			t.Errorf("Locate(%q) = %s:%d:%d, want %s:%d:%d", tt.path, got.File, got.Line, got.Column, tt.file, tt.line, tt.col)
+		}
+	}
+}
+
+func TestServerSchema(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(ServerSchema(), &schema); err != nil {
+		t.Fatalf("Invalid server schema: %v", err)
+	}
+	defs, _ := schema["$defs"].(map[string]interface{})
+	if schema["$ref"] != "#/$defs/server" || defs["server"] == nil {
+		t.Errorf("Expected a reference to the server definition, got %v", schema["$ref"])
+	}
+}
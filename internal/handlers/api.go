@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
 	"github.com/vlazic/mcp-server-manager/internal/services"
 )
 
@@ -19,6 +24,32 @@ func NewAPIHandler(mcpManager *services.MCPManagerService) *APIHandler {
 	}
 }
 
+// manager credits changes made by this request to the API caller in the audit log
+func (h *APIHandler) manager(c *gin.Context) *services.MCPManagerService {
+	return h.mcpManager.WithActor(services.Actor{Source: services.SourceAPI, RemoteAddr: c.RemoteIP(), RequestID: c.GetString(requestIDContextKey)})
+}
+
+// GetAuditLog returns the newest audit log entries; ?limit=N (default 100, 0 for all)
+func (h *APIHandler) GetAuditLog(c *gin.Context) {
+	limit := 100
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit value: " + raw})
+			return
+		}
+		limit = parsed
+	}
+
+	entries, err := h.mcpManager.GetAuditLog(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
 func (h *APIHandler) GetMCPServers(c *gin.Context) {
 	servers := h.mcpManager.GetMCPServers()
 	c.JSON(http.StatusOK, gin.H{"servers": servers})
@@ -41,7 +72,7 @@ func (h *APIHandler) ToggleClientServer(c *gin.Context) {
 		return
 	}
 
-	if err := h.mcpManager.ToggleClientMCPServer(clientName, serverName, enabled); err != nil {
+	if err := h.manager(c).ToggleClientMCPServer(clientName, serverName, enabled); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -61,8 +92,274 @@ func (h *APIHandler) GetServerStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, server)
 }
 
+func (h *APIHandler) SetServerTags(c *gin.Context) {
+	var requestBody struct {
+		Tags []string `json:"tags"`
+	}
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+
+	if err := h.manager(c).SetServerTags(c.Param("server"), requestBody.Tags); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// BulkToggle enables or disables all servers matching a selector such as "tag:docs"
+func (h *APIHandler) BulkToggle(c *gin.Context) {
+	var requestBody struct {
+		Selector string   `json:"selector"`
+		Clients  []string `json:"clients"`
+		Enabled  *bool    `json:"enabled"`
+	}
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+
+	if requestBody.Enabled == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "enabled is required"})
+		return
+	}
+
+	result, err := h.manager(c).BulkSetEnabled(requestBody.Selector, requestBody.Clients, *requestBody.Enabled)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "result": result})
+}
+
+// ReorderServers sets the server order from the full ordered list of server names
+func (h *APIHandler) ReorderServers(c *gin.Context) {
+	var requestBody struct {
+		Order []string `json:"order"`
+	}
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+
+	if err := h.manager(c).ReorderServers(requestBody.Order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "order": requestBody.Order})
+}
+
+func (h *APIHandler) GetProjects(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"projects": h.mcpManager.GetProjects()})
+}
+
+func (h *APIHandler) AddProject(c *gin.Context) {
+	var requestBody struct {
+		Name    string   `json:"name"`
+		Path    string   `json:"path"`
+		Clients []string `json:"clients"`
+		Enabled []string `json:"enabled"`
+	}
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+
+	project := &models.Project{
+		Path:    requestBody.Path,
+		Clients: requestBody.Clients,
+		Enabled: requestBody.Enabled,
+	}
+
+	if err := h.manager(c).AddProject(requestBody.Name, project); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "project": project})
+}
+
+func (h *APIHandler) ToggleProjectServer(c *gin.Context) {
+	projectName := c.Param("project")
+	serverName := c.Param("server")
+
+	enabled, err := strconv.ParseBool(c.PostForm("enabled"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid enabled value"})
+		return
+	}
+
+	// confirm=true writes credentials even into files git would commit
+	result, err := h.manager(c).ToggleProjectServer(projectName, serverName, enabled, c.PostForm("confirm") == "true")
+	if err != nil {
+		if errors.Is(err, services.ErrConfirmationRequired) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "confirmation_required": true})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "files": result.Files, "warnings": result.Warnings})
+}
+
+func (h *APIHandler) SyncProject(c *gin.Context) {
+	result, err := h.manager(c).SyncProject(c.Param("project"), c.Query("confirm") == "true")
+	if err != nil {
+		if errors.Is(err, services.ErrConfirmationRequired) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "confirmation_required": true})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "files": result.Files, "warnings": result.Warnings})
+}
+
+func (h *APIHandler) GetProfiles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"profiles": h.mcpManager.GetProfiles()})
+}
+
+func (h *APIHandler) ActivateProfile(c *gin.Context) {
+	profileName := c.Param("name")
+
+	if err := h.manager(c).ActivateProfile(profileName); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "profile": profileName})
+}
+
+// GetUndoStatus describes the operations the next undo and redo would affect
+func (h *APIHandler) GetUndoStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.mcpManager.GetUndoStatus())
+}
+
+// Undo reverts the last operation, including the client files it rewrote
+func (h *APIHandler) Undo(c *gin.Context) {
+	h.applyUndo(c, h.manager(c).Undo, services.ErrNothingToUndo)
+}
+
+// Redo re-applies the last undone operation
+func (h *APIHandler) Redo(c *gin.Context) {
+	h.applyUndo(c, h.manager(c).Redo, services.ErrNothingToRedo)
+}
+
+func (h *APIHandler) applyUndo(c *gin.Context, apply func() (string, error), emptyErr error) {
+	label, err := apply()
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, emptyErr) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "operation": label, "status": h.mcpManager.GetUndoStatus()})
+}
+
+// GetConfigHistory lists the git revisions of config.yaml; ?limit=N (default 50, 0 for all)
+func (h *APIHandler) GetConfigHistory(c *gin.Context) {
+	limit := 50
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit value: " + raw})
+			return
+		}
+		limit = parsed
+	}
+
+	revisions, err := h.mcpManager.GetConfigHistory(limit)
+	if err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// RevertConfig restores config.yaml from a git revision and syncs all clients
+func (h *APIHandler) RevertConfig(c *gin.Context) {
+	rev := c.Param("rev")
+
+	if err := h.manager(c).RevertConfig(rev); err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "rev": rev})
+}
+
+// historyErrorStatus maps history errors to a status: 404 when versioning is off
+func historyErrorStatus(err error) int {
+	if errors.Is(err, services.ErrVersioningDisabled) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+// GetDriftEvents returns client file drift recorded by the watcher
+func (h *APIHandler) GetDriftEvents(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"events": h.mcpManager.GetDriftEvents()})
+}
+
+// GetHealth returns the latest health check result per server; empty when
+// health checks are disabled
+func (h *APIHandler) GetHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"health": h.mcpManager.GetHealth()})
+}
+
+// eventKeepAlive is how often an idle event stream sends a comment, so proxies
+// and browsers do not drop the connection
+const eventKeepAlive = 30 * time.Second
+
+// Events streams manager events as Server-Sent Events until the client goes away.
+// The SSE event name is the event type; the data is the JSON-encoded event.
+func (h *APIHandler) Events(c *gin.Context) {
+	events, unsubscribe := h.mcpManager.Events().Subscribe()
+	defer unsubscribe()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			c.SSEvent(event.Type, event)
+		case <-keepAlive.C:
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
 func (h *APIHandler) SyncAllClients(c *gin.Context) {
-	if err := h.mcpManager.SyncAllClients(); err != nil {
+	if err := h.manager(c).SyncAllClients(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -71,9 +368,11 @@ func (h *APIHandler) SyncAllClients(c *gin.Context) {
 }
 
 func (h *APIHandler) AddServer(c *gin.Context) {
-	// Expect JSON in format: {"mcpServers": {"server-name": {config...}}}
+	// Expect JSON in format: {"mcpServers": {"server-name": {config...}}}, plus
+	// "confirm": true to add a command that is outside the policy allowlist
 	var requestBody struct {
 		MCPServers map[string]map[string]interface{} `json:"mcpServers"`
+		Confirm    bool                              `json:"confirm"`
 	}
 
 	if err := c.ShouldBindJSON(&requestBody); err != nil {
@@ -95,7 +394,16 @@ func (h *APIHandler) AddServer(c *gin.Context) {
 		break
 	}
 
-	if err := h.mcpManager.AddServer(serverName, serverConfig); err != nil {
+	addServer := h.manager(c).AddServer
+	if requestBody.Confirm {
+		addServer = h.manager(c).AddConfirmedServer
+	}
+
+	if err := addServer(serverName, serverConfig); err != nil {
+		if errors.Is(err, services.ErrConfirmationRequired) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "confirmation_required": true})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -107,4 +415,189 @@ func (h *APIHandler) AddServer(c *gin.Context) {
 			"config": serverConfig,
 		},
 	})
+}
+
+// GetCatalog lists the catalog entries matching ?q= (all when empty)
+func (h *APIHandler) GetCatalog(c *gin.Context) {
+	entries, err := h.mcpManager.Catalog().Search(c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "registry": h.mcpManager.Catalog().RegistryConfigured()})
+}
+
+// SyncCatalog merges the configured registry index into the catalog
+func (h *APIHandler) SyncCatalog(c *gin.Context) {
+	result, err := h.manager(c).SyncCatalog()
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, services.ErrRegistryNotConfigured) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "result": result})
+}
+
+// InstallFromCatalog adds a server from a catalog entry. Expects {"entry": ...,
+// "name": ..., "values": {"INPUT": "value"}}; the name defaults to the entry's
+// and "confirm" works as for AddServer.
+func (h *APIHandler) InstallFromCatalog(c *gin.Context) {
+	var requestBody struct {
+		Entry   string            `json:"entry"`
+		Name    string            `json:"name"`
+		Values  map[string]string `json:"values"`
+		Confirm bool              `json:"confirm"`
+	}
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+
+	if err := h.manager(c).InstallFromCatalog(requestBody.Entry, requestBody.Name, requestBody.Values, requestBody.Confirm); err != nil {
+		if errors.Is(err, services.ErrConfirmationRequired) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "confirmation_required": true})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// GetTemplates returns the server templates and the servers made from each
+func (h *APIHandler) GetTemplates(c *gin.Context) {
+	templates := h.mcpManager.GetTemplates()
+	servers := make(map[string][]string, len(templates))
+	for name := range templates {
+		servers[name] = h.mcpManager.TemplateServers(name)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": templates, "servers": servers})
+}
+
+// SetTemplate adds or replaces a template. The response lists the servers made
+// from it, which keep their config until they are re-rendered. The body is the
+// template, so ?confirm=true works as "confirm" does for AddServer.
+func (h *APIHandler) SetTemplate(c *gin.Context) {
+	var tmpl models.ServerTemplate
+	if err := c.ShouldBindJSON(&tmpl); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+
+	servers, err := h.manager(c).SetTemplate(c.Param("name"), &tmpl, c.Query("confirm") == "true")
+	if err != nil {
+		if errors.Is(err, services.ErrConfirmationRequired) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "confirmation_required": true})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "servers": servers})
+}
+
+// DeleteTemplate removes a template that no server was made from
+func (h *APIHandler) DeleteTemplate(c *gin.Context) {
+	if err := h.manager(c).DeleteTemplate(c.Param("name")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// AddServerFromTemplate creates a server from a template. Expects {"name": ...,
+// "params": {"param": "value"}}; "confirm" works as for AddServer.
+func (h *APIHandler) AddServerFromTemplate(c *gin.Context) {
+	var requestBody struct {
+		Name    string            `json:"name"`
+		Params  map[string]string `json:"params"`
+		Confirm bool              `json:"confirm"`
+	}
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+
+	if err := h.manager(c).AddServerFromTemplate(c.Param("name"), requestBody.Name, requestBody.Params, requestBody.Confirm); err != nil {
+		if errors.Is(err, services.ErrConfirmationRequired) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "confirmation_required": true})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "server": requestBody.Name})
+}
+
+// RerenderTemplate renders servers made from a template again; {"servers": [...]}
+// limits it to some of them and "confirm" works as for AddServer
+func (h *APIHandler) RerenderTemplate(c *gin.Context) {
+	var requestBody struct {
+		Servers []string `json:"servers"`
+		Confirm bool     `json:"confirm"`
+	}
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+
+	rendered, err := h.manager(c).RerenderTemplateServers(c.Param("name"), requestBody.Servers, requestBody.Confirm)
+	if err != nil {
+		if errors.Is(err, services.ErrConfirmationRequired) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "confirmation_required": true})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "servers": rendered})
+}
+
+// GetConfigLayers lists the files the configuration is merged from and the file
+// each value comes from, keyed by dotted path (e.g. "mcpServers.git.args")
+func (h *APIHandler) GetConfigLayers(c *gin.Context) {
+	layers, err := h.mcpManager.ConfigLayers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, layers)
+}
+
+// ValidateConfig checks config.yaml and its overlays as on disk and lists every
+// problem with its severity, file, line and column. The config is valid when
+// none of them is an error.
+func (h *APIHandler) ValidateConfig(c *gin.Context) {
+	diagnostics, err := h.mcpManager.CheckConfigFile()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	valid := true
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == models.SeverityError {
+			valid = false
+		}
+	}
+	if diagnostics == nil {
+		diagnostics = []config.Diagnostic{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": valid, "diagnostics": diagnostics})
 }
\ No newline at end of file
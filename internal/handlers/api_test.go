@@ -1,18 +1,21 @@
 package handlers
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/vlazic/mcp-server-manager/internal/config"
 	"github.com/vlazic/mcp-server-manager/internal/models"
 	"github.com/vlazic/mcp-server-manager/internal/services"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
 )
 
 // setupTestAPIHandler creates a test API handler with a temporary config file
@@ -569,3 +572,509 @@ func TestToggleClientServer_NonExistentClient(t *testing.T) {
 		t.Error("Expected error message for non-existent client")
 	}
 }
+
+// TestAddServer_PolicyConfirmation tests that commands outside the policy allowlist need confirmation
+func TestAddServer_PolicyConfirmation(t *testing.T) {
+	_, tempDir, cleanup := setupTestAPIHandler(t)
+	defer cleanup()
+
+	configPath := filepath.Join(tempDir, "config.yaml")
+	cfg, _, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg.Policy = &models.PolicyConfig{AllowedCommands: []string{"npx"}}
+	handler := NewAPIHandler(services.NewMCPManagerService(cfg, configPath))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/servers", handler.AddServer)
+
+	post := func(confirm bool) *httptest.ResponseRecorder {
+		requestBody := map[string]interface{}{
+			"mcpServers": map[string]interface{}{
+				"shell-server": map[string]interface{}{"command": "sh"},
+			},
+			"confirm": confirm,
+		}
+		jsonData, _ := json.Marshal(requestBody)
+
+		req, _ := http.NewRequest("POST", "/api/servers", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(false)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response["confirmation_required"] != true {
+		t.Errorf("Expected confirmation_required in response, got %v", response)
+	}
+
+	if w := post(true); w.Code != http.StatusOK {
+		t.Fatalf("Expected confirmed server to be added, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := handler.mcpManager.GetServerStatus("shell-server"); err != nil {
+		t.Errorf("Expected shell-server in config: %v", err)
+	}
+}
+
+// TestGetAuditLog tests that API changes are listed with their source
+func TestGetAuditLog(t *testing.T) {
+	handler, _, cleanup := setupTestAPIHandler(t)
+	defer cleanup()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/sync", handler.SyncAllClients)
+	router.GET("/api/audit", handler.GetAuditLog)
+
+	req, _ := http.NewRequest("POST", "/api/sync", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", "/api/audit?limit=10", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Entries []services.AuditEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(response.Entries) != 1 || response.Entries[0].Action != "sync" || response.Entries[0].Actor.Source != services.SourceAPI {
+		t.Errorf("Expected one sync entry from the API, got %+v", response.Entries)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/audit?limit=abc", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid limit, got %d", w.Code)
+	}
+}
+
+// TestUndoRedo_API tests undoing and redoing a toggle through the API
+func TestUndoRedo_API(t *testing.T) {
+	handler, _, cleanup := setupTestAPIHandler(t)
+	defer cleanup()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/clients/:client/servers/:server/toggle", handler.ToggleClientServer)
+	router.POST("/api/undo", handler.Undo)
+	router.POST("/api/redo", handler.Redo)
+
+	post := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString("enabled=false"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post("/api/clients/test-client/servers/test-server/toggle"); w.Code != http.StatusOK {
+		t.Fatalf("Expected toggle to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := post("/api/undo")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected undo to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response["operation"] != "disable test-server for test-client" {
+		t.Errorf("Unexpected undone operation: %v", response["operation"])
+	}
+	if enabled := handler.mcpManager.GetClients()["test-client"].Enabled; len(enabled) != 1 {
+		t.Errorf("Expected test-server enabled again, got %v", enabled)
+	}
+
+	if w := post("/api/undo"); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 with nothing to undo, got %d", w.Code)
+	}
+	if w := post("/api/redo"); w.Code != http.StatusOK {
+		t.Errorf("Expected redo to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestEvents_Stream(t *testing.T) {
+	handler, _, cleanup := setupTestAPIHandler(t)
+	defer cleanup()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/events", handler.Events)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/events")
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+		t.Fatalf("Expected an event stream, got %q", contentType)
+	}
+
+	// The stream is subscribed once the headers arrive
+	if err := handler.mcpManager.ToggleClientMCPServer("test-client", "test-server", false); err != nil {
+		t.Fatalf("ToggleClientMCPServer failed: %v", err)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	var eventLine, dataLine string
+	for lines.Scan() && dataLine == "" {
+		switch line := lines.Text(); {
+		case strings.HasPrefix(line, "event:"):
+			eventLine = line
+		case strings.HasPrefix(line, "data:"):
+			dataLine = line
+		}
+	}
+
+	if eventLine != "event:"+services.EventServerToggled {
+		t.Errorf("Expected a server_toggled event, got %q", eventLine)
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(dataLine, "data:")), &event); err != nil {
+		t.Fatalf("Failed to decode event data %q: %v", dataLine, err)
+	}
+	data, _ := event["data"].(map[string]interface{})
+	if event["type"] != services.EventServerToggled || data["enabled"] != false {
+		t.Errorf("Unexpected event: %v", event)
+	}
+}
+
+func TestMetrics_RequestsByRoute(t *testing.T) {
+	handler, _, cleanup := setupTestAPIHandler(t)
+	defer cleanup()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestMetrics())
+	router.GET("/api/servers", handler.GetMCPServers)
+	router.GET("/metrics", Metrics)
+
+	for _, path := range []string{"/api/servers", "/no-such-page"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("Expected metrics as text, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	for _, want := range []string{
+		`mcp_manager_http_requests_total{method="GET",route="/api/servers",status="200"}`,
+		`mcp_manager_http_requests_total{method="GET",route="unmatched",status="404"}`,
+		`mcp_manager_http_request_duration_seconds_count{method="GET",route="/api/servers"}`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("Expected metrics to contain %s", want)
+		}
+	}
+}
+
+// TestRequestID tests that request IDs are returned and recorded with audited changes
+func TestRequestID(t *testing.T) {
+	handler, _, cleanup := setupTestAPIHandler(t)
+	defer cleanup()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.POST("/api/sync", handler.SyncAllClients)
+	router.GET("/api/audit", handler.GetAuditLog)
+
+	req := httptest.NewRequest("POST", "/api/sync", nil)
+	req.Header.Set("X-Request-ID", "proxy-id.42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if got := w.Header().Get("X-Request-ID"); got != "proxy-id.42" {
+		t.Errorf("Expected the proxy's request ID to be reused, got %q", got)
+	}
+
+	req = httptest.NewRequest("GET", "/api/audit", nil)
+	req.Header.Set("X-Request-ID", "bad id\n")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if got := w.Header().Get("X-Request-ID"); len(got) != 16 || got == "bad id\n" {
+		t.Errorf("Expected a generated request ID, got %q", got)
+	}
+
+	var response struct {
+		Entries []services.AuditEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(response.Entries) != 1 || response.Entries[0].Actor.RequestID != "proxy-id.42" {
+		t.Errorf("Expected the sync entry to carry the request ID, got %+v", response.Entries)
+	}
+}
+
+// TestCatalog_SearchAndInstall tests searching the built-in catalog and installing an entry
+func TestCatalog_SearchAndInstall(t *testing.T) {
+	handler, _, cleanup := setupTestAPIHandler(t)
+	defer cleanup()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/catalog", handler.GetCatalog)
+	router.POST("/api/catalog/install", handler.InstallFromCatalog)
+	router.POST("/api/catalog/sync", handler.SyncCatalog)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/catalog?q=memory", nil))
+
+	var response struct {
+		Entries  []models.CatalogEntry `json:"entries"`
+		Registry bool                  `json:"registry"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(response.Entries) != 1 || response.Entries[0].Name != "memory" || response.Registry {
+		t.Fatalf("Expected the memory entry without a registry, got %+v", response)
+	}
+
+	body := `{"entry": "memory", "name": "notes"}`
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/catalog/install", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := handler.mcpManager.GetServerStatus("notes"); err != nil {
+		t.Errorf("Expected the installed server: %v", err)
+	}
+
+	body = `{"entry": "filesystem"}`
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/catalog/install", strings.NewReader(body)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "DIRECTORY") {
+		t.Errorf("Expected a missing value error, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/catalog/sync", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without a registry, got %d", w.Code)
+	}
+}
+
+func TestTemplates_CreateAndRerender(t *testing.T) {
+	handler, _, cleanup := setupTestAPIHandler(t)
+	defer cleanup()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/templates", handler.GetTemplates)
+	router.PUT("/api/templates/:name", handler.SetTemplate)
+	router.POST("/api/templates/:name/servers", handler.AddServerFromTemplate)
+	router.POST("/api/templates/:name/rerender", handler.RerenderTemplate)
+
+	template := `{"params": [{"name": "dir", "type": "path", "required": true}],
+		"config": {"command": "echo", "args": ["{{.dir}}"]}}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PUT", "/api/templates/echo", strings.NewReader(template)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/templates/echo/servers", strings.NewReader(`{"name": "from-template"}`)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "'dir' is required") {
+		t.Errorf("Expected a missing parameter error, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := `{"name": "from-template", "params": {"dir": "/srv"}}`
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/templates/echo/servers", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Editing the template reports the server made from it
+	template = strings.Replace(template, `["{{.dir}}"]`, `["--root", "{{.dir}}"]`, 1)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PUT", "/api/templates/echo", strings.NewReader(template)))
+	var response struct {
+		Servers []string `json:"servers"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(response.Servers) != 1 || response.Servers[0] != "from-template" {
+		t.Fatalf("Expected the derived server, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/templates/echo/rerender", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	serverConfig, err := handler.mcpManager.GetServerStatus("from-template")
+	if err != nil {
+		t.Fatalf("Expected the server: %v", err)
+	}
+	if args := serverConfig["args"].([]interface{}); len(args) != 2 {
+		t.Errorf("Expected the re-rendered args, got %v", args)
+	}
+}
+
+
+// TestValidateConfig_API tests that problems in config.yaml are listed with locations
+func TestValidateConfig_API(t *testing.T) {
+	handler, tempDir, cleanup := setupTestAPIHandler(t)
+	defer cleanup()
+
+	configPath := filepath.Join(tempDir, "config.yaml")
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if err := os.WriteFile(configPath, append([]byte("sever_port: 1\n"), data...), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/config/validate", handler.ValidateConfig)
+	router.GET("/schema/config.json", ConfigSchema)
+
+	req, _ := http.NewRequest("GET", "/api/config/validate", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response struct {
+		Valid       bool                `json:"valid"`
+		Diagnostics []config.Diagnostic `json:"diagnostics"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.Valid || len(response.Diagnostics) == 0 {
+		t.Fatalf("Expected diagnostics, got %s", w.Body.String())
+	}
+	first := response.Diagnostics[0]
+	if first.Path != "sever_port" || first.Line != 1 || first.Column != 1 || first.File != configPath {
+		t.Errorf("Expected the unknown key at 1:1, got %+v", first)
+	}
+
+	req, _ = http.NewRequest("GET", "/schema/config.json", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !json.Valid(w.Body.Bytes()) {
+		t.Errorf("Expected the schema, got %d", w.Code)
+	}
+}
+
+// TestOAuth_BrowserFlow signs in to a server through the web routes against a
+// stand-in authorization server, as a browser following the redirects would
+func TestOAuth_BrowserFlow(t *testing.T) {
+	authServer := testutil.NewOAuthServer(t)
+	tempDir := t.TempDir()
+	clientPath := filepath.Join(tempDir, "client.json")
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{
+			{Name: "remote", OAuth: &models.OAuthConfig{}, Config: map[string]interface{}{"type": "http", "url": authServer.ResourceURL()}},
+		},
+		Clients: map[string]*models.Client{
+			"test-client": {ConfigPath: clientPath, Enabled: []string{"remote"}},
+		},
+	}
+	mcpManager := services.NewMCPManagerService(cfg, filepath.Join(tempDir, "config.yaml"))
+	apiHandler := NewAPIHandler(mcpManager)
+	webHandler := NewWebHandler(mcpManager)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET(OAuthCallbackPath, webHandler.OAuthCallback)
+	router.GET("/oauth/:server/start", webHandler.StartOAuth)
+	router.GET("/api/oauth", apiHandler.GetOAuthStatus)
+	router.POST("/api/servers/:server/oauth", apiHandler.StartOAuth)
+	router.POST("/htmx/servers/:server/oauth/signout", webHandler.SignOutOAuthHTMX)
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Host = "127.0.0.1:6543"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("GET", "/oauth/remote/start")
+	if w.Code != http.StatusFound || !strings.HasPrefix(w.Header().Get("Location"), authServer.Issuer()+"/authorize?") {
+		t.Fatalf("Expected a redirect to the authorization server, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+
+	// The stand-in signs the user in at once and sends the browser back
+	browser := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := browser.Get(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("Authorization request failed: %v", err)
+	}
+	resp.Body.Close()
+	callback := resp.Header.Get("Location")
+	if !strings.HasPrefix(callback, "http://127.0.0.1:6543"+OAuthCallbackPath+"?") {
+		t.Fatalf("Expected a redirect to the callback, got %d %s", resp.StatusCode, callback)
+	}
+
+	w = serve("GET", strings.TrimPrefix(callback, "http://127.0.0.1:6543"))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/" {
+		t.Fatalf("Expected a redirect to the UI, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var status struct {
+		Servers map[string]services.OAuthStatus `json:"servers"`
+	}
+	w = serve("GET", "/api/oauth")
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil || !status.Servers["remote"].SignedIn {
+		t.Fatalf("Expected to be signed in, got %s", w.Body.String())
+	}
+	data, err := os.ReadFile(clientPath)
+	if err != nil || !strings.Contains(string(data), `"Authorization": "Bearer access-`) {
+		t.Errorf("Expected the token in the client file, got %s", data)
+	}
+
+	// Errors from the authorization server are shown, escaped
+	w = serve("GET", OAuthCallbackPath+"?error=access_denied&error_description=%3Cb%3Eno%3C%2Fb%3E")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "access_denied (&lt;b&gt;no&lt;/b&gt;)") {
+		t.Errorf("Expected the escaped error, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serve("POST", "/api/servers/remote/oauth")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"redirect_uri":"http://127.0.0.1:6543/oauth/callback"`) {
+		t.Errorf("Expected an authorization URL, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The callback does not follow the Host the request names
+	req := httptest.NewRequest("POST", "/api/servers/remote/oauth", nil)
+	req.Host = "attacker.example"
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if strings.Contains(w.Body.String(), "attacker.example") {
+		t.Errorf("Expected the configured callback, got %s", w.Body.String())
+	}
+
+	w = serve("POST", "/htmx/servers/remote/oauth/signout")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if data, _ := os.ReadFile(clientPath); strings.Contains(string(data), "Authorization") {
+		t.Errorf("Expected the token to be removed, got %s", data)
+	}
+}
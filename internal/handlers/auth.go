@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+const (
+	sessionCookieName = "mcp_session"
+	csrfHeaderName    = "X-CSRF-Token"
+	csrfFormField     = "csrf_token"
+	csrfContextKey    = "csrfToken"
+
+	defaultSessionTTL = 24 * time.Hour
+
+	// After loginFreeAttempts failed logins from one address, each further attempt
+	// waits twice as long as the previous one, up to loginMaxBackoff
+	loginFreeAttempts = 5
+	loginMaxBackoff   = 15 * time.Minute
+)
+
+// session is a logged-in browser. The CSRF token is bound to the session and must
+// accompany every state-changing request made with the session cookie.
+type session struct {
+	csrfToken string
+	expires   time.Time
+}
+
+// loginFailures counts the failed logins from one remote address
+type loginFailures struct {
+	count int
+	last  time.Time
+	until time.Time // Attempts before this are refused without checking the password
+}
+
+// AuthHandler guards the web UI and REST API with the credentials from config.yaml.
+// When no credentials are configured every request is let through; the validator
+// makes sure that only happens when the manager is bound to a loopback address.
+type AuthHandler struct {
+	auth *models.AuthConfig
+	ttl  time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	failures map[string]*loginFailures // Remote address -> failed logins
+}
+
+func NewAuthHandler(auth *models.AuthConfig) *AuthHandler {
+	h := &AuthHandler{
+		auth:     auth,
+		ttl:      defaultSessionTTL,
+		sessions: make(map[string]*session),
+		failures: make(map[string]*loginFailures),
+	}
+	if auth != nil && auth.SessionTTLHours > 0 {
+		h.ttl = time.Duration(auth.SessionTTLHours) * time.Hour
+	}
+	return h
+}
+
+// Enabled reports whether requests must authenticate
+func (h *AuthHandler) Enabled() bool {
+	return h.auth.Enabled()
+}
+
+// RequireAPI accepts a bearer token, or a session cookie plus CSRF token as sent by the web UI
+func (h *AuthHandler) RequireAPI() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.Enabled() {
+			c.Next()
+			return
+		}
+
+		if header := c.GetHeader("Authorization"); header != "" {
+			token, found := strings.CutPrefix(header, "Bearer ")
+			if !found || !h.validToken(token) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid bearer token"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		sess := h.currentSession(c)
+		if sess == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+		if !checkCSRF(c, sess) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Invalid CSRF token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession protects the web UI and HTMX routes. Page requests without a session
+// are redirected to the login form; HTMX requests get 401 and the client redirects.
+func (h *AuthHandler) RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.Enabled() {
+			c.Next()
+			return
+		}
+
+		sess := h.currentSession(c)
+		if sess == nil {
+			if c.Request.Method == http.MethodGet && c.GetHeader("HX-Request") == "" {
+				c.Redirect(http.StatusSeeOther, "/login")
+				c.Abort()
+				return
+			}
+			c.Header("HX-Redirect", "/login")
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		if !checkCSRF(c, sess) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Next()
+	}
+}
+
+// LoginPage renders the login form
+func (h *AuthHandler) LoginPage(c *gin.Context) {
+	if !h.Enabled() || h.currentSession(c) != nil {
+		c.Redirect(http.StatusSeeOther, "/")
+		return
+	}
+	c.HTML(http.StatusOK, "login.html", gin.H{})
+}
+
+// Login checks the password (or an API token) and starts a session. Repeated
+// failures from one address are slowed down, see loginFreeAttempts.
+func (h *AuthHandler) Login(c *gin.Context) {
+	if !h.Enabled() {
+		c.Redirect(http.StatusSeeOther, "/")
+		return
+	}
+
+	// RemoteIP, unlike ClientIP, cannot be chosen with X-Forwarded-For
+	addr := c.RemoteIP()
+	if wait := h.loginBackoff(addr); wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		c.HTML(http.StatusTooManyRequests, "login.html", gin.H{"error": fmt.Sprintf("Too many failed logins, try again in %s", wait.Round(time.Second))})
+		return
+	}
+
+	if !h.validPassword(c.PostForm("password")) {
+		h.recordLoginFailure(addr)
+		c.HTML(http.StatusUnauthorized, "login.html", gin.H{"error": "Invalid password"})
+		return
+	}
+
+	id := randomToken()
+	h.mu.Lock()
+	delete(h.failures, addr)
+	h.pruneSessions()
+	h.sessions[id] = &session{csrfToken: randomToken(), expires: time.Now().Add(h.ttl)}
+	h.mu.Unlock()
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(h.ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	c.Redirect(http.StatusSeeOther, "/")
+}
+
+// Logout ends the current session
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if cookie, err := c.Cookie(sessionCookieName); err == nil {
+		h.mu.Lock()
+		delete(h.sessions, cookie)
+		h.mu.Unlock()
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	c.Redirect(http.StatusSeeOther, "/login")
+}
+
+// currentSession returns the valid session of the request and exposes its CSRF
+// token to templates, or nil when the request has no valid session
+func (h *AuthHandler) currentSession(c *gin.Context) *session {
+	id, err := c.Cookie(sessionCookieName)
+	if err != nil || id == "" {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sess, exists := h.sessions[id]
+	if !exists {
+		return nil
+	}
+	if time.Now().After(sess.expires) {
+		delete(h.sessions, id)
+		return nil
+	}
+
+	c.Set(csrfContextKey, sess.csrfToken)
+	return sess
+}
+
+// pruneSessions drops expired sessions; callers hold h.mu
+func (h *AuthHandler) pruneSessions() {
+	now := time.Now()
+	for id, sess := range h.sessions {
+		if now.After(sess.expires) {
+			delete(h.sessions, id)
+		}
+	}
+}
+
+// loginBackoff returns how long logins from addr are still refused
+func (h *AuthHandler) loginBackoff(addr string) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if failures, exists := h.failures[addr]; exists {
+		return time.Until(failures.until)
+	}
+	return 0
+}
+
+// recordLoginFailure counts a failed login from addr and, past the free
+// attempts, refuses further ones for a doubling delay. Failures are forgotten
+// once an address has been quiet for loginMaxBackoff.
+func (h *AuthHandler) recordLoginFailure(addr string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for other, failures := range h.failures {
+		if now.Sub(failures.last) > loginMaxBackoff && now.After(failures.until) {
+			delete(h.failures, other)
+		}
+	}
+
+	failures, exists := h.failures[addr]
+	if !exists {
+		failures = &loginFailures{}
+		h.failures[addr] = failures
+	}
+	failures.count++
+	failures.last = now
+
+	if extra := failures.count - loginFreeAttempts; extra >= 0 {
+		wait := loginMaxBackoff
+		if extra < 20 {
+			wait = min(time.Second<<extra, loginMaxBackoff)
+		}
+		failures.until = now.Add(wait)
+	}
+}
+
+func (h *AuthHandler) validToken(token string) bool {
+	valid := false
+	for _, candidate := range h.auth.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(candidate)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+func (h *AuthHandler) validPassword(password string) bool {
+	if password == "" {
+		return false
+	}
+	if h.auth.PasswordHash != "" {
+		if bcrypt.CompareHashAndPassword([]byte(h.auth.PasswordHash), []byte(password)) == nil {
+			return true
+		}
+	} else if h.auth.Password != "" && subtle.ConstantTimeCompare([]byte(password), []byte(h.auth.Password)) == 1 {
+		return true
+	}
+	return h.validToken(password)
+}
+
+// checkCSRF requires the session's CSRF token on every request that can change state
+func checkCSRF(c *gin.Context, sess *session) bool {
+	if !changesState(c.Request.Method) {
+		return true
+	}
+
+	token := c.GetHeader(csrfHeaderName)
+	if token == "" {
+		token = c.PostForm(csrfFormField)
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(sess.csrfToken)) == 1
+}
+
+// CSRFToken returns the CSRF token of the current session, empty when auth is off
+func CSRFToken(c *gin.Context) string {
+	return c.GetString(csrfContextKey)
+}
+
+func randomToken() string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+const testToken = "test-token-0123456789abcdef"
+
+// jsonRequest marks a request as JSON, which the API requires for changes
+var jsonRequest = map[string]string{"Content-Type": "application/json"}
+
+// setupAuthRouter wires the auth middleware the same way main does, with stub handlers
+func setupAuthRouter(auth *models.AuthConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.SetHTMLTemplate(template.Must(template.New("login.html").Parse(`{{.error}}`)))
+
+	authHandler := NewAuthHandler(auth)
+	ok := func(c *gin.Context) { c.String(http.StatusOK, CSRFToken(c)) }
+
+	r.GET("/login", authHandler.LoginPage)
+	r.POST("/login", authHandler.Login)
+
+	ui := r.Group("/", authHandler.RequireSession())
+	ui.GET("/", ok)
+	ui.POST("/logout", authHandler.Logout)
+
+	r.Group("/api", authHandler.RequireAPI(), RequireJSON()).POST("/sync", ok)
+	r.Group("/htmx", authHandler.RequireSession()).POST("/toggle", ok)
+	return r
+}
+
+func doRequest(r *gin.Engine, method, path string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// login signs in and returns the session cookie and its CSRF token
+func login(t *testing.T, r *gin.Engine, password string) (string, string) {
+	t.Helper()
+	w := doRequest(r, http.MethodPost, "/login", url.Values{"password": {password}}.Encode(), nil)
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("Expected login redirect, got %d: %s", w.Code, w.Body.String())
+	}
+
+	cookie := w.Result().Cookies()[0]
+	if !cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode {
+		t.Errorf("Expected HttpOnly SameSite=Strict session cookie, got %+v", cookie)
+	}
+	sessionCookie := cookie.Name + "=" + cookie.Value
+
+	page := doRequest(r, http.MethodGet, "/", "", map[string]string{"Cookie": sessionCookie})
+	if page.Code != http.StatusOK || page.Body.Len() == 0 {
+		t.Fatalf("Expected page with CSRF token after login, got %d", page.Code)
+	}
+	return sessionCookie, page.Body.String()
+}
+
+func TestAuth_Disabled(t *testing.T) {
+	r := setupAuthRouter(nil)
+
+	if w := doRequest(r, http.MethodPost, "/api/sync", "", jsonRequest); w.Code != http.StatusOK {
+		t.Errorf("Expected API access without auth config, got %d", w.Code)
+	}
+	if w := doRequest(r, http.MethodPost, "/htmx/toggle", "", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected HTMX access without auth config, got %d", w.Code)
+	}
+}
+
+func TestAuth_BearerToken(t *testing.T) {
+	r := setupAuthRouter(&models.AuthConfig{Tokens: []string{testToken}})
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{name: "Missing credentials", header: "", want: http.StatusUnauthorized},
+		{name: "Wrong token", header: "Bearer wrong", want: http.StatusUnauthorized},
+		{name: "Wrong scheme", header: "Basic " + testToken, want: http.StatusUnauthorized},
+		{name: "Valid token", header: "Bearer " + testToken, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{"Content-Type": "application/json"}
+			if tt.header != "" {
+				headers["Authorization"] = tt.header
+			}
+			if w := doRequest(r, http.MethodPost, "/api/sync", "", headers); w.Code != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+
+	// Bearer tokens authenticate API clients only, not the HTMX routes
+	w := doRequest(r, http.MethodPost, "/htmx/toggle", "", map[string]string{"Authorization": "Bearer " + testToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected HTMX route to require a session, got %d", w.Code)
+	}
+}
+
+func TestAuth_SessionAndCSRF(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := setupAuthRouter(&models.AuthConfig{PasswordHash: string(hash)})
+
+	if w := doRequest(r, http.MethodGet, "/", "", nil); w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/login" {
+		t.Errorf("Expected redirect to /login, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+
+	if w := doRequest(r, http.MethodPost, "/login", "password=wrong", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong password, got %d", w.Code)
+	}
+
+	sessionCookie, csrfToken := login(t, r, "s3cret")
+
+	withSession := map[string]string{"Cookie": sessionCookie}
+	if w := doRequest(r, http.MethodPost, "/htmx/toggle", "", withSession); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without CSRF token, got %d", w.Code)
+	}
+
+	withCSRF := map[string]string{"Cookie": sessionCookie, "X-CSRF-Token": csrfToken}
+	if w := doRequest(r, http.MethodPost, "/htmx/toggle", "", withCSRF); w.Code != http.StatusOK {
+		t.Errorf("Expected HTMX request with session and CSRF token to succeed, got %d", w.Code)
+	}
+	if w := doRequest(r, http.MethodPost, "/api/sync", "", map[string]string{"Cookie": sessionCookie, "X-CSRF-Token": csrfToken, "Content-Type": "application/json"}); w.Code != http.StatusOK {
+		t.Errorf("Expected API request from the web UI to succeed, got %d", w.Code)
+	}
+
+	if w := doRequest(r, http.MethodPost, "/logout", "csrf_token="+csrfToken, withSession); w.Code != http.StatusSeeOther {
+		t.Errorf("Expected logout redirect, got %d", w.Code)
+	}
+	if w := doRequest(r, http.MethodPost, "/htmx/toggle", "", withCSRF); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected session to end after logout, got %d", w.Code)
+	}
+}
+
+func TestAuth_TokenLogin(t *testing.T) {
+	r := setupAuthRouter(&models.AuthConfig{Tokens: []string{testToken}})
+
+	if w := doRequest(r, http.MethodPost, "/login", "password=", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an empty password, got %d", w.Code)
+	}
+
+	login(t, r, testToken)
+}
+
+func TestAuth_RequireJSON(t *testing.T) {
+	r := setupAuthRouter(nil)
+
+	if w := doRequest(r, http.MethodPost, "/api/sync", "", nil); w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected 415 without a content type, got %d", w.Code)
+	}
+	// A cross-site form can send these without a preflight
+	for _, contentType := range []string{"application/x-www-form-urlencoded", "text/plain", "multipart/form-data"} {
+		if w := doRequest(r, http.MethodPost, "/api/sync", "a=b", map[string]string{"Content-Type": contentType}); w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("Expected 415 for %s, got %d", contentType, w.Code)
+		}
+	}
+	if w := doRequest(r, http.MethodPost, "/api/sync", "{}", map[string]string{"Content-Type": "application/json; charset=utf-8"}); w.Code != http.StatusOK {
+		t.Errorf("Expected JSON request to succeed, got %d", w.Code)
+	}
+}
+
+func TestAuth_LoginBackoff(t *testing.T) {
+	r := setupAuthRouter(&models.AuthConfig{Tokens: []string{testToken}})
+
+	for i := 0; i < loginFreeAttempts; i++ {
+		if w := doRequest(r, http.MethodPost, "/login", "password=wrong", nil); w.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected 401 for failed attempt %d, got %d", i+1, w.Code)
+		}
+	}
+
+	// Even the right password is refused while backing off, so guessing gains nothing
+	w := doRequest(r, http.MethodPost, "/login", "password="+testToken, nil)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 429 with Retry-After after repeated failures, got %d", w.Code)
+	}
+
+	// Other addresses are not affected
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("password="+testToken))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "192.0.2.7:40000"
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusSeeOther {
+		t.Errorf("Expected login from another address to succeed, got %d", w.Code)
+	}
+}
+
+func TestHostGuard(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *models.Config
+		method  string
+		host    string
+		headers map[string]string
+		want    int
+	}{
+		{name: "Loopback name", cfg: &models.Config{BindAddress: "127.0.0.1"}, method: http.MethodGet, host: "localhost:6543", want: http.StatusOK},
+		{name: "Bind address", cfg: &models.Config{BindAddress: "127.0.0.1"}, method: http.MethodGet, host: "127.0.0.1:6543", want: http.StatusOK},
+		{name: "IPv6 loopback", cfg: &models.Config{BindAddress: "::1"}, method: http.MethodGet, host: "[::1]:6543", want: http.StatusOK},
+		{name: "Rebound name", cfg: &models.Config{BindAddress: "127.0.0.1"}, method: http.MethodGet, host: "attacker.example:6543", want: http.StatusForbidden},
+		{name: "Other name on a LAN address", cfg: &models.Config{BindAddress: "192.168.1.5"}, method: http.MethodGet, host: "localhost:6543", want: http.StatusForbidden},
+		{name: "Any name on all interfaces", cfg: &models.Config{BindAddress: "0.0.0.0"}, method: http.MethodGet, host: "manager.lan:6543", want: http.StatusOK},
+		{name: "Public URL host", cfg: &models.Config{BindAddress: "127.0.0.1", PublicURL: "https://MCP.example.com"}, method: http.MethodGet, host: "mcp.example.com", want: http.StatusOK},
+		{name: "Any name on a socket", cfg: &models.Config{UnixSocket: &models.UnixSocketConfig{Path: "/tmp/mcp.sock"}}, method: http.MethodGet, host: "whatever", want: http.StatusOK},
+		{
+			name: "Same origin", cfg: &models.Config{BindAddress: "127.0.0.1"}, method: http.MethodPost, host: "localhost:6543",
+			headers: map[string]string{"Origin": "http://localhost:6543"}, want: http.StatusOK,
+		},
+		{
+			name: "Foreign origin", cfg: &models.Config{BindAddress: "127.0.0.1"}, method: http.MethodPost, host: "localhost:6543",
+			headers: map[string]string{"Origin": "https://attacker.example"}, want: http.StatusForbidden,
+		},
+		{
+			name: "Opaque origin", cfg: &models.Config{BindAddress: "127.0.0.1"}, method: http.MethodPost, host: "localhost:6543",
+			headers: map[string]string{"Origin": "null"}, want: http.StatusForbidden,
+		},
+		{
+			name: "Foreign origin reading", cfg: &models.Config{BindAddress: "127.0.0.1"}, method: http.MethodGet, host: "localhost:6543",
+			headers: map[string]string{"Origin": "https://attacker.example"}, want: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(HostGuard(tt.cfg))
+			r.Handle(tt.method, "/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.Host = tt.host
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestOAuthRedirectURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *models.Config
+		want    string
+		wantErr string
+	}{
+		{name: "Bind address", cfg: &models.Config{BindAddress: "127.0.0.1", ServerPort: 6543}, want: "http://127.0.0.1:6543/oauth/callback"},
+		{name: "IPv6 with TLS", cfg: &models.Config{BindAddress: "::1", ServerPort: 6543, TLS: &models.TLSConfig{Enabled: true}}, want: "https://[::1]:6543/oauth/callback"},
+		{name: "Public URL", cfg: &models.Config{BindAddress: "0.0.0.0", ServerPort: 6543, PublicURL: "https://mcp.example.com/"}, want: "https://mcp.example.com/oauth/callback"},
+		{name: "All interfaces", cfg: &models.Config{BindAddress: "0.0.0.0", ServerPort: 6543}, wantErr: "set public_url"},
+		{name: "Unix socket", cfg: &models.Config{UnixSocket: &models.UnixSocketConfig{Path: "/tmp/mcp.sock"}}, wantErr: "set public_url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := oauthRedirectURI(tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("oauthRedirectURI() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
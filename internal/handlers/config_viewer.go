@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"os"
+	"sort"
 
 	"github.com/gin-gonic/gin"
 
@@ -58,4 +59,32 @@ func (h *ConfigViewerHandler) GetClientConfig(c *gin.Context) {
 		"content":  string(configJson),
 		"language": "json",
 	})
+}
+
+// GetConfigLayers shows the files the configuration is merged from and the
+// values set by overlays
+func (h *ConfigViewerHandler) GetConfigLayers(c *gin.Context) {
+	layers, err := h.mcpManager.ConfigLayers()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error reading config layers: %s", err.Error())
+		return
+	}
+
+	type OriginView struct {
+		Path string
+		File string
+	}
+
+	overrides := make([]OriginView, 0)
+	for path, file := range layers.Origins {
+		if file != layers.Files[0] {
+			overrides = append(overrides, OriginView{Path: path, File: file})
+		}
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].Path < overrides[j].Path })
+
+	c.HTML(http.StatusOK, "config_layers.html", gin.H{
+		"files":     layers.Files,
+		"overrides": overrides,
+	})
 }
\ No newline at end of file
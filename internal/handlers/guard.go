@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services"
+)
+
+// RequireJSON refuses state-changing API requests that are not sent as JSON. A
+// page on another site can post a form or plain text without a preflight, but
+// not application/json, so this keeps such pages away from the API even when
+// auth is off.
+func RequireJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !changesState(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/json"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// HostGuard rejects requests addressed to a host name the manager does not
+// listen on, which is how DNS rebinding lets a web page reach a manager bound
+// to loopback, and state-changing requests whose Origin is another site
+func HostGuard(cfg *models.Config) gin.HandlerFunc {
+	hosts := allowedHosts(cfg)
+	return func(c *gin.Context) {
+		if hosts != nil && !hosts[strings.ToLower(requestHostname(c.Request.Host))] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Unknown host"})
+			return
+		}
+
+		if origin := c.GetHeader("Origin"); origin != "" && changesState(c.Request.Method) {
+			u, err := url.Parse(origin)
+			if err != nil || !strings.EqualFold(u.Host, c.Request.Host) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Cross-origin request refused"})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// allowedHosts lists the host names requests may address: the bind address,
+// loopback names when bound to loopback, and the host of public_url. It is nil
+// when any name can reach the listener: on a Unix socket, or bound to every
+// interface, where the validator requires auth.
+func allowedHosts(cfg *models.Config) map[string]bool {
+	if cfg.UnixSocket != nil {
+		return nil
+	}
+	if ip := net.ParseIP(strings.Trim(cfg.BindAddress, "[]")); ip != nil && ip.IsUnspecified() {
+		return nil
+	}
+
+	hosts := map[string]bool{strings.ToLower(strings.Trim(cfg.BindAddress, "[]")): true}
+	if cfg.BindAddress == "" || services.IsLoopbackAddress(cfg.BindAddress) {
+		for _, name := range []string{"localhost", "127.0.0.1", "::1"} {
+			hosts[name] = true
+		}
+	}
+	if u, err := url.Parse(cfg.PublicURL); err == nil && u.Hostname() != "" {
+		hosts[strings.ToLower(u.Hostname())] = true
+	}
+	return hosts
+}
+
+// requestHostname strips the port and IPv6 brackets from a Host header
+func requestHostname(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return name
+	}
+	return strings.Trim(host, "[]")
+}
+
+// changesState reports whether a request with this method can change state
+func changesState(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	requestIDHeader     = "X-Request-ID"
+	requestIDContextKey = "request_id"
+)
+
+// validRequestID limits IDs taken from the request to something safe to log
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID gives every request an ID, reusing a well-formed X-Request-ID from a
+// proxy, and returns it in the X-Request-ID response header. Changes made by the
+// request carry it into the service logs and the audit log.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = randomToken()[:16]
+		}
+
+		c.Set(requestIDContextKey, id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// RequestLogger logs each request once it has been handled. Server errors are
+// logged as errors and client errors as warnings.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []any{
+			"request_id", c.GetString(requestIDContextKey),
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration", time.Since(start),
+			"remote_addr", c.ClientIP(),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "error", c.Errors.String())
+		}
+
+		slog.Log(c.Request.Context(), level, "HTTP request", attrs...)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vlazic/mcp-server-manager/internal/metrics"
+)
+
+var (
+	httpRequestsTotal = metrics.Default.NewCounterVec("mcp_manager_http_requests_total",
+		"HTTP requests by method, route and status code.", "method", "route", "status")
+	httpRequestSeconds = metrics.Default.NewHistogramVec("mcp_manager_http_request_duration_seconds",
+		"HTTP request latency by method and route.", metrics.DefaultBuckets, "method", "route")
+)
+
+// RequestMetrics counts requests and their latency per route pattern, e.g.
+// /api/clients/:client/servers/:server/toggle. Unknown paths share one label so
+// scanners cannot create unbounded series.
+func RequestMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+
+		httpRequestsTotal.Inc(method, route, strconv.Itoa(c.Writer.Status()))
+		httpRequestSeconds.Observe(time.Since(start).Seconds(), method, route)
+	}
+}
+
+// Metrics serves all metrics in the Prometheus text format
+func Metrics(c *gin.Context) {
+	c.Header("Content-Type", metrics.ContentType)
+	c.Status(http.StatusOK)
+	if err := metrics.Default.WriteText(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
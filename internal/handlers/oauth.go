@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// OAuthCallbackPath is where authorization servers send the browser back to
+// after signing in to a server
+const OAuthCallbackPath = "/oauth/callback"
+
+// oauthRedirectURI returns the callback URL under public_url or, without one,
+// at the address the manager listens on. The request's Host is not used: the
+// client chooses it, and authorization codes would go wherever it names.
+func oauthRedirectURI(cfg *models.Config) (string, error) {
+	if cfg.PublicURL != "" {
+		return strings.TrimSuffix(cfg.PublicURL, "/") + OAuthCallbackPath, nil
+	}
+	if cfg.UnixSocket != nil {
+		return "", errors.New("set public_url to sign in with OAuth while listening on a Unix socket")
+	}
+
+	host := cfg.BindAddress
+	if host == "" {
+		host = config.DefaultBindAddress
+	}
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil && ip.IsUnspecified() {
+		return "", errors.New("set public_url to sign in with OAuth while bound to every interface")
+	}
+
+	scheme := "http"
+	if cfg.TLS != nil && cfg.TLS.Enabled {
+		scheme = "https"
+	}
+	return scheme + "://" + net.JoinHostPort(strings.Trim(host, "[]"), strconv.Itoa(cfg.ServerPort)) + OAuthCallbackPath, nil
+}
+
+// StartOAuth sends the browser to the login page of a server's authorization server
+func (h *WebHandler) StartOAuth(c *gin.Context) {
+	redirectURI, err := oauthRedirectURI(h.mcpManager.GetConfig())
+	if err != nil {
+		c.Data(http.StatusBadRequest, contentTypeHTML, []byte(renderErrorBox(template.HTMLEscapeString(err.Error()))))
+		return
+	}
+
+	authURL, err := h.mcpManager.StartOAuth(c.Param("server"), redirectURI)
+	if err != nil {
+		c.Data(http.StatusBadGateway, contentTypeHTML, []byte(renderErrorBox(template.HTMLEscapeString(err.Error()))))
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OAuthCallback completes a sign-in and returns to the server table. It needs
+// no session: the state parameter only matches a sign-in started from the UI.
+func (h *WebHandler) OAuthCallback(c *gin.Context) {
+	if code := c.Query("error"); code != "" {
+		message := "Sign-in was not completed: " + code
+		if description := c.Query("error_description"); description != "" {
+			message += " (" + description + ")"
+		}
+		c.Data(http.StatusBadRequest, contentTypeHTML, []byte(renderErrorBox(template.HTMLEscapeString(message))))
+		return
+	}
+
+	if _, err := h.manager(c).CompleteOAuth(c.Query("state"), c.Query("code")); err != nil {
+		c.Data(http.StatusBadRequest, contentTypeHTML, []byte(renderErrorBox(template.HTMLEscapeString(err.Error()))))
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/")
+}
+
+// SignOutOAuthHTMX forgets a server's tokens; the rows reload on the synced event
+func (h *WebHandler) SignOutOAuthHTMX(c *gin.Context) {
+	if err := h.manager(c).SignOutOAuth(c.Param("server")); err != nil {
+		c.Data(http.StatusBadRequest, contentTypeHTML, []byte(renderErrorBox(template.HTMLEscapeString(err.Error()))))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetOAuthStatus returns the sign-in state of every server using OAuth
+func (h *APIHandler) GetOAuthStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"servers": h.mcpManager.OAuthStatuses()})
+}
+
+// StartOAuth begins signing in to a server and returns the URL to open in a
+// browser; the authorization server sends it back to this manager's callback
+func (h *APIHandler) StartOAuth(c *gin.Context) {
+	redirectURI, err := oauthRedirectURI(h.mcpManager.GetConfig())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authURL, err := h.mcpManager.StartOAuth(c.Param("server"), redirectURI)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL, "redirect_uri": redirectURI})
+}
+
+// SignOutOAuth forgets a server's tokens and removes its Authorization header
+// from client files
+func (h *APIHandler) SignOutOAuth(c *gin.Context) {
+	if err := h.manager(c).SignOutOAuth(c.Param("server")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
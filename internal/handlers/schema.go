@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+)
+
+// ConfigSchema serves the JSON Schema of config.yaml, e.g. for editors with a
+// "# yaml-language-server: $schema=http://127.0.0.1:6543/schema/config.json" line
+func ConfigSchema(c *gin.Context) {
+	c.Data(http.StatusOK, "application/schema+json", config.Schema())
+}
+
+// ServerSchema serves the JSON Schema of a single mcpServers entry
+func ServerSchema(c *gin.Context) {
+	c.Data(http.StatusOK, "application/schema+json", config.ServerSchema())
+}
@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/vlazic/mcp-server-manager/internal/models"
 	"github.com/vlazic/mcp-server-manager/internal/services"
 )
 
@@ -22,14 +26,27 @@ func NewWebHandler(mcpManager *services.MCPManagerService) *WebHandler {
 	}
 }
 
-func (h *WebHandler) Index(c *gin.Context) {
+// manager credits changes made by this request to the web UI user in the audit log
+func (h *WebHandler) manager(c *gin.Context) *services.MCPManagerService {
+	return h.mcpManager.WithActor(services.Actor{Source: services.SourceHTMX, RemoteAddr: c.RemoteIP(), RequestID: c.GetString(requestIDContextKey)})
+}
+
+// serverTable builds the server rows and client columns of the main table. Clients
+// are sorted so the page and a live refresh of its rows agree on column order.
+func (h *WebHandler) serverTable() gin.H {
 	servers := h.mcpManager.GetMCPServers()
 	clientsMap := h.mcpManager.GetClients()
+	health := h.mcpManager.GetHealth()
 
 	// Convert to view structures
 	type ServerView struct {
-		Name   string
-		Config map[string]interface{}
+		Name     string
+		Tags     []string
+		Config   map[string]interface{}
+		Health   *services.HealthStatus   // Nil until checked
+		Overlays map[string][]string      // Overlay file -> fields it sets
+		Problems []services.ConfigProblem // Warnings such as a command missing on this machine
+		OAuth    *services.OAuthStatus    // Nil unless the server signs in with OAuth
 	}
 
 	type ClientView struct {
@@ -38,30 +55,97 @@ func (h *WebHandler) Index(c *gin.Context) {
 		Enabled    []string
 	}
 
+	// Overlay origins are a hint only; the table works without them
+	layers, _ := h.mcpManager.ConfigLayers()
+	problems := h.mcpManager.ServerProblems()
+	oauth := h.mcpManager.OAuthStatuses()
+
 	// Servers already ordered from config
 	serverViews := make([]ServerView, 0, len(servers))
 	for _, server := range servers {
-		serverViews = append(serverViews, ServerView{
+		view := ServerView{
 			Name:   server.Name,
+			Tags:   server.Tags,
 			Config: server.Config,
-		})
+		}
+		if status, checked := health[server.Name]; checked {
+			view.Health = &status
+		}
+		if layers != nil {
+			view.Overlays = layers.ServerOverlays(server.Name)
+		}
+		view.Problems = problems[server.Name]
+		if status, ok := oauth[server.Name]; ok {
+			view.OAuth = &status
+		}
+		serverViews = append(serverViews, view)
+	}
+
+	clientNames := make([]string, 0, len(clientsMap))
+	for name := range clientsMap {
+		clientNames = append(clientNames, name)
 	}
+	sort.Strings(clientNames)
 
-	clients := make([]ClientView, 0, len(clientsMap))
-	for name, client := range clientsMap {
+	clients := make([]ClientView, 0, len(clientNames))
+	for _, name := range clientNames {
 		clients = append(clients, ClientView{
 			Name:       name,
-			ConfigPath: client.ConfigPath,
-			Enabled:    client.Enabled,
+			ConfigPath: clientsMap[name].ConfigPath,
+			Enabled:    clientsMap[name].Enabled,
 		})
 	}
 
+	return gin.H{"servers": serverViews, "clients": clients}
+}
+
+func (h *WebHandler) Index(c *gin.Context) {
+	type ProjectView struct {
+		Name    string
+		Path    string
+		Clients []string
+		Enabled []string
+	}
+
+	projectsMap := h.mcpManager.GetProjects()
+	projectNames := make([]string, 0, len(projectsMap))
+	for name := range projectsMap {
+		projectNames = append(projectNames, name)
+	}
+	sort.Strings(projectNames)
+
+	projects := make([]ProjectView, 0, len(projectNames))
+	for _, name := range projectNames {
+		projects = append(projects, ProjectView{
+			Name:    name,
+			Path:    projectsMap[name].Path,
+			Clients: projectsMap[name].Clients,
+			Enabled: projectsMap[name].Enabled,
+		})
+	}
+
+	table := h.serverTable()
 	c.HTML(http.StatusOK, "index.html", gin.H{
-		"servers": serverViews,
-		"clients": clients,
+		"servers":  table["servers"],
+		"tags":     h.mcpManager.GetTags(),
+		"clients":  table["clients"],
+		"projects": projects,
+		"profiles": h.mcpManager.GetProfiles(),
+
+		"catalogRegistry": h.mcpManager.Catalog().RegistryConfigured(),
+
+		// A CSRF token only exists for a signed-in session
+		"csrfToken":   CSRFToken(c),
+		"authEnabled": CSRFToken(c) != "",
 	})
 }
 
+// ServerRows renders the rows of the server table; the page reloads them when
+// the event stream reports a change
+func (h *WebHandler) ServerRows(c *gin.Context) {
+	c.HTML(http.StatusOK, "server_rows.html", h.serverTable())
+}
+
 
 func (h *WebHandler) ToggleClientServerHTMX(c *gin.Context) {
 	clientName := c.Param("client")
@@ -75,7 +159,7 @@ func (h *WebHandler) ToggleClientServerHTMX(c *gin.Context) {
 		return
 	}
 
-	if err := h.mcpManager.ToggleClientMCPServer(clientName, serverName, enabled); err != nil {
+	if err := h.manager(c).ToggleClientMCPServer(clientName, serverName, enabled); err != nil {
 		errorHTML := renderClientToggleWithError(clientName, serverName, "Error: "+err.Error())
 		c.Data(http.StatusBadRequest, contentTypeHTML, []byte(errorHTML))
 		return
@@ -98,6 +182,7 @@ func (h *WebHandler) ToggleClientServerHTMX(c *gin.Context) {
 	}
 
 	// Success - return normal toggle with hidden error container
+	h.triggerUndoToast(c)
 	c.HTML(http.StatusOK, "client_toggle.html", gin.H{
 		"serverName":    serverName,
 		"serverConfig":  serverConfig,
@@ -106,8 +191,176 @@ func (h *WebHandler) ToggleClientServerHTMX(c *gin.Context) {
 	})
 }
 
+func (h *WebHandler) ToggleProjectServerHTMX(c *gin.Context) {
+	projectName := c.Param("project")
+	serverName := c.Param("server")
+	enabledStr := c.PostForm("enabled")
+
+	enabled, err := strconv.ParseBool(enabledStr)
+	if err != nil {
+		c.Data(http.StatusBadRequest, contentTypeHTML, []byte(renderErrorBox("Invalid enabled value: "+enabledStr)))
+		return
+	}
+
+	result, err := h.manager(c).ToggleProjectServer(projectName, serverName, enabled, c.PostForm("confirm") == "true")
+	if errors.Is(err, services.ErrConfirmationRequired) {
+		// Keep the server disabled and offer to write the credentials anyway
+		c.HTML(http.StatusOK, "project_toggle.html", gin.H{
+			"serverName":     serverName,
+			"project":        projectName,
+			"projectEnabled": h.mcpManager.GetProjects()[projectName].Enabled,
+			"warnings":       []string{err.Error()},
+			"confirm":        true,
+		})
+		return
+	}
+	if err != nil {
+		c.Data(http.StatusBadRequest, contentTypeHTML, []byte(renderErrorBox("Error: "+err.Error())))
+		return
+	}
+
+	project, exists := h.mcpManager.GetProjects()[projectName]
+	if !exists {
+		c.Data(http.StatusInternalServerError, contentTypeHTML, []byte(renderErrorBox("Project not found")))
+		return
+	}
+
+	h.triggerUndoToast(c)
+	c.HTML(http.StatusOK, "project_toggle.html", gin.H{
+		"serverName":     serverName,
+		"project":        projectName,
+		"projectEnabled": project.Enabled,
+		"warnings":       result.Warnings,
+	})
+}
+
+// CatalogResults renders the catalog entries matching ?q= for the catalog browser
+func (h *WebHandler) CatalogResults(c *gin.Context) {
+	entries, err := h.mcpManager.Catalog().Search(c.Query("q"))
+	if err != nil {
+		c.Data(http.StatusInternalServerError, contentTypeHTML, []byte(renderErrorBox("Error reading catalog: "+err.Error())))
+		return
+	}
+
+	installed := make(map[string]bool)
+	for _, server := range h.mcpManager.GetMCPServers() {
+		installed[server.Name] = true
+	}
+
+	c.HTML(http.StatusOK, "catalog_results.html", gin.H{"entries": entries, "installed": installed})
+}
+
+// Templates renders the template panel: a form to create a server from each
+// template and an editor for the template itself
+func (h *WebHandler) Templates(c *gin.Context) {
+	type TemplateView struct {
+		Name        string
+		Description string
+		Params      []models.TemplateParam
+		Servers     []string
+		JSON        string // Editable template definition
+	}
+
+	templates := h.mcpManager.GetTemplates()
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	views := make([]TemplateView, 0, len(names))
+	for _, name := range names {
+		tmpl := templates[name]
+		definition, err := json.MarshalIndent(tmpl, "", "  ")
+		if err != nil {
+			c.Data(http.StatusInternalServerError, contentTypeHTML, []byte(renderErrorBox("Error encoding template: "+err.Error())))
+			return
+		}
+		views = append(views, TemplateView{
+			Name:        name,
+			Description: tmpl.Description,
+			Params:      tmpl.Params,
+			Servers:     h.mcpManager.TemplateServers(name),
+			JSON:        string(definition),
+		})
+	}
+
+	c.HTML(http.StatusOK, "templates_panel.html", gin.H{"templates": views})
+}
+
+// triggerUndoToast tells the page, through an HX-Trigger event, that the change
+// just made can be undone
+func (h *WebHandler) triggerUndoToast(c *gin.Context) {
+	payload, err := json.Marshal(map[string]services.UndoStatus{"undoAvailable": h.mcpManager.GetUndoStatus()})
+	if err == nil {
+		c.Header("HX-Trigger", string(payload))
+	}
+}
+
+// historyLimit is the number of audit entries shown in the history panel
+const historyLimit = 50
+
+// History renders the newest audit log entries for the history panel
+func (h *WebHandler) History(c *gin.Context) {
+	entries, err := h.mcpManager.GetAuditLog(historyLimit)
+	if err != nil {
+		c.Data(http.StatusInternalServerError, contentTypeHTML, []byte(renderErrorBox("Error reading audit log: "+err.Error())))
+		return
+	}
+
+	type ChangeView struct {
+		Path   string
+		Before string
+		After  string
+	}
+
+	type EntryView struct {
+		Time       string
+		Source     string
+		RemoteAddr string
+		Action     string
+		Target     string
+		Error      string
+		Changes    []ChangeView
+	}
+
+	views := make([]EntryView, 0, len(entries))
+	for _, entry := range entries {
+		view := EntryView{
+			Time:       entry.Time.Local().Format("2006-01-02 15:04:05"),
+			Source:     entry.Actor.Source,
+			RemoteAddr: entry.Actor.RemoteAddr,
+			Action:     entry.Action,
+			Target:     entry.Target,
+			Error:      entry.Error,
+		}
+		for _, change := range entry.Changes {
+			view.Changes = append(view.Changes, ChangeView{
+				Path:   change.Path,
+				Before: formatAuditValue(change.Before),
+				After:  formatAuditValue(change.After),
+			})
+		}
+		views = append(views, view)
+	}
+
+	c.HTML(http.StatusOK, "audit_history.html", gin.H{"entries": views})
+}
+
 // Helper functions
 
+// formatAuditValue renders a changed value compactly, "-" when it was absent
+func formatAuditValue(value interface{}) string {
+	if value == nil {
+		return "-"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
@@ -0,0 +1,368 @@
+// Package jsonedit applies changes to JSON and JSONC documents without reformatting them.
+//
+// Client config files such as ~/.claude.json are owned by other programs and by
+// their users. Decoding them into a map and marshaling them back would sort every
+// key, drop the trailing newline, change the indentation and round large numbers.
+// Patch instead rewrites only the values that actually changed and copies every
+// other byte of the original document, including comments and trailing commas.
+package jsonedit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// defaultIndent is used for new documents and for documents without indentation hints
+const defaultIndent = "  "
+
+// Object is a JSON object with an explicit key order. Its members are written in
+// Keys order (keys missing from Values are skipped), followed by any other members
+// of Values: those already in the document keep their relative order, new ones
+// are sorted.
+type Object struct {
+	Keys   []string
+	Values map[string]interface{}
+}
+
+// MarshalJSON writes the members in Keys order, then the remaining members sorted
+func (o Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for _, key := range o.order(nil) {
+		value := o.Values[key]
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		keyData, err := marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valueData, err := marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyData)
+		buf.WriteByte(':')
+		buf.Write(valueData)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// order lists the keys to write: Keys first, then the other members of Values in
+// the order they appear in existing, then the rest sorted
+func (o Object) order(existing []string) []string {
+	seen := make(map[string]bool, len(o.Values))
+	var keys []string
+	add := func(key string) {
+		if _, exists := o.Values[key]; exists && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range o.Keys {
+		add(key)
+	}
+	for _, key := range existing {
+		add(key)
+	}
+
+	var rest []string
+	for key := range o.Values {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// Patch returns the original document changed so that its top-level object matches
+// updated. Members are rewritten only where their value differs; objects present on
+// both sides are patched recursively. Existing keys keep their position and new keys
+// are appended in sorted order, unless the updated value is an Object, which fixes
+// the order of its members.
+//
+// An empty original produces a freshly indented document.
+func Patch(original []byte, updated map[string]interface{}) ([]byte, error) {
+	if len(bytes.TrimSpace(original)) == 0 {
+		data, err := marshalIndent(updated, "", defaultIndent)
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+
+	s := &scanner{data: original}
+	leading := s.skipSpace()
+	obj, err := s.scanObject()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON document: %w", err)
+	}
+	trailing := s.skipSpace()
+	if s.pos != len(original) {
+		return nil, fmt.Errorf("failed to parse JSON document: %w", s.errorf("unexpected data after top-level object"))
+	}
+
+	p := &patcher{indent: detectIndent(obj)}
+
+	var buf bytes.Buffer
+	buf.Write(leading)
+	if err := p.writeObject(&buf, obj, updated, 0); err != nil {
+		return nil, err
+	}
+	buf.Write(trailing)
+	return buf.Bytes(), nil
+}
+
+// patcher carries the formatting conventions detected in the original document
+type patcher struct {
+	indent string // One level of indentation; empty for single-line documents
+}
+
+// writeObject writes obj patched to match the updated value at the given depth
+func (p *patcher) writeObject(buf *bytes.Buffer, obj *object, updated interface{}, depth int) error {
+	keys, values := p.targetMembers(obj, updated)
+
+	existing := make(map[string]member, len(obj.members))
+	for _, m := range obj.members {
+		existing[m.key] = m
+	}
+
+	if len(keys) == 0 {
+		buf.WriteString("{}")
+		return nil
+	}
+
+	lead, colon, closing := p.memberSpacing(obj, depth)
+
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		m, found := existing[key]
+		if !found {
+			raw, err := marshal(key)
+			if err != nil {
+				return err
+			}
+			m = member{key: key, raw: raw, colon: colon}
+		}
+
+		// Whitespace around members belongs to the position, not the member,
+		// so reordering keeps the layout of the object intact. Comments do
+		// belong to the member they precede and move along with it.
+		switch {
+		case found && hasComment(m.lead):
+			buf.Write(m.lead)
+		case i < len(obj.members) && !hasComment(obj.members[i].lead):
+			buf.Write(obj.members[i].lead)
+		default:
+			buf.Write(lead)
+		}
+		buf.Write(m.raw)
+		buf.Write(m.colon)
+		if err := p.writeValue(buf, m, found, values[key], depth+1); err != nil {
+			return err
+		}
+		if i < len(keys)-1 && i < len(obj.members)-1 {
+			buf.Write(obj.members[i].trail)
+		}
+	}
+	if obj.trailingComma {
+		buf.WriteByte(',')
+	}
+	buf.Write(closing)
+	buf.WriteByte('}')
+	return nil
+}
+
+// writeValue writes a member value, keeping the original bytes when nothing changed
+func (p *patcher) writeValue(buf *bytes.Buffer, m member, found bool, value interface{}, depth int) error {
+	if found {
+		// An Object may only differ in key order, which sameValue can't see
+		if _, ordered := value.(Object); !ordered {
+			equal, err := sameValue(m.value, value)
+			if err != nil {
+				return err
+			}
+			if equal {
+				buf.Write(m.value)
+				return nil
+			}
+		}
+
+		if len(m.value) > 0 && m.value[0] == '{' && isObject(value) {
+			s := &scanner{data: m.value}
+			obj, err := s.scanObject()
+			if err != nil {
+				return err
+			}
+			return p.writeObject(buf, obj, value, depth)
+		}
+	}
+
+	data, err := p.marshalAt(value, depth)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+// targetMembers lists the keys the patched object must contain, in output order
+func (p *patcher) targetMembers(obj *object, updated interface{}) ([]string, map[string]interface{}) {
+	existing := make([]string, 0, len(obj.members))
+	for _, m := range obj.members {
+		existing = append(existing, m.key)
+	}
+
+	ordered, isOrdered := updated.(Object)
+	if !isOrdered {
+		ordered = Object{Values: toMap(updated)}
+	}
+	return ordered.order(existing), ordered.Values
+}
+
+// memberSpacing returns the whitespace used before new keys, between new keys
+// and their values, and before the closing brace, taken from the object where possible
+func (p *patcher) memberSpacing(obj *object, depth int) (lead, colon, closing []byte) {
+	if n := len(obj.members); n > 0 {
+		for i := n - 1; i >= 0; i-- {
+			if !hasComment(obj.members[i].lead) {
+				return obj.members[i].lead, obj.members[0].colon, obj.closing
+			}
+		}
+		if p.indent == "" {
+			return []byte(" "), obj.members[0].colon, obj.closing
+		}
+		return []byte("\n" + strings.Repeat(p.indent, depth+1)), obj.members[0].colon, obj.closing
+	}
+
+	if p.indent == "" {
+		return nil, []byte(":"), nil
+	}
+	return []byte("\n" + strings.Repeat(p.indent, depth+1)), []byte(": "), []byte("\n" + strings.Repeat(p.indent, depth))
+}
+
+// marshalAt encodes a new value indented for the given depth
+func (p *patcher) marshalAt(value interface{}, depth int) ([]byte, error) {
+	if p.indent == "" {
+		return marshal(value)
+	}
+	return marshalIndent(value, strings.Repeat(p.indent, depth), p.indent)
+}
+
+// detectIndent infers one level of indentation from the first member of the top-level object
+func detectIndent(obj *object) string {
+	if len(obj.members) == 0 {
+		return defaultIndent
+	}
+
+	lead := string(obj.members[0].lead)
+	newline := strings.LastIndexByte(lead, '\n')
+	if newline < 0 {
+		return ""
+	}
+	return lead[newline+1:]
+}
+
+// hasComment reports whether whitespace captured by the scanner contains a comment
+func hasComment(space []byte) bool {
+	return bytes.IndexByte(space, '/') >= 0
+}
+
+// sameValue reports whether raw JSON and a Go value encode the same data.
+// Numbers are compared by their literal text, so 1e3 and 1000 differ but
+// precision beyond float64 is honoured.
+func sameValue(raw []byte, value interface{}) (bool, error) {
+	original, err := decode(Standardize(raw))
+	if err != nil {
+		return false, err
+	}
+
+	data, err := marshal(value)
+	if err != nil {
+		return false, err
+	}
+	current, err := decode(data)
+	if err != nil {
+		return false, err
+	}
+
+	return reflect.DeepEqual(original, current), nil
+}
+
+// toMap converts an object-like value to a plain map
+func toMap(value interface{}) map[string]interface{} {
+	if m, ok := value.(map[string]interface{}); ok {
+		return m
+	}
+
+	data, err := marshal(value)
+	if err != nil {
+		return nil
+	}
+	decoded, err := decode(data)
+	if err != nil {
+		return nil
+	}
+	m, _ := decoded.(map[string]interface{})
+	return m
+}
+
+func isObject(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, Object:
+		return true
+	}
+	return reflect.ValueOf(value).Kind() == reflect.Map
+}
+
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func decodeString(raw []byte) (string, error) {
+	var str string
+	err := json.Unmarshal(raw, &str)
+	return str, err
+}
+
+// marshal encodes without escaping <, > and &, which are common in URLs and commands
+func marshal(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func marshalIndent(value interface{}, prefix, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent(prefix, indent)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,270 @@
+package jsonedit
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// decodeDoc decodes a document the way callers do, keeping numbers as written
+func decodeDoc(t *testing.T, doc string) map[string]interface{} {
+	t.Helper()
+	value, err := decode([]byte(doc))
+	if err != nil {
+		t.Fatalf("Failed to decode test document: %v", err)
+	}
+	return value.(map[string]interface{})
+}
+
+func TestPatch_UnchangedDocumentIsIdentical(t *testing.T) {
+	docs := []string{
+		"{\n    \"zeta\": 1,\n    \"alpha\": {\"nested\": [1, 2, 3]},\n    \"big\": 12345678901234567890\n}\n",
+		"{\"compact\":true,\"list\":[]}",
+		"\t{\n\t\"tabs\": \"yes\"\n\t}\n\n",
+		"{}",
+	}
+
+	for _, doc := range docs {
+		patched, err := Patch([]byte(doc), decodeDoc(t, doc))
+		if err != nil {
+			t.Fatalf("Patch failed: %v", err)
+		}
+		if string(patched) != doc {
+			t.Errorf("Expected unchanged document\n%q\ngot\n%q", doc, patched)
+		}
+	}
+}
+
+func TestPatch_OnlyTouchesChangedSubtree(t *testing.T) {
+	original := `{
+    "numStartups": 12345678901234567890,
+    "zebra": "first",
+    "mcpServers": {
+        "keep": {"command": "a",   "args": ["x"]},
+        "change": {
+            "command": "old",
+            "env": {"B": "2", "A": "1"}
+        },
+        "remove": {"command": "gone"}
+    },
+    "alpha": 0.10
+}
+`
+	updated := decodeDoc(t, original)
+	servers := updated["mcpServers"].(map[string]interface{})
+	servers["change"].(map[string]interface{})["command"] = "new"
+	delete(servers, "remove")
+	servers["added"] = map[string]interface{}{"command": "fresh", "args": []interface{}{"--flag"}}
+
+	patched, err := Patch([]byte(original), updated)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+
+	expected := `{
+    "numStartups": 12345678901234567890,
+    "zebra": "first",
+    "mcpServers": {
+        "keep": {"command": "a",   "args": ["x"]},
+        "change": {
+            "command": "new",
+            "env": {"B": "2", "A": "1"}
+        },
+        "added": {
+            "args": [
+                "--flag"
+            ],
+            "command": "fresh"
+        }
+    },
+    "alpha": 0.10
+}
+`
+	if string(patched) != expected {
+		t.Errorf("Unexpected patch result:\n%s\nexpected:\n%s", patched, expected)
+	}
+}
+
+func TestPatch_ObjectOrder(t *testing.T) {
+	original := `{"servers": {"unmanaged": 0, "b": 2, "a": 1}}`
+	updated := decodeDoc(t, original)
+	updated["servers"] = Object{
+		Keys:   []string{"a", "b", "c"},
+		Values: map[string]interface{}{"unmanaged": 0, "a": 1, "b": 2, "c": 3},
+	}
+
+	patched, err := Patch([]byte(original), updated)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+
+	expected := `{"servers": {"a": 1, "b": 2, "c": 3, "unmanaged": 0}}`
+	if string(patched) != expected {
+		t.Errorf("Expected %s, got %s", expected, patched)
+	}
+}
+
+func TestPatch_NewMembersInEmptyObjects(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		expected string
+	}{
+		{
+			name:     "Indented document",
+			original: "{\n  \"mcpServers\": {}\n}\n",
+			expected: "{\n  \"mcpServers\": {\n    \"s\": {\n      \"command\": \"x\"\n    }\n  }\n}\n",
+		},
+		{
+			name:     "Compact document",
+			original: `{"mcpServers":{}}`,
+			expected: `{"mcpServers":{"s":{"command":"x"}}}`,
+		},
+		{
+			name:     "Missing section",
+			original: "{\n\t\"other\": true\n}",
+			expected: "{\n\t\"other\": true,\n\t\"mcpServers\": {\n\t\t\"s\": {\n\t\t\t\"command\": \"x\"\n\t\t}\n\t}\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := decodeDoc(t, tt.original)
+			updated["mcpServers"] = map[string]interface{}{
+				"s": map[string]interface{}{"command": "x"},
+			}
+
+			patched, err := Patch([]byte(tt.original), updated)
+			if err != nil {
+				t.Fatalf("Patch failed: %v", err)
+			}
+			if string(patched) != tt.expected {
+				t.Errorf("Expected\n%q\ngot\n%q", tt.expected, patched)
+			}
+		})
+	}
+}
+
+func TestPatch_RemoveLastMember(t *testing.T) {
+	original := "{\n  \"a\": 1,\n  \"b\": 2\n}\n"
+	updated := decodeDoc(t, original)
+	delete(updated, "b")
+
+	patched, err := Patch([]byte(original), updated)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	if expected := "{\n  \"a\": 1\n}\n"; string(patched) != expected {
+		t.Errorf("Expected %q, got %q", expected, patched)
+	}
+}
+
+func TestPatch_NewDocument(t *testing.T) {
+	patched, err := Patch(nil, map[string]interface{}{
+		"mcpServers": map[string]interface{}{"s": map[string]interface{}{"url": "https://example.com/?a=1&b=2"}},
+	})
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+
+	if !strings.HasSuffix(string(patched), "}\n") {
+		t.Errorf("Expected trailing newline, got %q", patched)
+	}
+	if !strings.Contains(string(patched), "a=1&b=2") {
+		t.Errorf("Expected '&' to be written unescaped, got %s", patched)
+	}
+	if !json.Valid(patched) {
+		t.Errorf("Expected valid JSON, got %s", patched)
+	}
+}
+
+func TestPatch_InvalidDocument(t *testing.T) {
+	invalid := []string{
+		`{"a": }`,
+		`{"a": 1`,
+		`[1, 2]`,
+		`{"a": 1} trailing`,
+		`{"a": "unterminated}`,
+		`{"a": nope}`,
+		`{"a": True}`,
+		`{"a": 01}`,
+		`{"a": 1.}`,
+		`{"a": --1}`,
+	}
+
+	for _, doc := range invalid {
+		if _, err := Patch([]byte(doc), map[string]interface{}{}); err == nil {
+			t.Errorf("Expected error for %q", doc)
+		}
+	}
+}
+
+func TestPatch_JSONC(t *testing.T) {
+	original := `{
+  // Editor settings
+  "editor.fontSize": 14,
+  "mcpServers": {
+    /* Managed by mcp-server-manager */
+    "old": {"command": "old"},
+    // Keep this one
+    "keep": {
+      "command": "keep", // inline note
+      "args": ["a",],
+    },
+  },
+}
+`
+	updated := decodeDoc(t, string(Standardize([]byte(original))))
+	servers := updated["mcpServers"].(map[string]interface{})
+	delete(servers, "old")
+	servers["new"] = map[string]interface{}{"command": "new"}
+	updated["mcpServers"] = Object{Keys: []string{"new", "keep"}, Values: servers}
+
+	patched, err := Patch([]byte(original), updated)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+
+	expected := `{
+  // Editor settings
+  "editor.fontSize": 14,
+  "mcpServers": {
+    "new": {
+      "command": "new"
+    },
+    // Keep this one
+    "keep": {
+      "command": "keep", // inline note
+      "args": ["a",],
+    },
+  },
+}
+`
+	if string(patched) != expected {
+		t.Errorf("Unexpected patch result:\n%s\nexpected:\n%s", patched, expected)
+	}
+}
+
+func TestStandardize(t *testing.T) {
+	input := "{\n  // comment with \"quotes\" and , commas\n  \"url\": \"http://example.com/*not-a-comment*/\",\n  \"list\": [1, 2,],\n  /* block\n  comment */ \"a\": {\"b\": 1,},\n}"
+
+	output := Standardize([]byte(input))
+	if len(output) != len(input) || strings.Count(string(output), "\n") != strings.Count(input, "\n") {
+		t.Error("Standardize must keep offsets and line numbers")
+	}
+
+	var value map[string]interface{}
+	if err := json.Unmarshal(output, &value); err != nil {
+		t.Fatalf("Expected valid JSON, got error %v:\n%s", err, output)
+	}
+	if value["url"] != "http://example.com/*not-a-comment*/" {
+		t.Errorf("String contents changed: %v", value["url"])
+	}
+
+	if !IsJSONC([]byte(input)) {
+		t.Error("Expected input to be detected as JSONC")
+	}
+	if IsJSONC([]byte(`{"a": "// not a comment", "b": [1, 2]}`)) {
+		t.Error("Expected plain JSON not to be detected as JSONC")
+	}
+}
@@ -0,0 +1,304 @@
+package jsonedit
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// numberPattern matches a JSON number
+var numberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// member is one "key": value pair of a parsed object, kept as raw byte ranges
+// so it can be written back exactly as it was read. "Whitespace" includes
+// comments, which makes JSONC documents round-trip unchanged.
+type member struct {
+	key   string // Decoded key
+	lead  []byte // Whitespace before the key
+	raw   []byte // Key as written, including quotes
+	colon []byte // Everything between the key and the value (":" and whitespace)
+	value []byte // Value as written
+	trail []byte // Whitespace between the value and the following comma
+}
+
+// object is a parsed JSON object
+type object struct {
+	members       []member
+	closing       []byte // Whitespace before the closing brace
+	trailingComma bool   // The last member is followed by a comma (JSONC)
+}
+
+// scanner walks a JSON or JSONC document without decoding it
+type scanner struct {
+	data []byte
+	pos  int
+}
+
+func (s *scanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("offset %d: %s", s.pos, fmt.Sprintf(format, args...))
+}
+
+// skipSpace advances past insignificant whitespace and comments and returns them
+func (s *scanner) skipSpace() []byte {
+	start := s.pos
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\n', '\r':
+			s.pos++
+		case '/':
+			if !s.skipComment() {
+				return s.data[start:s.pos]
+			}
+		default:
+			return s.data[start:s.pos]
+		}
+	}
+	return s.data[start:s.pos]
+}
+
+// skipComment advances past a // or /* */ comment at the current position
+func (s *scanner) skipComment() bool {
+	if s.pos+1 >= len(s.data) {
+		return false
+	}
+
+	switch s.data[s.pos+1] {
+	case '/':
+		end := bytes.IndexByte(s.data[s.pos:], '\n')
+		if end < 0 {
+			s.pos = len(s.data)
+		} else {
+			s.pos += end
+		}
+		return true
+	case '*':
+		end := bytes.Index(s.data[s.pos+2:], []byte("*/"))
+		if end < 0 {
+			s.pos = len(s.data)
+		} else {
+			s.pos += end + 4
+		}
+		return true
+	}
+	return false
+}
+
+// scanValue advances past one value of any kind
+func (s *scanner) scanValue() error {
+	if s.pos >= len(s.data) {
+		return s.errorf("unexpected end of input")
+	}
+
+	switch c := s.data[s.pos]; {
+	case c == '{':
+		_, err := s.scanObject()
+		return err
+	case c == '[':
+		return s.scanArray()
+	case c == '"':
+		return s.scanString()
+	default:
+		return s.scanLiteral()
+	}
+}
+
+// scanObject parses an object into its members, starting at the opening brace
+func (s *scanner) scanObject() (*object, error) {
+	if s.pos >= len(s.data) || s.data[s.pos] != '{' {
+		return nil, s.errorf("expected '{'")
+	}
+	s.pos++
+
+	obj := &object{}
+	lead := s.skipSpace()
+	if s.pos < len(s.data) && s.data[s.pos] == '}' {
+		obj.closing = lead
+		s.pos++
+		return obj, nil
+	}
+
+	for {
+		m := member{lead: lead}
+
+		keyStart := s.pos
+		if s.pos >= len(s.data) || s.data[s.pos] != '"' {
+			return nil, s.errorf("expected object key")
+		}
+		if err := s.scanString(); err != nil {
+			return nil, err
+		}
+		m.raw = s.data[keyStart:s.pos]
+		key, err := decodeString(m.raw)
+		if err != nil {
+			return nil, s.errorf("invalid object key: %v", err)
+		}
+		m.key = key
+
+		colonStart := s.pos
+		s.skipSpace()
+		if s.pos >= len(s.data) || s.data[s.pos] != ':' {
+			return nil, s.errorf("expected ':' after object key")
+		}
+		s.pos++
+		s.skipSpace()
+		m.colon = s.data[colonStart:s.pos]
+
+		valueStart := s.pos
+		if err := s.scanValue(); err != nil {
+			return nil, err
+		}
+		m.value = s.data[valueStart:s.pos]
+
+		trail := s.skipSpace()
+		if s.pos >= len(s.data) {
+			return nil, s.errorf("unterminated object")
+		}
+
+		switch s.data[s.pos] {
+		case ',':
+			m.trail = trail
+			obj.members = append(obj.members, m)
+			s.pos++
+			lead = s.skipSpace()
+			if s.pos < len(s.data) && s.data[s.pos] == '}' {
+				obj.closing = lead
+				obj.trailingComma = true
+				s.pos++
+				return obj, nil
+			}
+		case '}':
+			obj.closing = trail
+			obj.members = append(obj.members, m)
+			s.pos++
+			return obj, nil
+		default:
+			return nil, s.errorf("expected ',' or '}' in object")
+		}
+	}
+}
+
+func (s *scanner) scanArray() error {
+	s.pos++ // opening bracket
+	s.skipSpace()
+	if s.pos < len(s.data) && s.data[s.pos] == ']' {
+		s.pos++
+		return nil
+	}
+
+	for {
+		if err := s.scanValue(); err != nil {
+			return err
+		}
+		s.skipSpace()
+		if s.pos >= len(s.data) {
+			return s.errorf("unterminated array")
+		}
+
+		switch s.data[s.pos] {
+		case ',':
+			s.pos++
+			s.skipSpace()
+			if s.pos < len(s.data) && s.data[s.pos] == ']' {
+				s.pos++
+				return nil
+			}
+		case ']':
+			s.pos++
+			return nil
+		default:
+			return s.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (s *scanner) scanString() error {
+	s.pos++ // opening quote
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case '\\':
+			s.pos += 2
+		case '"':
+			s.pos++
+			return nil
+		default:
+			s.pos++
+		}
+	}
+	return s.errorf("unterminated string")
+}
+
+// scanLiteral advances past a number, true, false or null
+func (s *scanner) scanLiteral() error {
+	start := s.pos
+	for s.pos < len(s.data) {
+		switch c := s.data[s.pos]; {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '+', c == '.', c == 'E':
+			s.pos++
+			continue
+		}
+		break
+	}
+	if s.pos == start {
+		return s.errorf("unexpected character %q", s.data[s.pos])
+	}
+
+	switch literal := s.data[start:s.pos]; string(literal) {
+	case "true", "false", "null":
+		return nil
+	default:
+		if !numberPattern.Match(literal) {
+			s.pos = start
+			return s.errorf("invalid literal %q", literal)
+		}
+	}
+	return nil
+}
+
+// Standardize turns a JSONC document into plain JSON by blanking out comments and
+// trailing commas. Byte offsets and line numbers are unchanged, so errors reported
+// for the result point at the right place in the original.
+func Standardize(data []byte) []byte {
+	out := make([]byte, len(data))
+	copy(out, data)
+
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if out[i] != '\n' && out[i] != '\r' {
+				out[i] = ' '
+			}
+		}
+	}
+
+	s := &scanner{data: data}
+	for s.pos < len(data) {
+		switch data[s.pos] {
+		case '"':
+			if err := s.scanString(); err != nil {
+				return out
+			}
+		case '/':
+			start := s.pos
+			if !s.skipComment() {
+				s.pos++
+				continue
+			}
+			blank(start, s.pos)
+		case ',':
+			comma := s.pos
+			s.pos++
+			next := &scanner{data: data, pos: s.pos}
+			next.skipSpace()
+			if next.pos < len(data) && (data[next.pos] == '}' || data[next.pos] == ']') {
+				blank(comma, comma+1)
+			}
+		default:
+			s.pos++
+		}
+	}
+	return out
+}
+
+// IsJSONC reports whether a document uses comments or trailing commas
+func IsJSONC(data []byte) bool {
+	return !bytes.Equal(Standardize(data), data)
+}
@@ -0,0 +1,68 @@
+// Package logging sets up the process-wide structured logger.
+//
+// Everything logs through log/slog, so one setting controls the level and format
+// of the HTTP request log, the service layer and messages from the standard log
+// package alike. Under systemd the text format reads well in journalctl; the
+// JSON format suits log shippers.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// Output formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ParseLevel accepts debug, info, warn or error (any case); empty means info
+func ParseLevel(level string) (slog.Level, error) {
+	if level == "" {
+		return slog.LevelInfo, nil
+	}
+
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("invalid log level '%s': must be debug, info, warn or error", level)
+	}
+	return parsed, nil
+}
+
+// NewLogger creates a logger writing to w with the configured level and format
+func NewLogger(cfg *models.LoggingConfig, w io.Writer) (*slog.Logger, error) {
+	var level, format string
+	if cfg != nil {
+		level, format = cfg.Level, cfg.Format
+	}
+
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	options := &slog.HandlerOptions{Level: parsed}
+
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format '%s': must be %s or %s", format, FormatText, FormatJSON)
+	}
+}
+
+// Setup makes a logger for the settings the default for slog and the log package
+func Setup(cfg *models.LoggingConfig, w io.Writer) (*slog.Logger, error) {
+	logger, err := NewLogger(cfg, w)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(logger)
+	return logger, nil
+}
@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"", slog.LevelInfo, false},
+		{"debug", slog.LevelDebug, false},
+		{"WARN", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	t.Run("JSON at warn level", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := NewLogger(&models.LoggingConfig{Level: "warn", Format: "json"}, &buf)
+		if err != nil {
+			t.Fatalf("NewLogger failed: %v", err)
+		}
+
+		logger.Info("hidden")
+		logger.Warn("Wrote client config", "path", "/tmp/client.json")
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 1 {
+			t.Fatalf("Expected only the warning to be logged, got %q", buf.String())
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+			t.Fatalf("Expected a JSON record: %v", err)
+		}
+		if record["msg"] != "Wrote client config" || record["path"] != "/tmp/client.json" {
+			t.Errorf("Unexpected record: %v", record)
+		}
+	})
+
+	t.Run("Text by default", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := NewLogger(nil, &buf)
+		if err != nil {
+			t.Fatalf("NewLogger failed: %v", err)
+		}
+
+		logger.Debug("hidden")
+		logger.Info("Started", "port", 6543)
+		if got := buf.String(); !strings.Contains(got, "msg=Started port=6543") || strings.Contains(got, "hidden") {
+			t.Errorf("Unexpected text output: %q", got)
+		}
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		if _, err := NewLogger(&models.LoggingConfig{Format: "xml"}, &bytes.Buffer{}); err == nil {
+			t.Error("Expected an error for an unknown format")
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.NewCounterVec("requests_total", "Requests.\nBy route.", "route")
+	up := registry.NewGaugeVec("up", "Up.", "server")
+	latency := registry.NewHistogramVec("latency_seconds", "Latency.", []float64{1, 0.1}, "route")
+	registry.NewCounterVec("unused_total", "Never incremented.")
+
+	requests.Inc("/b")
+	requests.Add(2, "/a")
+	requests.Add(-1, "/a") // Counters never go down
+	up.Set(1, `quote"and\backslash`)
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.5, "/a")
+	latency.Observe(5, "/a")
+
+	var out strings.Builder
+	if err := registry.WriteText(&out); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+
+	want := `# HELP requests_total Requests.\nBy route.
+# TYPE requests_total counter
+requests_total{route="/a"} 2
+requests_total{route="/b"} 1
+# HELP up Up.
+# TYPE up gauge
+up{server="quote\"and\\backslash"} 1
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 1
+latency_seconds_bucket{route="/a",le="1"} 2
+latency_seconds_bucket{route="/a",le="+Inf"} 3
+latency_seconds_sum{route="/a"} 5.55
+latency_seconds_count{route="/a"} 3
+`
+	if out.String() != want {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -1,22 +1,299 @@
 package models
 
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
 // Client represents an MCP client configuration
 type Client struct {
 	ConfigPath string   `yaml:"config_path" json:"config_path"`
+	Format     string   `yaml:"format,omitempty" json:"format,omitempty"`   // json, jsonc or empty to accept both
 	Enabled    []string `yaml:"enabled,omitempty" json:"enabled,omitempty"` // List of enabled server names
 }
 
+// Client config file formats
+const (
+	ClientFormatJSON  = "json"  // Strict JSON; comments and trailing commas are rejected
+	ClientFormatJSONC = "jsonc" // JSON with comments and trailing commas, kept when writing
+)
+
+// Project is a repository directory whose project-scoped client files
+// (.mcp.json, .cursor/mcp.json, .vscode/mcp.json) are managed
+type Project struct {
+	Path    string   `yaml:"path" json:"path"`
+	Clients []string `yaml:"clients" json:"clients"`                     // Project file kinds: claude_code, cursor, vscode
+	Enabled []string `yaml:"enabled,omitempty" json:"enabled,omitempty"` // List of enabled server names
+}
+
+// ServerTagsKey is the reserved server entry key holding tags. Tags are split
+// out of the passthrough config on load so they never reach client files.
+const ServerTagsKey = "tags"
+
+// ServerTemplateKey is the reserved server entry key recording the template a
+// server was rendered from. Like tags, it never reaches client files.
+const ServerTemplateKey = "template"
+
+// ServerOAuthKey is the reserved server entry key turning on OAuth sign-in for a
+// remote server. Clients get the resulting Authorization header instead.
+const ServerOAuthKey = "oauth"
+
 // MCPServer represents a single MCP server with its name and configuration
 type MCPServer struct {
-	Name   string                 `yaml:"name" json:"name"`
-	Config map[string]interface{} `yaml:"config,inline" json:"config,inline"`
+	Name     string                 `yaml:"name" json:"name"`
+	Tags     []string               `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Template *TemplateRef           `yaml:"template,omitempty" json:"template,omitempty"` // Set for servers created from a template
+	OAuth    *OAuthConfig           `yaml:"oauth,omitempty" json:"oauth,omitempty"`       // Set for servers signed in with OAuth
+	Config   map[string]interface{} `yaml:"config,inline" json:"config,inline"`
+}
+
+// OAuthConfig signs in to a remote server with OAuth 2.1 (authorization code
+// with PKCE). Every field is optional: the authorization server is discovered
+// from the server URL and the manager registers itself as a client unless a
+// client ID is given.
+type OAuthConfig struct {
+	Scopes              []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+	ClientID            string   `yaml:"client_id,omitempty" json:"client_id,omitempty"`                       // Pre-registered client; skips dynamic registration
+	ClientSecret        string   `yaml:"client_secret,omitempty" json:"-"`                                     // Only for confidential pre-registered clients
+	AuthorizationServer string   `yaml:"authorization_server,omitempty" json:"authorization_server,omitempty"` // Issuer URL when the server does not advertise one
+}
+
+// TemplateRef names the template a server was rendered from and the parameter
+// values used, so the server can be rendered again when the template changes
+type TemplateRef struct {
+	Name   string            `yaml:"name" json:"name"`
+	Params map[string]string `yaml:"params,omitempty" json:"params,omitempty"`
+}
+
+// HasTag reports whether the server carries the given tag
+func (s MCPServer) HasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// WatchConfig controls the optional watcher on client config files
+type WatchConfig struct {
+	Enabled    bool   `yaml:"enabled" json:"enabled"`
+	Mode       string `yaml:"mode,omitempty" json:"mode,omitempty"`               // auto_heal or notify (default)
+	IntervalMs int    `yaml:"interval_ms,omitempty" json:"interval_ms,omitempty"` // Polling interval
+	DebounceMs int    `yaml:"debounce_ms,omitempty" json:"debounce_ms,omitempty"` // Quiet period before reacting to a change
+}
+
+// AuthConfig holds the credentials accepted by the web UI and REST API.
+// API requests authenticate with "Authorization: Bearer <token>"; the web UI
+// logs in with the password (or a token) and then uses a session cookie.
+type AuthConfig struct {
+	Tokens          []string `yaml:"tokens,omitempty" json:"-"`                                      // Bearer tokens for /api
+	Password        string   `yaml:"password,omitempty" json:"-"`                                    // Plain-text login password
+	PasswordHash    string   `yaml:"password_hash,omitempty" json:"-"`                               // bcrypt hash, preferred over password
+	SessionTTLHours int      `yaml:"session_ttl_hours,omitempty" json:"session_ttl_hours,omitempty"` // Session lifetime (default 24)
+}
+
+// Enabled reports whether any credential is configured
+func (a *AuthConfig) Enabled() bool {
+	return a != nil && (len(a.Tokens) > 0 || a.Password != "" || a.PasswordHash != "")
+}
+
+// TLSConfig enables HTTPS. Without cert and key files a self-signed certificate
+// is created next to config.yaml and reused on later starts.
+type TLSConfig struct {
+	Enabled  bool   `yaml:"enabled" json:"enabled"`
+	CertFile string `yaml:"cert_file,omitempty" json:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty" json:"key_file,omitempty"`
+}
+
+// UnixSocketConfig makes the manager listen on a Unix domain socket instead of a TCP port
+type UnixSocketConfig struct {
+	Path string `yaml:"path" json:"path"`
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"` // Octal file mode (default 0600)
+}
+
+// DefaultSocketMode only lets the owner connect to the socket
+const DefaultSocketMode os.FileMode = 0600
+
+// ParseSocketMode parses an octal file mode such as "0660", defaulting to 0600
+func ParseSocketMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return DefaultSocketMode, nil
+	}
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || value > 0777 {
+		return 0, fmt.Errorf("invalid unix socket mode '%s': must be octal like 0600", mode)
+	}
+	return os.FileMode(value), nil
+}
+
+// PolicyConfig restricts the stdio commands that can be added through the web UI
+// or REST API. Servers written into config.yaml by hand are trusted as they are.
+type PolicyConfig struct {
+	AllowedCommands []string `yaml:"allowed_commands,omitempty" json:"allowed_commands,omitempty"` // Commands and package runners (npx, uvx, docker) accepted as written
+	DeniedArgs      []string `yaml:"denied_args,omitempty" json:"denied_args,omitempty"`           // Regular expressions rejected in any argument
 }
 
+// Severities of config problems. Only errors prevent saving the config; warnings
+// and infos are shown but do not block any operation.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// ValidationConfig sets the severity of problems that depend on the machine, so a
+// config shared between machines keeps working where a server is not installed
+type ValidationConfig struct {
+	MissingCommands string `yaml:"missing_commands,omitempty" json:"missing_commands,omitempty"` // error, warning (default) or info
+	DeepChecks      bool   `yaml:"deep_checks,omitempty" json:"deep_checks,omitempty"`           // Check npx/uvx packages, docker images and command paths
+}
+
+// GitConfig commits config.yaml to a git repository after every save. The
+// directory holding config.yaml is initialized as a repository unless it is
+// already inside one; no remote is needed.
+type GitConfig struct {
+	Enabled     bool   `yaml:"enabled" json:"enabled"`
+	AuthorName  string `yaml:"author_name,omitempty" json:"author_name,omitempty"`   // Used when git has no user.name
+	AuthorEmail string `yaml:"author_email,omitempty" json:"author_email,omitempty"` // Used when git has no user.email
+}
+
+// HealthConfig enables periodic health checks of every server. A stdio server is
+// healthy when its command can be found; an HTTP server when its URL answers
+// without a server error.
+type HealthConfig struct {
+	Enabled         bool `yaml:"enabled" json:"enabled"`
+	IntervalSeconds int  `yaml:"interval_seconds,omitempty" json:"interval_seconds,omitempty"` // Time between checks (default 60)
+	TimeoutSeconds  int  `yaml:"timeout_seconds,omitempty" json:"timeout_seconds,omitempty"`   // Limit per HTTP check (default 5)
+}
+
+// NotificationsConfig sends manager events to webhooks and the local desktop.
+// Health changes are only sent when a server starts failing or recovers.
+type NotificationsConfig struct {
+	Webhooks []WebhookConfig      `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+	Desktop  *DesktopNotifyConfig `yaml:"desktop,omitempty" json:"desktop,omitempty"`
+}
+
+// WebhookConfig is an HTTP endpoint receiving events as JSON POST requests
+type WebhookConfig struct {
+	URL        string   `yaml:"url" json:"url"`
+	Events     []string `yaml:"events,omitempty" json:"events,omitempty"`           // Event types to send (default all)
+	Secret     string   `yaml:"secret,omitempty" json:"-"`                          // Signs the body with HMAC-SHA256
+	MaxRetries *int     `yaml:"max_retries,omitempty" json:"max_retries,omitempty"` // Retries after a failed delivery (default 3)
+}
+
+// DesktopNotifyConfig shows events as desktop notifications through notify-send
+type DesktopNotifyConfig struct {
+	Enabled bool     `yaml:"enabled" json:"enabled"`
+	Events  []string `yaml:"events,omitempty" json:"events,omitempty"` // Event types to show (default health_changed, drift_detected)
+}
+
+// LoggingConfig sets the level and format of the structured log on stderr
+type LoggingConfig struct {
+	Level  string `yaml:"level,omitempty" json:"level,omitempty"`   // debug, info (default), warn or error
+	Format string `yaml:"format,omitempty" json:"format,omitempty"` // text (default) or json
+}
+
+// CatalogConfig locates the catalog of known servers offered for installation
+type CatalogConfig struct {
+	Path        string `yaml:"path,omitempty" json:"path,omitempty"`                 // YAML or JSON file (default catalog.yaml next to config.yaml)
+	RegistryURL string `yaml:"registry_url,omitempty" json:"registry_url,omitempty"` // Registry-format index (URL or file) merged in by a sync
+}
+
+// CatalogEntry describes a known MCP server. Values asked for on installation
+// are declared as inputs and referenced as ${NAME} in args, url, headers and env.
+type CatalogEntry struct {
+	Name        string            `yaml:"name" json:"name"`
+	Description string            `yaml:"description,omitempty" json:"description,omitempty"`
+	Transport   string            `yaml:"transport" json:"transport"` // stdio, http or sse
+	Command     string            `yaml:"command,omitempty" json:"command,omitempty"`
+	Args        []string          `yaml:"args,omitempty" json:"args,omitempty"`
+	URL         string            `yaml:"url,omitempty" json:"url,omitempty"`
+	Headers     map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Env         map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	Inputs      []CatalogInput    `yaml:"inputs,omitempty" json:"inputs,omitempty"`
+	Docs        string            `yaml:"docs,omitempty" json:"docs,omitempty"`
+	Tags        []string          `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Source      string            `yaml:"source,omitempty" json:"source,omitempty"` // "registry" for entries added by a sync
+}
+
+// CatalogInput is a value the user provides when installing a catalog entry,
+// typically an API key or a directory
+type CatalogInput struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty" json:"required,omitempty"`
+	Secret      bool   `yaml:"secret,omitempty" json:"secret,omitempty"` // Masked in the install form
+	Default     string `yaml:"default,omitempty" json:"default,omitempty"`
+}
+
+// ServerName suggests a server name: the last segment of the entry name, so
+// "io.github.example/weather" becomes "weather"
+func (e CatalogEntry) ServerName() string {
+	return e.Name[strings.LastIndex(e.Name, "/")+1:]
+}
+
+// Template parameter types
+const (
+	ParamTypeString = "string"
+	ParamTypeInt    = "int"
+	ParamTypeBool   = "bool"
+	ParamTypePath   = "path" // A string with a leading ~ expanded to the home directory
+	ParamTypeEnum   = "enum" // One of Values
+)
+
+// ServerTemplate is a reusable server config. String values may use Go template
+// syntax, e.g. "{{.repo}}", to insert parameter values.
+type ServerTemplate struct {
+	Description string                 `yaml:"description,omitempty" json:"description,omitempty"`
+	Params      []TemplateParam        `yaml:"params,omitempty" json:"params,omitempty"`
+	Config      map[string]interface{} `yaml:"config" json:"config"` // Server config with placeholders
+}
+
+// TemplateParam declares a template parameter
+type TemplateParam struct {
+	Name        string   `yaml:"name" json:"name"`
+	Type        string   `yaml:"type,omitempty" json:"type,omitempty"` // string (default), int, bool, path or enum
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool     `yaml:"required,omitempty" json:"required,omitempty"`
+	Default     string   `yaml:"default,omitempty" json:"default,omitempty"`
+	Values      []string `yaml:"values,omitempty" json:"values,omitempty"` // Allowed values of an enum
+}
+
+// Profile maps client names to the servers enabled for them while the profile is active
+type Profile map[string][]string
+
 // Config is the main application configuration
 type Config struct {
 	MCPServers []MCPServer        `yaml:"mcpServers" json:"mcpServers"` // Ordered list of MCP servers
 	Clients    map[string]*Client `yaml:"clients" json:"clients"`       // Client name -> client config
 	ServerPort int                `yaml:"server_port" json:"server_port"`
+	Watch      *WatchConfig       `yaml:"watch,omitempty" json:"watch,omitempty"`
+
+	BindAddress string      `yaml:"bind_address,omitempty" json:"bind_address,omitempty"` // Interface to listen on (default 127.0.0.1)
+	PublicURL   string      `yaml:"public_url,omitempty" json:"public_url,omitempty"`     // URL browsers reach the manager at, e.g. behind a proxy
+	Auth        *AuthConfig `yaml:"auth,omitempty" json:"auth,omitempty"`                 // Credentials; required when not bound to loopback
+
+	TLS        *TLSConfig        `yaml:"tls,omitempty" json:"tls,omitempty"`
+	UnixSocket *UnixSocketConfig `yaml:"unix_socket,omitempty" json:"unix_socket,omitempty"` // Replaces the TCP listener when set
+
+	Policy *PolicyConfig `yaml:"policy,omitempty" json:"policy,omitempty"` // Command policy for added servers
+	Git    *GitConfig    `yaml:"git,omitempty" json:"git,omitempty"`       // Version config.yaml in git
+	Health *HealthConfig `yaml:"health,omitempty" json:"health,omitempty"` // Periodic server health checks
+
+	Notifications *NotificationsConfig `yaml:"notifications,omitempty" json:"notifications,omitempty"` // Webhooks and desktop notifications
+	Logging       *LoggingConfig       `yaml:"logging,omitempty" json:"logging,omitempty"`
+	Catalog       *CatalogConfig       `yaml:"catalog,omitempty" json:"catalog,omitempty"` // Catalog of installable servers
+	Validation    *ValidationConfig    `yaml:"validation,omitempty" json:"validation,omitempty"`
+
+	Templates map[string]*ServerTemplate `yaml:"templates,omitempty" json:"templates,omitempty"` // Template name -> parameterized server config
+	Vars      map[string]string          `yaml:"vars,omitempty" json:"vars,omitempty"`           // ${NAME} values expanded when writing client files
+
+	Projects      map[string]*Project `yaml:"projects,omitempty" json:"projects,omitempty"`             // Project name -> project-scoped config
+	Profiles      map[string]Profile  `yaml:"profiles,omitempty" json:"profiles,omitempty"`             // Profile name -> enabled servers per client
+	ActiveProfile string              `yaml:"active_profile,omitempty" json:"active_profile,omitempty"` // Last activated profile
 }
 
 type ClientConfig struct {
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"os"
 	"testing"
 )
 
@@ -255,4 +256,29 @@ func TestFieldPreservation(t *testing.T) {
 	if server.Config["nestedCustom"] == nil {
 		t.Errorf("nestedCustom not preserved")
 	}
+}
+
+func TestParseSocketMode(t *testing.T) {
+	tests := []struct {
+		mode    string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{mode: "", want: DefaultSocketMode},
+		{mode: "0660", want: 0660},
+		{mode: "600", want: 0600},
+		{mode: "0999", wantErr: true},
+		{mode: "rw", wantErr: true},
+		{mode: "01777", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSocketMode(tt.mode)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSocketMode(%q) error = %v, wantErr %v", tt.mode, err, tt.wantErr)
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseSocketMode(%q) = %o, want %o", tt.mode, got, tt.want)
+		}
+	}
 }
\ No newline at end of file
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package server
+
+import (
+	"net"
+	"os"
+)
+
+// listenWithMode creates a Unix socket; without a umask its mode is set
+// afterwards by the caller
+func listenWithMode(path string, mode os.FileMode) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package server
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// listenWithMode creates a Unix socket with the given permissions by narrowing
+// the umask while it is bound. The umask is process-wide; a file another
+// goroutine creates in that moment only ends up stricter than intended.
+func listenWithMode(path string, mode os.FileMode) (net.Listener, error) {
+	previous := syscall.Umask(int(^mode & 0777))
+	defer syscall.Umask(previous)
+
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,130 @@
+// Package server runs the HTTP handler on the listener selected in config.yaml:
+// a TCP address (optionally with TLS) or a Unix domain socket.
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// Run serves handler until the listener fails. configPath locates the directory
+// used for the generated self-signed certificate.
+func Run(handler http.Handler, cfg *models.Config, configPath string) error {
+	listener, err := Listen(cfg)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn), // e.g. TLS handshake errors
+	}
+
+	if cfg.TLS != nil && cfg.TLS.Enabled {
+		tlsConfig, err := LoadTLSConfig(cfg.TLS, filepath.Dir(configPath), certificateHosts(cfg))
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = tlsConfig
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	slog.Info("Starting MCP Manager server", "address", Describe(cfg))
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server stopped: %w", err)
+	}
+	return nil
+}
+
+// Listen opens the Unix socket or TCP listener configured in cfg
+func Listen(cfg *models.Config) (net.Listener, error) {
+	if cfg.UnixSocket != nil {
+		return listenUnix(cfg.UnixSocket)
+	}
+
+	address := net.JoinHostPort(cfg.BindAddress, strconv.Itoa(cfg.ServerPort))
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on '%s': %w", address, err)
+	}
+	return listener, nil
+}
+
+// Describe returns the URL the server can be reached at, for logging
+func Describe(cfg *models.Config) string {
+	scheme := "http"
+	if cfg.TLS != nil && cfg.TLS.Enabled {
+		scheme = "https"
+	}
+	if cfg.UnixSocket != nil {
+		return fmt.Sprintf("%s+unix://%s", scheme, config.ExpandPath(cfg.UnixSocket.Path))
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(cfg.BindAddress, strconv.Itoa(cfg.ServerPort)))
+}
+
+// listenUnix creates the socket, replacing a stale one left by a previous run,
+// and restricts access through its file mode
+func listenUnix(socketCfg *models.UnixSocketConfig) (net.Listener, error) {
+	path := config.ExpandPath(socketCfg.Path)
+
+	mode, err := models.ParseSocketMode(socketCfg.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("unix socket path '%s' exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("unix socket '%s' is in use by another process", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale unix socket '%s': %w", path, err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	// The socket is created with mode already, so it is never reachable with
+	// looser permissions; the chmod covers platforms without a umask
+	listener, err := listenWithMode(path, mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on unix socket '%s': %w", path, err)
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set unix socket mode: %w", err)
+	}
+
+	return listener, nil
+}
+
+// certificateHosts lists the names a generated certificate should be valid for
+func certificateHosts(cfg *models.Config) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if hostname, err := os.Hostname(); err == nil {
+		hosts = append(hosts, hostname)
+	}
+	if cfg.BindAddress != "" && net.ParseIP(cfg.BindAddress) != nil && !net.ParseIP(cfg.BindAddress).IsUnspecified() {
+		hosts = append(hosts, cfg.BindAddress)
+	}
+	return hosts
+}
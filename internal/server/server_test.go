@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+func TestListen_UnixSocket(t *testing.T) {
+	// Keep the path short: socket paths are limited to ~100 bytes
+	dir, err := os.MkdirTemp("", "mcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "m.sock")
+
+	cfg := &models.Config{UnixSocket: &models.UnixSocketConfig{Path: path, Mode: "0660"}}
+
+	listener, err := Listen(cfg)
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Socket was not created: %v", err)
+	}
+	if info.Mode().Perm() != 0660 {
+		t.Errorf("Expected socket mode 0660, got %o", info.Mode().Perm())
+	}
+
+	go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(_ context.Context, _, _ string) (net.Conn, error) { return net.Dial("unix", path) },
+	}}
+	resp, err := client.Get("http://unix/")
+	if err != nil {
+		t.Fatalf("Request over unix socket failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Errorf("Expected 'ok', got %q", body)
+	}
+
+	if _, err := Listen(cfg); err == nil {
+		t.Error("Expected error when the socket is in use")
+	}
+
+	// A socket left behind by a crashed process is replaced
+	listener.Close()
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err = Listen(cfg)
+	if err != nil {
+		t.Fatalf("Expected stale socket to be replaced: %v", err)
+	}
+	listener.Close()
+
+	regularFile := filepath.Join(dir, "file")
+	if err := os.WriteFile(regularFile, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(&models.Config{UnixSocket: &models.UnixSocketConfig{Path: regularFile}}); err == nil {
+		t.Error("Expected error when the path is a regular file")
+	}
+}
+
+func TestLoadTLSConfig_SelfSigned(t *testing.T) {
+	configDir := t.TempDir()
+	hosts := []string{"localhost", "127.0.0.1"}
+
+	tlsConfig, err := LoadTLSConfig(&models.TLSConfig{Enabled: true}, configDir, hosts)
+	if err != nil {
+		t.Fatalf("LoadTLSConfig failed: %v", err)
+	}
+
+	leaf, err := x509.ParseCertificate(tlsConfig.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	if err := leaf.VerifyHostname("localhost"); err != nil {
+		t.Errorf("Certificate not valid for localhost: %v", err)
+	}
+	if err := leaf.VerifyHostname("127.0.0.1"); err != nil {
+		t.Errorf("Certificate not valid for 127.0.0.1: %v", err)
+	}
+
+	keyPath := filepath.Join(configDir, selfSignedDir, selfSignedKeyFile)
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		t.Fatalf("Key file not created: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected key mode 0600, got %o", info.Mode().Perm())
+	}
+
+	// The generated certificate is reused on the next start
+	again, err := LoadTLSConfig(&models.TLSConfig{Enabled: true}, configDir, hosts)
+	if err != nil {
+		t.Fatalf("LoadTLSConfig failed: %v", err)
+	}
+	if string(again.Certificates[0].Certificate[0]) != string(tlsConfig.Certificates[0].Certificate[0]) {
+		t.Error("Expected the self-signed certificate to be reused")
+	}
+
+	// Configured files take precedence
+	certFile := filepath.Join(configDir, selfSignedDir, selfSignedCertFile)
+	configured, err := LoadTLSConfig(&models.TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyPath}, t.TempDir(), hosts)
+	if err != nil {
+		t.Fatalf("LoadTLSConfig with configured files failed: %v", err)
+	}
+	if configured.MinVersion != tls.VersionTLS12 {
+		t.Error("Expected TLS 1.2 minimum")
+	}
+
+	if _, err := LoadTLSConfig(&models.TLSConfig{Enabled: true, CertFile: "missing.pem", KeyFile: "missing.key"}, configDir, hosts); err == nil {
+		t.Error("Expected error for missing certificate files")
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+const (
+	selfSignedDir      = "tls"
+	selfSignedCertFile = "cert.pem"
+	selfSignedKeyFile  = "key.pem"
+	selfSignedValidity = 365 * 24 * time.Hour
+
+	// Renew a generated certificate this long before it expires
+	selfSignedRenewal = 30 * 24 * time.Hour
+)
+
+// LoadTLSConfig loads the configured certificate, or a self-signed one stored in
+// configDir/tls that is created on first use and renewed when close to expiry
+func LoadTLSConfig(tlsCfg *models.TLSConfig, configDir string, hosts []string) (*tls.Config, error) {
+	certFile := config.ExpandPath(tlsCfg.CertFile)
+	keyFile := config.ExpandPath(tlsCfg.KeyFile)
+
+	if tlsCfg.CertFile == "" && tlsCfg.KeyFile == "" {
+		dir := filepath.Join(configDir, selfSignedDir)
+		certFile = filepath.Join(dir, selfSignedCertFile)
+		keyFile = filepath.Join(dir, selfSignedKeyFile)
+
+		if !certificateUsable(certFile, keyFile) {
+			if err := generateSelfSigned(certFile, keyFile, hosts); err != nil {
+				return nil, fmt.Errorf("failed to create self-signed certificate: %w", err)
+			}
+			slog.Info("Created self-signed TLS certificate", "path", certFile, "key", keyFile)
+		}
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate '%s': %w", certFile, err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// certificateUsable reports whether a generated certificate exists and is not about to expire
+func certificateUsable(certFile, keyFile string) bool {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return false
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return false
+	}
+	return time.Until(leaf.NotAfter) > selfSignedRenewal
+}
+
+// generateSelfSigned writes an ECDSA certificate and key valid for the given hosts
+func generateSelfSigned(certFile, keyFile string, hosts []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"MCP Server Manager"}, CommonName: "mcp-server-manager"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(certFile), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// AuditLogFile is created next to config.yaml
+const AuditLogFile = "audit.jsonl"
+
+// Sources of a change recorded in the audit log
+const (
+	SourceAPI   = "api"   // REST API
+	SourceHTMX  = "htmx"  // Web UI
+	SourceCLI   = "cli"   // The manager process itself, e.g. a command-line action
+	SourceWatch = "watch" // Client file watcher
+)
+
+// Actor identifies who made a change
+type Actor struct {
+	Source     string `json:"source"`
+	RemoteAddr string `json:"remote_addr,omitempty"`
+	RequestID  string `json:"request_id,omitempty"` // Matches the request log and X-Request-ID
+}
+
+// AuditChange is one value that differs between the configuration before and
+// after an action. Paths are dotted, e.g. "clients.cursor.enabled".
+type AuditChange struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AuditEntry is one line of the audit log
+type AuditEntry struct {
+	Time    time.Time     `json:"time"`
+	Actor   Actor         `json:"actor"`
+	Action  string        `json:"action"`
+	Target  string        `json:"target,omitempty"`
+	Changes []AuditChange `json:"changes,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// auditLog appends entries to a JSON Lines file. It keeps a snapshot of the
+// configuration as of the last entry so each entry can carry a diff.
+type auditLog struct {
+	path string
+	last map[string]interface{}
+	key  []byte // Random per process, so redacted fingerprints cannot be guessed
+}
+
+func newAuditLog(path string, cfg *models.Config) *auditLog {
+	a := &auditLog{path: path, key: make([]byte, 32)}
+	rand.Read(a.key)
+	a.last = a.redactSecrets(configSnapshot(cfg))
+	return a
+}
+
+// record compares cfg with the previous snapshot and appends an entry. Failed
+// actions are only recorded when they still changed the configuration.
+func (a *auditLog) record(actor Actor, action, target string, cfg *models.Config, actionErr error) error {
+	current := a.redactSecrets(configSnapshot(cfg))
+	changes := diffValues("", a.last, current)
+	a.last = current
+
+	if actionErr != nil && len(changes) == 0 {
+		return nil
+	}
+
+	entry := AuditEntry{
+		Time:    time.Now().UTC(),
+		Actor:   actor,
+		Action:  action,
+		Target:  target,
+		Changes: changes,
+	}
+	if actionErr != nil {
+		entry.Error = actionErr.Error()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(a.path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log '%s': %w", a.path, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log '%s': %w", a.path, err)
+	}
+	return nil
+}
+
+// read returns up to limit entries, newest first. A missing log has no entries.
+func (a *auditLog) read(limit int) ([]AuditEntry, error) {
+	file, err := os.Open(a.path)
+	if os.IsNotExist(err) {
+		return []AuditEntry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log '%s': %w", a.path, err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // Skip a line cut short by a crash rather than hide the rest
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log '%s': %w", a.path, err)
+	}
+
+	newest := make([]AuditEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0 && (limit <= 0 || len(newest) < limit); i-- {
+		newest = append(newest, entries[i])
+	}
+	return newest, nil
+}
+
+// redactSecrets replaces server env and header values and variables in a
+// snapshot, which often hold API keys and tokens, with a fingerprint keyed by
+// the log. So are the values of secret-looking flags in args, such as
+// --api-key=... or --token ..., and query parameter values of server URLs.
+// Changed values still show up as changes, but the log, which the API serves,
+// never contains them. Values referring to ${NAME} variables hold no secret and
+// are kept.
+func (a *auditLog) redactSecrets(snapshot map[string]interface{}) map[string]interface{} {
+	servers, _ := snapshot["mcpServers"].(map[string]interface{})
+	for _, entry := range servers {
+		server, _ := entry.(map[string]interface{})
+		for _, section := range []string{"env", "headers"} {
+			if values, ok := server[section].(map[string]interface{}); ok {
+				a.redactValues(values)
+			}
+		}
+		if args, ok := server["args"].([]interface{}); ok {
+			a.redactArgs(args)
+		}
+		for _, key := range []string{"url", "httpUrl"} {
+			if rawURL, ok := server[key].(string); ok {
+				server[key] = a.redactQuery(rawURL)
+			}
+		}
+	}
+	if vars, ok := snapshot["vars"].(map[string]interface{}); ok {
+		a.redactValues(vars)
+	}
+	return snapshot
+}
+
+func (a *auditLog) redactValues(values map[string]interface{}) {
+	for key, value := range values {
+		if str, ok := value.(string); ok && strings.Contains(str, "${") {
+			continue
+		}
+		values[key] = a.fingerprint(value)
+	}
+}
+
+// redactArgs redacts the value of every flag whose name looks like it takes a
+// secret, whether given as --flag=value or as the next argument
+func (a *auditLog) redactArgs(args []interface{}) {
+	for i, arg := range args {
+		str, ok := arg.(string)
+		if !ok || !strings.HasPrefix(str, "-") || !secretKeyPattern.MatchString(str) {
+			continue
+		}
+
+		if name, value, found := strings.Cut(str, "="); found {
+			if secretKeyPattern.MatchString(name) && !strings.Contains(value, "${") {
+				args[i] = name + "=" + a.fingerprint(value)
+			}
+			continue
+		}
+
+		if i+1 < len(args) {
+			if next, ok := args[i+1].(string); ok && !strings.HasPrefix(next, "-") && !strings.Contains(next, "${") {
+				args[i+1] = a.fingerprint(next)
+			}
+		}
+	}
+}
+
+// redactQuery redacts the query parameter values of a URL, where servers are
+// often given an API key
+func (a *auditLog) redactQuery(rawURL string) string {
+	base, query, found := strings.Cut(rawURL, "?")
+	if !found {
+		return rawURL
+	}
+
+	fragment := ""
+	if i := strings.Index(query, "#"); i >= 0 {
+		query, fragment = query[:i], query[i:]
+	}
+
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		if name, value, found := strings.Cut(param, "="); found && value != "" && !strings.Contains(value, "${") {
+			params[i] = name + "=" + a.fingerprint(value)
+		}
+	}
+	return base + "?" + strings.Join(params, "&") + fragment
+}
+
+// fingerprint returns the redacted form of a value
+func (a *auditLog) fingerprint(value interface{}) string {
+	mac := hmac.New(sha256.New, a.key)
+	fmt.Fprint(mac, value)
+	return fmt.Sprintf("[redacted %x]", mac.Sum(nil)[:4])
+}
+
+// configSnapshot turns the configuration into plain JSON values for diffing.
+// Servers are keyed by name so adding one does not shift the others, and their
+// order is kept separately. Login and client credentials are excluded by their
+// json tags; env, header and variable values are not, see redactSecrets.
+func configSnapshot(cfg *models.Config) map[string]interface{} {
+	servers := make(map[string]interface{}, len(cfg.MCPServers))
+	order := make([]interface{}, 0, len(cfg.MCPServers))
+	for _, srv := range cfg.MCPServers {
+		entry := make(map[string]interface{}, len(srv.Config)+1)
+		for key, value := range srv.Config {
+			entry[key] = value
+		}
+		if len(srv.Tags) > 0 {
+			entry[models.ServerTagsKey] = srv.Tags
+		}
+		if srv.Template != nil {
+			entry[models.ServerTemplateKey] = srv.Template
+		}
+		if srv.OAuth != nil {
+			entry[models.ServerOAuthKey] = srv.OAuth
+		}
+		servers[srv.Name] = entry
+		order = append(order, srv.Name)
+	}
+
+	snapshot := map[string]interface{}{}
+	if data, err := json.Marshal(cfg); err == nil {
+		json.Unmarshal(data, &snapshot)
+	}
+
+	if data, err := json.Marshal(servers); err == nil {
+		var decoded map[string]interface{}
+		json.Unmarshal(data, &decoded)
+		snapshot["mcpServers"] = decoded
+	}
+	snapshot["serverOrder"] = order
+	return snapshot
+}
+
+// diffValues lists the paths where two decoded JSON values differ. Objects are
+// compared key by key; anything else is compared as a whole.
+func diffValues(path string, before, after interface{}) []AuditChange {
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+
+	if !beforeIsMap || !afterIsMap {
+		if reflect.DeepEqual(before, after) {
+			return nil
+		}
+		return []AuditChange{{Path: path, Before: before, After: after}}
+	}
+
+	keys := make([]string, 0, len(beforeMap)+len(afterMap))
+	for key := range beforeMap {
+		keys = append(keys, key)
+	}
+	for key := range afterMap {
+		if _, exists := beforeMap[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []AuditChange
+	for _, key := range keys {
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+		changes = append(changes, diffValues(childPath, beforeMap[key], afterMap[key])...)
+	}
+	return changes
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+func TestAuditLog_RecordsChanges(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, testutil.TestConfigYAML)
+
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{
+			{Name: "server-a", Config: map[string]interface{}{"command": "echo"}},
+		},
+		Clients: map[string]*models.Client{
+			testutil.TestClientName: {ConfigPath: filepath.Join(tempDir, testutil.TestClientJSON)},
+		},
+	}
+
+	service := NewMCPManagerService(cfg, configPath)
+	apiActor := Actor{Source: SourceAPI, RemoteAddr: "192.0.2.10"}
+
+	if err := service.WithActor(apiActor).ToggleClientMCPServer(testutil.TestClientName, "server-a", true); err != nil {
+		t.Fatalf("ToggleClientMCPServer failed: %v", err)
+	}
+	if err := service.AddServer("server-b", map[string]interface{}{"command": "echo", "args": []interface{}{"b"}}); err != nil {
+		t.Fatalf(testutil.ErrAddServerFailedFmt, err)
+	}
+	if err := service.ToggleClientMCPServer("missing", "server-a", true); err == nil {
+		t.Fatal("Expected error for missing client")
+	}
+
+	entries, err := service.GetAuditLog(0)
+	if err != nil {
+		t.Fatalf("GetAuditLog failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries (failed actions without changes are skipped), got %d: %+v", len(entries), entries)
+	}
+
+	// Newest first
+	added, toggled := entries[0], entries[1]
+
+	if toggled.Action != "toggle" || toggled.Target != testutil.TestClientName+"/server-a" || toggled.Actor != apiActor {
+		t.Errorf("Unexpected toggle entry: %+v", toggled)
+	}
+	if len(toggled.Changes) != 1 || toggled.Changes[0].Path != "clients."+testutil.TestClientName+".enabled" {
+		t.Errorf("Expected one change to the enabled list, got %+v", toggled.Changes)
+	}
+
+	if added.Action != "add_server" || added.Actor.Source != SourceCLI {
+		t.Errorf("Unexpected add entry: %+v", added)
+	}
+	paths := make([]string, 0, len(added.Changes))
+	for _, change := range added.Changes {
+		paths = append(paths, change.Path)
+	}
+	if got := strings.Join(paths, ","); got != "mcpServers.server-b,serverOrder" {
+		t.Errorf("Expected the new server and order as changes, got %s", got)
+	}
+
+	limited, err := service.GetAuditLog(1)
+	if err != nil || len(limited) != 1 || limited[0].Action != "add_server" {
+		t.Errorf("Expected only the newest entry with limit 1, got %+v (%v)", limited, err)
+	}
+
+	info, err := os.Stat(filepath.Join(tempDir, AuditLogFile))
+	if err != nil {
+		t.Fatalf("Expected audit log next to config: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected audit log mode 0600, got %o", info.Mode().Perm())
+	}
+}
+
+func TestAuditLog_RedactsSecrets(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{},
+		Vars:       map[string]string{"TOKEN": "var-secret-1"},
+		Clients: map[string]*models.Client{
+			testutil.TestClientName: {ConfigPath: filepath.Join(tempDir, testutil.TestClientJSON)},
+		},
+	}
+	service := NewMCPManagerService(cfg, filepath.Join(tempDir, testutil.TestConfigYAML))
+
+	if err := service.AddServer("remote", map[string]interface{}{
+		"url":     "https://example.com/mcp?api_key=query-secret-6&region=${REGION}",
+		"headers": map[string]interface{}{"Authorization": "Bearer header-secret-2", "X-Token": "${TOKEN}"},
+	}); err != nil {
+		t.Fatalf(testutil.ErrAddServerFailedFmt, err)
+	}
+	if err := service.AddServer("local", map[string]interface{}{
+		"command": "echo",
+		"args":    []interface{}{"--api-key=arg-secret-4", "--token", "arg-secret-5", "--verbose", "public-arg"},
+		"env":     map[string]interface{}{"API_KEY": "env-secret-3"},
+	}); err != nil {
+		t.Fatalf(testutil.ErrAddServerFailedFmt, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, AuditLogFile))
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	for _, secret := range []string{"var-secret-1", "header-secret-2", "env-secret-3", "arg-secret-4", "arg-secret-5", "query-secret-6"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Audit log contains %q:\n%s", secret, data)
+		}
+	}
+	for _, kept := range []string{"${TOKEN}", "region=${REGION}", "--api-key=[redacted", "public-arg"} {
+		if !strings.Contains(string(data), kept) {
+			t.Errorf("Expected %q to be kept, got %s", kept, data)
+		}
+	}
+
+	entries, err := service.GetAuditLog(1)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("GetAuditLog failed: %v", err)
+	}
+	env := entries[0].Changes[0].After.(map[string]interface{})["env"].(map[string]interface{})
+	if value, _ := env["API_KEY"].(string); !strings.HasPrefix(value, "[redacted") {
+		t.Errorf("Expected a redacted env value, got %q", value)
+	}
+}
+
+func TestAuditLog_SyncWithoutChanges(t *testing.T) {
+	service, _, _ := setupTagTest(t)
+
+	if err := service.SyncAllClients(); err != nil {
+		t.Fatalf("SyncAllClients failed: %v", err)
+	}
+
+	entries, err := service.GetAuditLog(0)
+	if err != nil {
+		t.Fatalf("GetAuditLog failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != "sync" || len(entries[0].Changes) != 0 {
+		t.Errorf("Expected one sync entry without changes, got %+v", entries)
+	}
+}
+
+func TestDiffValues(t *testing.T) {
+	before := map[string]interface{}{
+		"server_port": 6543.0,
+		"clients":     map[string]interface{}{"a": map[string]interface{}{"enabled": []interface{}{"x"}}},
+		"removed":     "value",
+	}
+	after := map[string]interface{}{
+		"server_port": 6543.0,
+		"clients":     map[string]interface{}{"a": map[string]interface{}{"enabled": []interface{}{"x", "y"}}},
+		"added":       true,
+	}
+
+	changes := diffValues("", before, after)
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 changes, got %+v", changes)
+	}
+
+	want := []string{"added", "clients.a.enabled", "removed"}
+	for i, change := range changes {
+		if change.Path != want[i] {
+			t.Errorf("Change[%d]: expected path %s, got %s", i, want[i], change.Path)
+		}
+	}
+	if changes[2].After != nil || changes[2].Before != "value" {
+		t.Errorf("Expected removed value with no after, got %+v", changes[2])
+	}
+}
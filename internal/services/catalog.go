@@ -0,0 +1,359 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// CatalogFile is the catalog file name used next to config.yaml when no path is configured
+const CatalogFile = "catalog.yaml"
+
+// Catalog entry transports
+const (
+	CatalogTransportStdio = "stdio"
+	CatalogTransportHTTP  = "http"
+	CatalogTransportSSE   = "sse"
+)
+
+// CatalogSourceRegistry marks entries added by a registry sync; the next sync replaces them
+const CatalogSourceRegistry = "registry"
+
+const registryTimeout = 30 * time.Second
+
+// ErrRegistryNotConfigured is returned by a sync when catalog.registry_url is not set
+var ErrRegistryNotConfigured = errors.New("no registry_url configured for the catalog")
+
+//go:embed catalog_default.yaml
+var defaultCatalog []byte
+
+// catalogInputPattern matches the ${NAME} references to catalog inputs
+var catalogInputPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// catalogFile is the layout of a catalog file
+type catalogFile struct {
+	Servers []models.CatalogEntry `yaml:"servers" json:"servers"`
+}
+
+// CatalogSyncResult summarizes a registry sync
+type CatalogSyncResult struct {
+	Registry int `json:"registry"` // Entries taken from the registry
+	Skipped  int `json:"skipped"`  // Registry entries without a supported package or remote, or shadowed by a local entry
+	Total    int `json:"total"`    // Entries in the catalog after the sync
+}
+
+// CatalogService reads the catalog of known servers and merges registry indexes into it
+type CatalogService struct {
+	path        string
+	registryURL string
+	client      *http.Client
+
+	mu sync.Mutex // Serializes syncs writing the catalog file
+}
+
+// NewCatalogService creates a catalog for the configured file, resolved against
+// the directory of config.yaml
+func NewCatalogService(cfg *models.CatalogConfig, configPath string) *CatalogService {
+	configDir := filepath.Dir(config.DefaultConfigPath)
+	if configPath != "" {
+		configDir = filepath.Dir(configPath)
+	}
+
+	c := &CatalogService{
+		path:   filepath.Join(configDir, CatalogFile),
+		client: &http.Client{Timeout: registryTimeout},
+	}
+
+	if cfg != nil {
+		if cfg.Path != "" {
+			c.path = config.ExpandPath(cfg.Path)
+			if !filepath.IsAbs(c.path) {
+				c.path = filepath.Join(configDir, c.path)
+			}
+		}
+		c.registryURL = cfg.RegistryURL
+	}
+
+	return c
+}
+
+// RegistryConfigured reports whether the catalog can be synced from a registry
+func (c *CatalogService) RegistryConfigured() bool {
+	return c.registryURL != ""
+}
+
+// Entries returns every catalog entry in file order; the built-in catalog when
+// the catalog file does not exist
+func (c *CatalogService) Entries() ([]models.CatalogEntry, error) {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return parseCatalog(defaultCatalog)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog '%s': %w", c.path, err)
+	}
+
+	entries, err := parseCatalog(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse catalog '%s': %w", c.path, err)
+	}
+	return entries, nil
+}
+
+// parseCatalog decodes a YAML or JSON catalog; JSON is read as YAML
+func parseCatalog(data []byte) ([]models.CatalogEntry, error) {
+	var file catalogFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	return file.Servers, nil
+}
+
+// Search returns the entries whose name, description or tags contain every word
+// of the query, ignoring case. An empty query matches everything.
+func (c *CatalogService) Search(query string) ([]models.CatalogEntry, error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	words := strings.Fields(strings.ToLower(query))
+	matches := make([]models.CatalogEntry, 0, len(entries))
+	for _, entry := range entries {
+		text := strings.ToLower(entry.Name + " " + entry.Description + " " + strings.Join(entry.Tags, " "))
+		matched := true
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			matches = append(matches, entry)
+		}
+	}
+	return matches, nil
+}
+
+// Entry returns the catalog entry with the given name
+func (c *CatalogService) Entry(name string) (*models.CatalogEntry, error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		if entries[i].Name == name {
+			return &entries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("catalog entry '%s' not found", name)
+}
+
+// Sync fetches the registry index and merges it into the catalog file. Entries
+// from an earlier sync are replaced; local entries are kept and win over
+// registry entries of the same name.
+func (c *CatalogService) Sync() (*CatalogSyncResult, error) {
+	if !c.RegistryConfigured() {
+		return nil, ErrRegistryNotConfigured
+	}
+
+	registryEntries, skipped, err := c.fetchRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make([]models.CatalogEntry, 0, len(current)+len(registryEntries))
+	local := make(map[string]bool)
+	for _, entry := range current {
+		if entry.Source != CatalogSourceRegistry {
+			merged = append(merged, entry)
+			local[entry.Name] = true
+		}
+	}
+
+	result := &CatalogSyncResult{Skipped: skipped}
+	for _, entry := range registryEntries {
+		if local[entry.Name] {
+			result.Skipped++
+			continue
+		}
+		merged = append(merged, entry)
+		result.Registry++
+	}
+	result.Total = len(merged)
+
+	if err := c.write(merged); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// write saves the catalog as JSON or YAML, following the file extension
+func (c *CatalogService) write(entries []models.CatalogEntry) error {
+	file := catalogFile{Servers: entries}
+
+	var data []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(c.path), ".json") {
+		data, err = json.MarshalIndent(file, "", "  ")
+	} else {
+		data, err = yaml.Marshal(file)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode catalog: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create catalog directory '%s': %w", filepath.Dir(c.path), err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write catalog '%s': %w", c.path, err)
+	}
+	return nil
+}
+
+// BuildCatalogServer renders a catalog entry into a server config, replacing
+// ${NAME} references to the entry's inputs with the given values or the input
+// defaults. Other references (e.g. "${HOME}") are kept as written.
+func BuildCatalogServer(entry *models.CatalogEntry, values map[string]string) (map[string]interface{}, error) {
+	resolved := make(map[string]string, len(entry.Inputs))
+	for _, input := range entry.Inputs {
+		value := strings.TrimSpace(values[input.Name])
+		if value == "" {
+			value = input.Default
+		}
+		if value == "" && input.Required {
+			return nil, fmt.Errorf("a value for '%s' is required", input.Name)
+		}
+		resolved[input.Name] = value
+	}
+	for name := range values {
+		if _, declared := resolved[name]; !declared {
+			return nil, fmt.Errorf("catalog entry '%s' has no input '%s'", entry.Name, name)
+		}
+	}
+
+	expand := func(s string) string {
+		return catalogInputPattern.ReplaceAllStringFunc(s, func(ref string) string {
+			if value, declared := resolved[ref[2:len(ref)-1]]; declared {
+				return value
+			}
+			return ref
+		})
+	}
+
+	serverConfig := make(map[string]interface{})
+	switch entry.Transport {
+	case CatalogTransportStdio, "":
+		if entry.Command == "" {
+			return nil, fmt.Errorf("catalog entry '%s' has no command", entry.Name)
+		}
+		serverConfig["command"] = expand(entry.Command)
+		if len(entry.Args) > 0 {
+			args := make([]interface{}, 0, len(entry.Args))
+			for _, arg := range entry.Args {
+				args = append(args, expand(arg))
+			}
+			serverConfig["args"] = args
+		}
+	case CatalogTransportHTTP, CatalogTransportSSE:
+		if entry.URL == "" {
+			return nil, fmt.Errorf("catalog entry '%s' has no url", entry.Name)
+		}
+		key := "httpUrl"
+		if entry.Transport == CatalogTransportSSE {
+			key = "url"
+		}
+		serverConfig[key] = expand(entry.URL)
+	default:
+		return nil, fmt.Errorf("catalog entry '%s' has unknown transport '%s'", entry.Name, entry.Transport)
+	}
+
+	// Optional values left empty drop the header or variable that needs them
+	if headers := expandValues(entry.Headers, expand); len(headers) > 0 {
+		serverConfig["headers"] = headers
+	}
+	if env := expandValues(entry.Env, expand); len(env) > 0 {
+		serverConfig["env"] = env
+	}
+	if len(entry.Tags) > 0 {
+		tags := make([]interface{}, 0, len(entry.Tags))
+		for _, tag := range entry.Tags {
+			tags = append(tags, tag)
+		}
+		serverConfig[models.ServerTagsKey] = tags
+	}
+
+	return serverConfig, nil
+}
+
+// expandValues expands a header or env map, leaving out values that end up empty
+func expandValues(values map[string]string, expand func(string) string) map[string]interface{} {
+	expanded := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if value = strings.TrimSpace(expand(value)); value != "" {
+			expanded[key] = value
+		}
+	}
+	return expanded
+}
+
+// Catalog returns the catalog of installable servers
+func (s *MCPManagerService) Catalog() *CatalogService {
+	return s.catalog
+}
+
+// InstallFromCatalog adds a server built from a catalog entry and the values for
+// its inputs. Like AddServer, commands outside the policy allowlist need confirmed.
+func (s *MCPManagerService) InstallFromCatalog(entryName, serverName string, values map[string]string, confirmed bool) error {
+	entry, err := s.catalog.Entry(entryName)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(serverName) == "" {
+		serverName = entry.ServerName()
+	}
+
+	serverConfig, err := BuildCatalogServer(entry, values)
+	if err != nil {
+		return err
+	}
+
+	return s.addServer(serverName, serverConfig, confirmed)
+}
+
+// SyncCatalog merges the configured registry index into the catalog file
+func (s *MCPManagerService) SyncCatalog() (*CatalogSyncResult, error) {
+	result, err := s.catalog.Sync()
+	if err != nil {
+		s.logger().Warn("Catalog sync failed", "registry", s.catalog.registryURL, "error", err)
+		return nil, err
+	}
+
+	s.logger().Info("Synced catalog", "path", s.catalog.path, "registry", s.catalog.registryURL,
+		"added", result.Registry, "skipped", result.Skipped, "total", result.Total)
+	return result, nil
+}
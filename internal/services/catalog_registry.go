@@ -0,0 +1,292 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// maxRegistryPages bounds how many pages of a paginated registry are read
+const maxRegistryPages = 50
+
+// maxRegistryPageBytes bounds the size of one registry page
+const maxRegistryPageBytes = 16 << 20
+
+// registryIndex is one page of a registry index in the format of the MCP
+// registry's /v0/servers API. Servers are either listed directly or wrapped as
+// {"server": {...}, "_meta": {...}}.
+type registryIndex struct {
+	Servers  []json.RawMessage `json:"servers"`
+	Metadata struct {
+		NextCursor string `json:"nextCursor"`
+	} `json:"metadata"`
+}
+
+type registryServer struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+	WebsiteURL  string `json:"websiteUrl"`
+	Repository  struct {
+		URL string `json:"url"`
+	} `json:"repository"`
+	Packages []registryPackage `json:"packages"`
+	Remotes  []registryRemote  `json:"remotes"`
+}
+
+type registryPackage struct {
+	RegistryType         string          `json:"registryType"` // npm, pypi or oci
+	Identifier           string          `json:"identifier"`
+	Version              string          `json:"version"`
+	EnvironmentVariables []registryInput `json:"environmentVariables"`
+}
+
+type registryRemote struct {
+	Type    string          `json:"type"` // streamable-http or sse
+	URL     string          `json:"url"`
+	Headers []registryInput `json:"headers"`
+}
+
+type registryInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	IsRequired  bool   `json:"isRequired"`
+	IsSecret    bool   `json:"isSecret"`
+	Default     string `json:"default"`
+}
+
+// fetchRegistry reads every page of the registry index and converts its servers
+// to catalog entries. It also returns the number of servers that were skipped
+// because none of their packages or remotes can be installed.
+func (c *CatalogService) fetchRegistry() ([]models.CatalogEntry, int, error) {
+	var entries []models.CatalogEntry
+	skipped := 0
+	seen := make(map[string]bool)
+
+	cursor := ""
+	for page := 0; page < maxRegistryPages; page++ {
+		index, err := c.readRegistryPage(cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, raw := range index.Servers {
+			server, err := decodeRegistryServer(raw)
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to parse registry '%s': %w", c.registryURL, err)
+			}
+
+			// The registry lists every version; the first one listed is kept
+			if seen[server.Name] {
+				continue
+			}
+			seen[server.Name] = true
+
+			entry, ok := catalogEntryFromRegistry(server)
+			if !ok {
+				skipped++
+				continue
+			}
+			entries = append(entries, entry)
+		}
+
+		cursor = index.Metadata.NextCursor
+		if cursor == "" || !isRemoteRegistry(c.registryURL) {
+			break
+		}
+	}
+
+	return entries, skipped, nil
+}
+
+// readRegistryPage reads the index from a file or one page of it from a URL
+func (c *CatalogService) readRegistryPage(cursor string) (*registryIndex, error) {
+	var data []byte
+	var err error
+
+	if isRemoteRegistry(c.registryURL) {
+		data, err = c.getRegistryPage(cursor)
+	} else {
+		data, err = os.ReadFile(config.ExpandPath(c.registryURL))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry '%s': %w", c.registryURL, err)
+	}
+
+	var index registryIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse registry '%s': %w", c.registryURL, err)
+	}
+	return &index, nil
+}
+
+func (c *CatalogService) getRegistryPage(cursor string) ([]byte, error) {
+	pageURL, err := url.Parse(c.registryURL)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != "" {
+		query := pageURL.Query()
+		query.Set("cursor", cursor)
+		pageURL.RawQuery = query.Encode()
+	}
+
+	resp, err := c.client.Get(pageURL.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRegistryPageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxRegistryPageBytes {
+		return nil, fmt.Errorf("page is larger than %d MB", maxRegistryPageBytes>>20)
+	}
+	return data, nil
+}
+
+func isRemoteRegistry(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// decodeRegistryServer accepts a server listed directly or wrapped in "server"
+func decodeRegistryServer(raw json.RawMessage) (*registryServer, error) {
+	var wrapped struct {
+		Server *registryServer `json:"server"`
+	}
+	if err := json.Unmarshal(raw, &wrapped); err != nil {
+		return nil, err
+	}
+	if wrapped.Server != nil {
+		return wrapped.Server, nil
+	}
+
+	var server registryServer
+	if err := json.Unmarshal(raw, &server); err != nil {
+		return nil, err
+	}
+	return &server, nil
+}
+
+// catalogEntryFromRegistry converts a registry server, preferring a package
+// (run with npx, uvx or docker) over a remote endpoint
+func catalogEntryFromRegistry(server *registryServer) (models.CatalogEntry, bool) {
+	entry := models.CatalogEntry{
+		Name:        server.Name,
+		Description: server.Description,
+		Docs:        server.WebsiteURL,
+		Source:      CatalogSourceRegistry,
+	}
+	if entry.Docs == "" {
+		entry.Docs = server.Repository.URL
+	}
+	if entry.Name == "" {
+		return entry, false
+	}
+
+	for _, pkg := range server.Packages {
+		if command, args, ok := registryPackageCommand(pkg); ok {
+			entry.Transport = CatalogTransportStdio
+			entry.Command = command
+			entry.Args = args
+			for _, variable := range pkg.EnvironmentVariables {
+				if entry.Env == nil {
+					entry.Env = make(map[string]string)
+				}
+				entry.Env[variable.Name] = "${" + variable.Name + "}"
+				entry.Inputs = append(entry.Inputs, catalogInputFromRegistry(variable))
+			}
+			return entry, true
+		}
+	}
+
+	for _, remote := range server.Remotes {
+		switch remote.Type {
+		case "streamable-http":
+			entry.Transport = CatalogTransportHTTP
+		case "sse":
+			entry.Transport = CatalogTransportSSE
+		default:
+			continue
+		}
+		entry.URL = remote.URL
+		for _, header := range remote.Headers {
+			if entry.Headers == nil {
+				entry.Headers = make(map[string]string)
+			}
+			input := catalogInputFromRegistry(header)
+			input.Name = inputNameForHeader(header.Name)
+			entry.Headers[header.Name] = "${" + input.Name + "}"
+			entry.Inputs = append(entry.Inputs, input)
+		}
+		return entry, true
+	}
+
+	return entry, false
+}
+
+// registryPackageCommand returns the command running a package, when its registry is supported
+func registryPackageCommand(pkg registryPackage) (string, []string, bool) {
+	if pkg.Identifier == "" {
+		return "", nil, false
+	}
+
+	spec := pkg.Identifier
+	switch pkg.RegistryType {
+	case "npm":
+		if pkg.Version != "" {
+			spec += "@" + pkg.Version
+		}
+		return "npx", []string{"-y", spec}, true
+	case "pypi":
+		if pkg.Version != "" {
+			spec += "@" + pkg.Version
+		}
+		return "uvx", []string{spec}, true
+	case "oci":
+		if pkg.Version != "" && !strings.Contains(spec, ":") {
+			spec += ":" + pkg.Version
+		}
+		args := []string{"run", "-i", "--rm"}
+		for _, variable := range pkg.EnvironmentVariables {
+			args = append(args, "-e", variable.Name)
+		}
+		return "docker", append(args, spec), true
+	default:
+		return "", nil, false
+	}
+}
+
+func catalogInputFromRegistry(input registryInput) models.CatalogInput {
+	return models.CatalogInput{
+		Name:        input.Name,
+		Description: input.Description,
+		Required:    input.IsRequired,
+		Secret:      input.IsSecret,
+		Default:     input.Default,
+	}
+}
+
+// inputNameForHeader turns a header name such as "X-API-Key" into X_API_KEY,
+// so it can be referenced as ${X_API_KEY}
+func inputNameForHeader(header string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, header))
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+const testCatalog = `servers:
+  - name: echo-files
+    description: Echoes the files of a directory
+    transport: stdio
+    command: echo
+    args: ["--root", "${DIRECTORY}", "${HOME}"]
+    env:
+      API_KEY: "${API_KEY}"
+      LOG_LEVEL: "${LOG_LEVEL}"
+    inputs:
+      - name: DIRECTORY
+        required: true
+      - name: API_KEY
+        secret: true
+      - name: LOG_LEVEL
+        default: info
+    tags: [files]
+  - name: remote-docs
+    description: Documentation search
+    transport: http
+    url: https://docs.example.com/mcp
+    headers:
+      X-API-Key: "${DOCS_KEY}"
+    inputs:
+      - name: DOCS_KEY
+        required: true
+        secret: true
+`
+
+// testRegistry lists one server wrapped as the registry API does, one listed
+// directly and one without an installable package or remote
+const testRegistry = `{
+  "servers": [
+    {"server": {
+      "name": "io.github.example/weather",
+      "description": "Weather forecasts",
+      "version": "1.2.0",
+      "repository": {"url": "https://github.com/example/weather"},
+      "packages": [{"registryType": "npm", "identifier": "@example/weather", "version": "1.2.0",
+        "environmentVariables": [{"name": "WEATHER_KEY", "isRequired": true, "isSecret": true}]}]
+    }, "_meta": {}},
+    {
+      "name": "io.github.example/search",
+      "description": "Web search",
+      "remotes": [{"type": "streamable-http", "url": "https://search.example.com/mcp",
+        "headers": [{"name": "X-API-Key", "isRequired": true, "isSecret": true}]}]
+    },
+    {"name": "io.github.example/binary", "packages": [{"registryType": "nuget", "identifier": "Example.Binary"}]},
+    {"name": "echo-files", "packages": [{"registryType": "oci", "identifier": "example/echo"}]}
+  ]
+}`
+
+// setupCatalogTest creates a manager whose catalog and registry are test files
+func setupCatalogTest(t *testing.T) (*MCPManagerService, string) {
+	t.Helper()
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, testutil.TestConfigYAML)
+	testutil.WriteTestFile(t, filepath.Join(tempDir, CatalogFile), testCatalog)
+	testutil.WriteTestFile(t, filepath.Join(tempDir, "registry.json"), testRegistry)
+
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{},
+		Clients: map[string]*models.Client{
+			"client1": {ConfigPath: filepath.Join(tempDir, "client1.json")},
+		},
+		Catalog: &models.CatalogConfig{RegistryURL: filepath.Join(tempDir, "registry.json")},
+	}
+
+	return NewMCPManagerService(cfg, configPath), tempDir
+}
+
+func TestCatalogEntries(t *testing.T) {
+	t.Run("Built-in catalog without a file", func(t *testing.T) {
+		catalog := NewCatalogService(nil, filepath.Join(t.TempDir(), testutil.TestConfigYAML))
+		entries, err := catalog.Entries()
+		if err != nil {
+			t.Fatalf("Entries failed: %v", err)
+		}
+		if len(entries) == 0 {
+			t.Fatal("Expected the built-in catalog")
+		}
+		for _, entry := range entries {
+			values := make(map[string]string)
+			for _, input := range entry.Inputs {
+				values[input.Name] = "value"
+			}
+			if _, err := BuildCatalogServer(&entry, values); err != nil {
+				t.Errorf("Built-in entry '%s' does not build: %v", entry.Name, err)
+			}
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		service, _ := setupCatalogTest(t)
+
+		for query, want := range map[string]int{"": 2, "FILES": 1, "documentation search": 1, "files search": 0} {
+			entries, err := service.Catalog().Search(query)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(entries) != want {
+				t.Errorf("Search(%q) returned %d entries, want %d", query, len(entries), want)
+			}
+		}
+	})
+}
+
+func TestBuildCatalogServer(t *testing.T) {
+	service, _ := setupCatalogTest(t)
+
+	stdio, err := service.Catalog().Entry("echo-files")
+	if err != nil {
+		t.Fatalf("Entry failed: %v", err)
+	}
+
+	got, err := BuildCatalogServer(stdio, map[string]string{"DIRECTORY": "/srv/data"})
+	if err != nil {
+		t.Fatalf("BuildCatalogServer failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"command": "echo",
+		"args":    []interface{}{"--root", "/srv/data", "${HOME}"},
+		"env":     map[string]interface{}{"LOG_LEVEL": "info"},
+		"tags":    []interface{}{"files"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected server config:\n got %v\nwant %v", got, want)
+	}
+
+	if _, err := BuildCatalogServer(stdio, nil); err == nil {
+		t.Error("Expected an error for a missing required value")
+	}
+	if _, err := BuildCatalogServer(stdio, map[string]string{"DIRECTORY": "/srv", "TYPO": "x"}); err == nil {
+		t.Error("Expected an error for an undeclared input")
+	}
+
+	remote, _ := service.Catalog().Entry("remote-docs")
+	got, err = BuildCatalogServer(remote, map[string]string{"DOCS_KEY": "secret"})
+	if err != nil {
+		t.Fatalf("BuildCatalogServer failed: %v", err)
+	}
+	if got["httpUrl"] != "https://docs.example.com/mcp" || !reflect.DeepEqual(got["headers"], map[string]interface{}{"X-API-Key": "secret"}) {
+		t.Errorf("Unexpected remote server config: %v", got)
+	}
+}
+
+func TestInstallFromCatalog(t *testing.T) {
+	service, _ := setupCatalogTest(t)
+
+	if err := service.InstallFromCatalog("echo-files", "", map[string]string{"DIRECTORY": "/srv", "API_KEY": "k"}, false); err != nil {
+		t.Fatalf("InstallFromCatalog failed: %v", err)
+	}
+
+	servers := service.GetMCPServers()
+	if len(servers) != 1 || servers[0].Name != "echo-files" || !servers[0].HasTag("files") {
+		t.Fatalf("Expected a tagged echo-files server, got %+v", servers)
+	}
+	if env := servers[0].Config["env"].(map[string]interface{}); env["API_KEY"] != "k" {
+		t.Errorf("Expected the secret in env, got %v", env)
+	}
+
+	err := service.InstallFromCatalog("echo-files", "", map[string]string{"DIRECTORY": "/srv"}, false)
+	testutil.AssertErrorContains(t, err, "already exists")
+
+	err = service.InstallFromCatalog("missing", "", nil, false)
+	testutil.AssertErrorContains(t, err, "not found")
+}
+
+func TestCatalogSync(t *testing.T) {
+	t.Run("Merges a registry file", func(t *testing.T) {
+		service, _ := setupCatalogTest(t)
+
+		result, err := service.SyncCatalog()
+		if err != nil {
+			t.Fatalf("SyncCatalog failed: %v", err)
+		}
+		if *result != (CatalogSyncResult{Registry: 2, Skipped: 2, Total: 4}) {
+			t.Errorf("Unexpected sync result: %+v", result)
+		}
+
+		weather, err := service.Catalog().Entry("io.github.example/weather")
+		if err != nil {
+			t.Fatalf("Expected the registry entry: %v", err)
+		}
+		if weather.Command != "npx" || !reflect.DeepEqual(weather.Args, []string{"-y", "@example/weather@1.2.0"}) ||
+			weather.Env["WEATHER_KEY"] != "${WEATHER_KEY}" || len(weather.Inputs) != 1 || !weather.Inputs[0].Secret ||
+			weather.ServerName() != "weather" || weather.Docs != "https://github.com/example/weather" {
+			t.Errorf("Unexpected npm entry: %+v", weather)
+		}
+
+		search, _ := service.Catalog().Entry("io.github.example/search")
+		if search.Transport != CatalogTransportHTTP || search.Headers["X-API-Key"] != "${X_API_KEY}" {
+			t.Errorf("Unexpected remote entry: %+v", search)
+		}
+
+		local, _ := service.Catalog().Entry("echo-files")
+		if local.Source != "" || local.Command != "echo" {
+			t.Errorf("Expected the local entry to win over the registry, got %+v", local)
+		}
+
+		// A second sync replaces the registry entries instead of adding them again
+		result, err = service.SyncCatalog()
+		if err != nil || result.Total != 4 {
+			t.Errorf("Expected the same catalog after a second sync, got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("Follows registry pages", func(t *testing.T) {
+		registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("cursor") == "" {
+				w.Write([]byte(`{"servers": [{"server": {"name": "a/one", "remotes": [{"type": "sse", "url": "https://one.example.com/sse"}]}}], "metadata": {"nextCursor": "page2"}}`))
+				return
+			}
+			w.Write([]byte(`{"servers": [{"server": {"name": "a/two", "packages": [{"registryType": "pypi", "identifier": "two-mcp"}]}}], "metadata": {}}`))
+		}))
+		defer registry.Close()
+
+		catalog := NewCatalogService(&models.CatalogConfig{Path: "catalog.json", RegistryURL: registry.URL}, filepath.Join(t.TempDir(), testutil.TestConfigYAML))
+		if _, err := catalog.Sync(); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+
+		entries, err := catalog.Search("a/")
+		if err != nil {
+			t.Fatalf("Failed to read the JSON catalog: %v", err)
+		}
+		if len(entries) != 2 || entries[0].Transport != CatalogTransportSSE || entries[1].Command != "uvx" {
+			t.Errorf("Unexpected entries from the paged registry: %+v", entries)
+		}
+	})
+
+	t.Run("No registry configured", func(t *testing.T) {
+		catalog := NewCatalogService(nil, filepath.Join(t.TempDir(), testutil.TestConfigYAML))
+		if _, err := catalog.Sync(); !errors.Is(err, ErrRegistryNotConfigured) {
+			t.Errorf("Expected ErrRegistryNotConfigured, got %v", err)
+		}
+	})
+}
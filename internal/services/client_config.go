@@ -1,19 +1,37 @@
 package services
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/jsonedit"
 	"github.com/vlazic/mcp-server-manager/internal/models"
 )
 
+// clientServersKey is the key holding server entries in global client config files
+const clientServersKey = "mcpServers"
+
+// maxClientBackups is how many backups are kept per client file; older ones are removed
+const maxClientBackups = 10
+
+// bearerTokenPattern matches an Authorization header with a bearer token as
+// written by withAuthorization
+var bearerTokenPattern = regexp.MustCompile(`("Authorization"\s*:\s*)"Bearer [^"]*"`)
+
 type ClientConfigService struct {
 	config    *models.Config
 	validator *ValidatorService
+	logger    *slog.Logger  // Nil for the default logger
+	oauth     *OAuthService // Supplies Authorization headers of servers using OAuth; nil leaves them out
 }
 
 func NewClientConfigService(cfg *models.Config) *ClientConfigService {
@@ -23,32 +41,61 @@ func NewClientConfigService(cfg *models.Config) *ClientConfigService {
 	}
 }
 
+// withLogger returns a copy of the service that logs file operations to logger
+func (s *ClientConfigService) withLogger(logger *slog.Logger) *ClientConfigService {
+	view := *s
+	view.logger = logger
+	return &view
+}
+
+func (s *ClientConfigService) log() *slog.Logger {
+	if s.logger != nil {
+		return s.logger
+	}
+	return slog.Default()
+}
+
 func (s *ClientConfigService) ReadClientConfig(clientName string) (map[string]interface{}, error) {
 	client := s.findClient(clientName)
 	if client == nil {
 		return nil, fmt.Errorf("client '%s' not found", clientName)
 	}
 
-	configPath := config.ExpandPath(client.ConfigPath)
+	return s.readConfigFile(config.ExpandPath(client.ConfigPath), clientServersKey, client.Format)
+}
+
+// readConfigFile reads a client config file, making sure the servers section exists.
+// Comments and trailing commas are accepted unless the format is strict JSON; they
+// survive rewrites because writeConfigFile patches the original text.
+func (s *ClientConfigService) readConfigFile(configPath, serversKey, format string) (map[string]interface{}, error) {
+	s.log().Debug("Reading client config", "path", configPath)
+
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			// Create empty config if file doesn't exist
 			return map[string]interface{}{
-				"mcpServers": make(map[string]interface{}),
+				serversKey: make(map[string]interface{}),
 			}, nil
 		}
 		return nil, fmt.Errorf("failed to read client config '%s': %w", configPath, err)
 	}
 
+	if format != models.ClientFormatJSON {
+		data = jsonedit.Standardize(data)
+	}
+
+	// Keep numbers as written so large values survive a rewrite
 	var rawConfig map[string]interface{}
-	if err := json.Unmarshal(data, &rawConfig); err != nil {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&rawConfig); err != nil {
 		return nil, fmt.Errorf("failed to parse client config '%s': %w", configPath, err)
 	}
 
-	// Initialize mcpServers if it doesn't exist
-	if rawConfig["mcpServers"] == nil {
-		rawConfig["mcpServers"] = make(map[string]interface{})
+	// Initialize the servers section if it doesn't exist
+	if rawConfig[serversKey] == nil {
+		rawConfig[serversKey] = make(map[string]interface{})
 	}
 
 	return rawConfig, nil
@@ -60,25 +107,65 @@ func (s *ClientConfigService) WriteClientConfig(clientName string, rawConfig map
 		return fmt.Errorf("client '%s' not found", clientName)
 	}
 
-	configPath := config.ExpandPath(client.ConfigPath)
+	return s.writeConfigFile(config.ExpandPath(client.ConfigPath), clientServersKey, rawConfig)
+}
+
+// writeConfigFile backs up and rewrites a client config file. Only values that changed
+// are rewritten; key order, indentation and everything else in the file are kept.
+// Entries of the servers section follow config.yaml order, followed by unmanaged entries.
+// Nothing is written when the file already matches.
+func (s *ClientConfigService) writeConfigFile(configPath, serversKey string, rawConfig map[string]interface{}) (err error) {
+	start := time.Now()
+	defer func() {
+		if err != nil {
+			clientFileWriteFailuresTotal.Inc(configPath)
+		}
+	}()
 
-	if err := s.backupConfig(configPath); err != nil {
-		return fmt.Errorf("failed to backup config: %w", err)
+	original, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read client config '%s': %w", configPath, err)
 	}
 
-	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+	output := make(map[string]interface{}, len(rawConfig))
+	for key, value := range rawConfig {
+		output[key] = value
+	}
+	if servers, ok := rawConfig[serversKey].(map[string]interface{}); ok {
+		output[serversKey] = s.orderServers(servers)
 	}
 
-	data, err := json.MarshalIndent(rawConfig, "", "  ")
+	// A file that cannot be patched is left alone rather than replaced: it was
+	// read successfully moments ago, so it was changed in between or holds
+	// something the patcher does not understand, and either way it is not ours to drop
+	data, err := jsonedit.Patch(original, output)
 	if err != nil {
-		return fmt.Errorf("failed to marshal client config: %w", err)
+		return fmt.Errorf("failed to update client config '%s', leaving it unchanged: %w", configPath, err)
+	}
+
+	if bytes.Equal(data, original) {
+		s.log().Debug("Client config already up to date", "path", configPath)
+		return nil
+	}
+
+	// Refreshing an OAuth token rewrites the file every hour or so; a backup of
+	// each expired token would only pile up credentials
+	if !tokenOnlyChange(original, data) {
+		if err := s.backupConfig(configPath); err != nil {
+			return fmt.Errorf("failed to backup config: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
 	if err := os.WriteFile(configPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write client config '%s': %w", configPath, err)
 	}
 
+	clientFileWriteSeconds.Observe(time.Since(start).Seconds(), configPath)
+	s.log().Info("Wrote client config", "path", configPath, "bytes", len(data))
 	return nil
 }
 
@@ -96,35 +183,96 @@ func (s *ClientConfigService) UpdateMCPServerStatus(clientName, serverName strin
 	}
 
 	if enabled {
-		// Get server config from app config
-		var serverConfig map[string]interface{}
-		found := false
-		for _, srv := range s.config.MCPServers {
-			if srv.Name == serverName {
-				serverConfig = srv.Config
-				found = true
-				break
-			}
+		copiedConfig, err := s.serverEntry(serverName, "")
+		if err != nil {
+			return err
 		}
-		if !found {
-			return fmt.Errorf("MCP server '%s' not found in app config", serverName)
+		mcpServers[serverName] = copiedConfig
+	} else {
+		// Remove server from client config
+		delete(mcpServers, serverName)
+	}
+
+	return s.WriteClientConfig(clientName, rawConfig)
+}
+
+// SyncClient writes the desired state of every managed server to a client's config file
+func (s *ClientConfigService) SyncClient(clientName string) error {
+	client := s.findClient(clientName)
+	if client == nil {
+		return fmt.Errorf("client '%s' not found", clientName)
+	}
+
+	return s.SyncServers(config.ExpandPath(client.ConfigPath), clientServersKey, client.Format, client.Enabled, "")
+}
+
+// SyncServers brings the servers section of a config file in line with the enabled
+// list using a single write. Servers not defined in config.yaml are left untouched.
+// The format is the client's configured format, or empty to detect it. The
+// workspace is the project directory for project files, empty otherwise.
+func (s *ClientConfigService) SyncServers(configPath, serversKey, format string, enabled []string, workspace string) error {
+	rawConfig, err := s.readConfigFile(configPath, serversKey, format)
+	if err != nil {
+		return err
+	}
+
+	servers, ok := rawConfig[serversKey].(map[string]interface{})
+	if !ok {
+		servers = make(map[string]interface{})
+		rawConfig[serversKey] = servers
+	}
+
+	for _, srv := range s.config.MCPServers {
+		if !contains(enabled, srv.Name) {
+			delete(servers, srv.Name)
+			continue
+		}
+		entry, err := s.serverEntry(srv.Name, workspace)
+		if err != nil {
+			return err
+		}
+		servers[srv.Name] = entry
+	}
+
+	return s.writeConfigFile(configPath, serversKey, rawConfig)
+}
+
+// orderServers puts server entries in config.yaml order; unmanaged entries follow
+// in the order they already have in the file
+func (s *ClientConfigService) orderServers(servers map[string]interface{}) jsonedit.Object {
+	keys := make([]string, 0, len(s.config.MCPServers))
+	for _, srv := range s.config.MCPServers {
+		keys = append(keys, srv.Name)
+	}
+	return jsonedit.Object{Keys: keys, Values: servers}
+}
+
+// serverEntry returns the entry written to client files for a server, with
+// variables expanded for the given workspace. Servers using OAuth get their
+// current access token as Authorization header in global client files; project
+// files are often committed, so they never carry it.
+func (s *ClientConfigService) serverEntry(serverName, workspace string) (map[string]interface{}, error) {
+	for _, srv := range s.config.MCPServers {
+		if srv.Name != serverName {
+			continue
 		}
 
 		// CRITICAL FIX: Copy the ENTIRE server config map without filtering
 		// This preserves ALL fields: type, url, httpUrl, command, args, env, headers, etc.
 		// Deep copy to avoid mutations
 		copiedConfig := make(map[string]interface{})
-		for key, value := range serverConfig {
+		for key, value := range srv.Config {
 			copiedConfig[key] = value
 		}
-
-		mcpServers[serverName] = copiedConfig
-	} else {
-		// Remove server from client config
-		delete(mcpServers, serverName)
+		entry := interpolateServerConfig(copiedConfig, newVariables(s.config.Vars, workspace))
+		if srv.OAuth != nil && s.oauth != nil && workspace == "" {
+			if token, ok := s.oauth.AccessToken(srv.Name); ok {
+				entry["headers"] = withAuthorization(entry["headers"], token)
+			}
+		}
+		return entry, nil
 	}
-
-	return s.WriteClientConfig(clientName, rawConfig)
+	return nil, fmt.Errorf("MCP server '%s' not found in app config", serverName)
 }
 
 func (s *ClientConfigService) GetMCPServerStatus(clientName, serverName string) (bool, error) {
@@ -149,10 +297,17 @@ func (s *ClientConfigService) findClient(name string) *models.Client {
 	return nil
 }
 
+// backupConfig copies a client file next to itself with a timestamp, keeping
+// its mode, and removes all but the newest maxClientBackups backups. Files with
+// headers, which usually hold credentials, are backed up readable by the owner only.
 func (s *ClientConfigService) backupConfig(configPath string) error {
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+	info, err := os.Stat(configPath)
+	if os.IsNotExist(err) {
 		return nil
 	}
+	if err != nil {
+		return err
+	}
 
 	backupPath := configPath + ".backup." + time.Now().Format("20060102-150405")
 
@@ -161,5 +316,72 @@ func (s *ClientConfigService) backupConfig(configPath string) error {
 		return err
 	}
 
-	return os.WriteFile(backupPath, data, 0644)
+	mode := info.Mode().Perm()
+	if bytes.Contains(data, []byte(`"headers"`)) {
+		mode &= 0600
+	}
+	if err := os.WriteFile(backupPath, data, mode); err != nil {
+		return err
+	}
+	// WriteFile leaves the mode of a backup from earlier in the same second as is
+	if err := os.Chmod(backupPath, mode); err != nil {
+		return err
+	}
+
+	s.log().Debug("Backed up client config", "path", configPath, "backup", backupPath)
+	backupsTotal.Inc(configPath)
+	backupBytesTotal.Add(float64(len(data)), configPath)
+
+	s.pruneBackups(configPath)
+	return nil
+}
+
+// pruneBackups removes the oldest backups of a client file beyond maxClientBackups.
+// Failures are only logged: the write the backup was made for can go ahead.
+func (s *ClientConfigService) pruneBackups(configPath string) {
+	entries, err := os.ReadDir(filepath.Dir(configPath))
+	if err != nil {
+		s.log().Warn("Failed to list client config backups", "path", configPath, "error", err)
+		return
+	}
+
+	prefix := filepath.Base(configPath) + ".backup."
+	var backups []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), prefix) && !entry.IsDir() {
+			backups = append(backups, entry.Name())
+		}
+	}
+	// The timestamp format sorts oldest first
+	sort.Strings(backups)
+
+	for len(backups) > maxClientBackups {
+		path := filepath.Join(filepath.Dir(configPath), backups[0])
+		if err := os.Remove(path); err != nil {
+			s.log().Warn("Failed to remove old client config backup", "backup", path, "error", err)
+		}
+		backups = backups[1:]
+	}
+}
+
+// tokenOnlyChange reports whether two versions of a client file differ only in
+// bearer tokens of Authorization headers
+func tokenOnlyChange(original, updated []byte) bool {
+	if original == nil {
+		return false
+	}
+	mask := []byte(`$1"Bearer"`)
+	return bytes.Equal(bearerTokenPattern.ReplaceAll(original, mask), bearerTokenPattern.ReplaceAll(updated, mask))
+}
+
+// withAuthorization returns a copy of a headers map with a bearer token set
+func withAuthorization(rawHeaders interface{}, token string) map[string]interface{} {
+	headers := make(map[string]interface{})
+	if existing, ok := rawHeaders.(map[string]interface{}); ok {
+		for key, value := range existing {
+			headers[key] = value
+		}
+	}
+	headers["Authorization"] = "Bearer " + token
+	return headers
 }
\ No newline at end of file
@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -22,6 +23,7 @@ import (
 // - Non-MCP settings preservation: Client theme, auth, etc. must not be affected
 // - Error handling: Malformed JSON, permission errors, non-existent servers
 // - Empty/missing config handling: Graceful creation of default structures
+// - Format preservation: Only the mcpServers subtree is rewritten, byte for byte elsewhere
 //
 // IMPORTANT: The field preservation test (TestFieldPreservation) validates the fix
 // for a critical bug where only command/args were copied, losing fields like url,
@@ -642,4 +644,197 @@ func TestUpdateMCPServerStatus_PreserveOtherSettings(t *testing.T) {
 	if rawConfig["settings"] == nil {
 		t.Error("Settings section not preserved")
 	}
-}
\ No newline at end of file
+}
+
+func TestSyncServers_PreservesFormatting(t *testing.T) {
+	service, clientConfigPath := setupClientConfigTest(t, []models.MCPServer{
+		{Name: "second", Config: map[string]interface{}{"command": "b"}},
+		{Name: "first", Config: map[string]interface{}{"command": "a", "timeout": 30000}},
+	}, []string{"first", "second"})
+
+	original := `{
+	"userID": 98765432109876543210,
+	"theme": "dark",
+	"mcpServers": {
+		"first": {"command": "a", "timeout": 30000}
+	},
+	"autoUpdates": false
+}
+`
+	testutil.WriteTestFile(t, clientConfigPath, original)
+
+	if err := service.SyncClient("test_client"); err != nil {
+		t.Fatalf("SyncClient failed: %v", err)
+	}
+
+	data, err := os.ReadFile(clientConfigPath)
+	if err != nil {
+		t.Fatalf("Failed to read client config: %v", err)
+	}
+
+	// "second" comes first in config.yaml, so it is written first; the untouched
+	// "first" entry keeps its original single-line layout
+	expected := `{
+	"userID": 98765432109876543210,
+	"theme": "dark",
+	"mcpServers": {
+		"second": {
+			"command": "b"
+		},
+		"first": {"command": "a", "timeout": 30000}
+	},
+	"autoUpdates": false
+}
+`
+	if string(data) != expected {
+		t.Errorf("Unexpected client config:\n%s\nexpected:\n%s", data, expected)
+	}
+}
+
+func TestSyncServers_SkipsUnchangedFile(t *testing.T) {
+	service, clientConfigPath := setupClientConfigTest(t, []models.MCPServer{
+		{Name: testutil.TestServerName, Config: map[string]interface{}{"command": "echo"}},
+	}, []string{testutil.TestServerName})
+
+	original := "{\n  \"mcpServers\": {\n    \"test-server\": {\"command\": \"echo\"}\n  }\n}\n"
+	testutil.WriteTestFile(t, clientConfigPath, original)
+
+	if err := service.SyncClient("test_client"); err != nil {
+		t.Fatalf("SyncClient failed: %v", err)
+	}
+
+	files, _ := os.ReadDir(filepath.Dir(clientConfigPath))
+	for _, file := range files {
+		if strings.Contains(file.Name(), ".backup.") {
+			t.Errorf("Expected no backup for an unchanged file, found %s", file.Name())
+		}
+	}
+}
+
+func TestSyncServers_JSONC(t *testing.T) {
+	servers := []models.MCPServer{
+		{Name: testutil.TestServerName, Config: map[string]interface{}{"command": "echo"}},
+	}
+
+	original := `{
+  // Cursor MCP servers
+  "mcpServers": {
+    "unmanaged": {"command": "other"}, // added by hand
+  },
+}
+`
+
+	t.Run("Detected automatically and comments kept", func(t *testing.T) {
+		service, clientConfigPath := setupClientConfigTest(t, servers, []string{testutil.TestServerName})
+		testutil.WriteTestFile(t, clientConfigPath, original)
+
+		if err := service.SyncClient("test_client"); err != nil {
+			t.Fatalf("SyncClient failed: %v", err)
+		}
+
+		data, err := os.ReadFile(clientConfigPath)
+		if err != nil {
+			t.Fatalf("Failed to read client config: %v", err)
+		}
+		for _, want := range []string{"// Cursor MCP servers", "// added by hand", `"test-server"`} {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("Expected client config to contain %s:\n%s", want, data)
+			}
+		}
+
+		rawConfig, err := service.ReadClientConfig("test_client")
+		if err != nil {
+			t.Fatalf(testutil.ErrReadClientConfigFailedFmt, err)
+		}
+		if len(rawConfig["mcpServers"].(map[string]interface{})) != 2 {
+			t.Errorf("Expected 2 servers, got %v", rawConfig["mcpServers"])
+		}
+	})
+
+	t.Run("Rejected in strict JSON format", func(t *testing.T) {
+		service, clientConfigPath := setupClientConfigTest(t, servers, []string{testutil.TestServerName})
+		service.config.Clients["test_client"].Format = models.ClientFormatJSON
+		testutil.WriteTestFile(t, clientConfigPath, original)
+
+		if err := service.SyncClient("test_client"); err == nil {
+			t.Error("Expected error for comments in a strict JSON client file")
+		}
+	})
+}
+
+func TestBackupConfig_ModeAndPruning(t *testing.T) {
+	service, clientConfigPath := setupClientConfigTest(t, []models.MCPServer{}, []string{})
+	tempDir := filepath.Dir(clientConfigPath)
+
+	original := `{"mcpServers": {"remote": {"url": "https://example.com/mcp", "headers": {"X-Api-Key": "secret"}}}}`
+	if err := os.WriteFile(clientConfigPath, []byte(original), 0644); err != nil {
+		t.Fatalf(testutil.ErrWriteInitialConfigFailedFmt, err)
+	}
+	if err := service.backupConfig(clientConfigPath); err != nil {
+		t.Fatalf("backupConfig failed: %v", err)
+	}
+
+	backups, _ := filepath.Glob(clientConfigPath + ".backup.*")
+	if len(backups) != 1 {
+		t.Fatalf("Expected 1 backup, got %v", backups)
+	}
+	if info, err := os.Stat(backups[0]); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected a backup with headers to be readable by the owner only, got %v", info.Mode().Perm())
+	}
+
+	// Only the newest backups are kept
+	for i := 0; i < maxClientBackups+3; i++ {
+		stale := filepath.Join(tempDir, fmt.Sprintf("%s.backup.20000101-0000%02d", testutil.TestClientJSON, i))
+		testutil.WriteTestFile(t, stale, "{}")
+	}
+	service.pruneBackups(clientConfigPath)
+
+	backups, _ = filepath.Glob(clientConfigPath + ".backup.*")
+	if len(backups) != maxClientBackups {
+		t.Fatalf("Expected %d backups after pruning, got %d", maxClientBackups, len(backups))
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, testutil.TestClientJSON+".backup.20000101-000000")); !os.IsNotExist(err) {
+		t.Error("Expected the oldest backup to be removed")
+	}
+}
+
+func TestBackupConfig_SkipsTokenOnlyChange(t *testing.T) {
+	service, clientConfigPath := setupClientConfigTest(t, []models.MCPServer{}, []string{})
+
+	withToken := func(token string) map[string]interface{} {
+		return map[string]interface{}{"mcpServers": map[string]interface{}{
+			"remote": map[string]interface{}{"url": "https://example.com/mcp", "headers": map[string]interface{}{"Authorization": "Bearer " + token}},
+		}}
+	}
+	if err := service.WriteClientConfig("test_client", withToken("old")); err != nil {
+		t.Fatalf(testutil.ErrWriteClientConfigFailedFmt, err)
+	}
+	if err := service.WriteClientConfig("test_client", withToken("new")); err != nil {
+		t.Fatalf(testutil.ErrWriteClientConfigFailedFmt, err)
+	}
+
+	if data, _ := os.ReadFile(clientConfigPath); !strings.Contains(string(data), "Bearer new") {
+		t.Fatalf("Expected the new token in the client file, got %s", data)
+	}
+	if backups, _ := filepath.Glob(clientConfigPath + ".backup.*"); len(backups) != 0 {
+		t.Errorf("Expected no backup for a token refresh, got %v", backups)
+	}
+}
+
+func TestWriteClientConfig_UnpatchableFileIsKept(t *testing.T) {
+	service, clientConfigPath := setupClientConfigTest(t, []models.MCPServer{}, []string{})
+
+	// Changed after it was read into something the patcher refuses
+	original := `{"mcpServers": {}, "theme": nope}`
+	testutil.WriteTestFile(t, clientConfigPath, original)
+
+	err := service.WriteClientConfig("test_client", map[string]interface{}{"mcpServers": map[string]interface{}{}})
+	testutil.AssertErrorContains(t, err, "leaving it unchanged")
+
+	if data, _ := os.ReadFile(clientConfigPath); string(data) != original {
+		t.Errorf("Expected the file to be kept, got %s", data)
+	}
+	if backups, _ := filepath.Glob(clientConfigPath + ".backup.*"); len(backups) != 0 {
+		t.Errorf("Expected no backup, got %v", backups)
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import "github.com/vlazic/mcp-server-manager/internal/models"
+
+// The getters hand out copies made under the lock, so callers can read them
+// while a reload or mutation replaces or edits the live configuration
+
+// copyValue deep-copies a decoded YAML or JSON value
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return copyConfigMap(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = copyValue(item)
+		}
+		return out
+	case []string:
+		return copyStrings(v)
+	default:
+		return v
+	}
+}
+
+// copyStrings copies a string slice, keeping nil and empty apart
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(s)), s...)
+}
+
+// copyConfigMap deep-copies a passthrough server or template config
+func copyConfigMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		out[key] = copyValue(value)
+	}
+	return out
+}
+
+// copyServer deep-copies a server entry
+func copyServer(srv models.MCPServer) models.MCPServer {
+	srv.Tags = copyStrings(srv.Tags)
+	srv.Config = copyConfigMap(srv.Config)
+	if srv.Template != nil {
+		ref := *srv.Template
+		if ref.Params != nil {
+			ref.Params = make(map[string]string, len(srv.Template.Params))
+			for key, value := range srv.Template.Params {
+				ref.Params[key] = value
+			}
+		}
+		srv.Template = &ref
+	}
+	if srv.OAuth != nil {
+		oauth := *srv.OAuth
+		oauth.Scopes = copyStrings(oauth.Scopes)
+		srv.OAuth = &oauth
+	}
+	return srv
+}
+
+func copyServers(servers []models.MCPServer) []models.MCPServer {
+	if servers == nil {
+		return nil
+	}
+	out := make([]models.MCPServer, len(servers))
+	for i, srv := range servers {
+		out[i] = copyServer(srv)
+	}
+	return out
+}
+
+func copyClients(clients map[string]*models.Client) map[string]*models.Client {
+	if clients == nil {
+		return nil
+	}
+	out := make(map[string]*models.Client, len(clients))
+	for name, client := range clients {
+		c := *client
+		c.Enabled = copyStrings(client.Enabled)
+		out[name] = &c
+	}
+	return out
+}
+
+func copyProjects(projects map[string]*models.Project) map[string]*models.Project {
+	if projects == nil {
+		return nil
+	}
+	out := make(map[string]*models.Project, len(projects))
+	for name, project := range projects {
+		p := *project
+		p.Clients = copyStrings(project.Clients)
+		p.Enabled = copyStrings(project.Enabled)
+		out[name] = &p
+	}
+	return out
+}
+
+func copyTemplates(templates map[string]*models.ServerTemplate) map[string]*models.ServerTemplate {
+	if templates == nil {
+		return nil
+	}
+	out := make(map[string]*models.ServerTemplate, len(templates))
+	for name, tmpl := range templates {
+		t := *tmpl
+		t.Params = append([]models.TemplateParam(nil), tmpl.Params...)
+		for i := range t.Params {
+			t.Params[i].Values = copyStrings(t.Params[i].Values)
+		}
+		t.Config = copyConfigMap(tmpl.Config)
+		out[name] = &t
+	}
+	return out
+}
+
+// copyConfig copies the parts of the configuration mutations edit in place.
+// Sections such as policy or auth are only ever replaced as a whole, by a
+// reload, so they are shared.
+func copyConfig(cfg *models.Config) *models.Config {
+	out := *cfg
+	out.MCPServers = copyServers(cfg.MCPServers)
+	out.Clients = copyClients(cfg.Clients)
+	out.Projects = copyProjects(cfg.Projects)
+	out.Templates = copyTemplates(cfg.Templates)
+	if cfg.Vars != nil {
+		out.Vars = make(map[string]string, len(cfg.Vars))
+		for key, value := range cfg.Vars {
+			out.Vars[key] = value
+		}
+	}
+	if cfg.Profiles != nil {
+		out.Profiles = make(map[string]models.Profile, len(cfg.Profiles))
+		for name, profile := range cfg.Profiles {
+			p := make(models.Profile, len(profile))
+			for client, servers := range profile {
+				p[client] = copyStrings(servers)
+			}
+			out.Profiles[name] = p
+		}
+	}
+	return &out
+}
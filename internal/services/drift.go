@@ -0,0 +1,157 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Drift kinds reported when a client file no longer matches config.yaml
+const (
+	DriftMissing    = "missing"    // Enabled server was removed from the client file
+	DriftChanged    = "changed"    // Enabled server entry differs from config.yaml
+	DriftUnexpected = "unexpected" // Disabled server was added back to the client file
+)
+
+// maxDriftEvents bounds the in-memory drift history
+const maxDriftEvents = 100
+
+// DriftEvent records a managed server entry that diverged from the desired state
+type DriftEvent struct {
+	Client     string    `json:"client"`
+	Server     string    `json:"server"`
+	Kind       string    `json:"kind"`
+	Healed     bool      `json:"healed"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// DetectClientDrift compares a client's config file with the desired state.
+// Only servers defined in config.yaml are considered; unmanaged entries are ignored.
+func (s *MCPManagerService) DetectClientDrift(clientName string) ([]DriftEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.detectClientDrift(clientName)
+}
+
+func (s *MCPManagerService) detectClientDrift(clientName string) ([]DriftEvent, error) {
+	client, exists := s.config.Clients[clientName]
+	if !exists {
+		return nil, fmt.Errorf("client '%s' not found", clientName)
+	}
+
+	rawConfig, err := s.clientConfigService.ReadClientConfig(clientName)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, _ := rawConfig["mcpServers"].(map[string]interface{})
+	now := time.Now()
+
+	var events []DriftEvent
+	for _, srv := range s.config.MCPServers {
+		entry, present := actual[srv.Name]
+		kind := ""
+
+		// Compare with the entry exactly as the writer produces it, OAuth header included
+		expected, err := s.clientConfigService.serverEntry(srv.Name, "")
+		if err != nil {
+			return nil, err
+		}
+
+		switch enabled := contains(client.Enabled, srv.Name); {
+		case enabled && !present:
+			kind = DriftMissing
+		case enabled && !jsonEqual(entry, expected):
+			kind = DriftChanged
+		case !enabled && present:
+			kind = DriftUnexpected
+		}
+
+		if kind != "" {
+			events = append(events, DriftEvent{
+				Client:     clientName,
+				Server:     srv.Name,
+				Kind:       kind,
+				DetectedAt: now,
+			})
+		}
+	}
+
+	return events, nil
+}
+
+// HealClientDrift re-applies the desired state for every drifted entry of a client
+// and records the events as healed
+func (s *MCPManagerService) HealClientDrift(clientName string, events []DriftEvent) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("heal_drift", clientName, &err)
+
+	client, exists := s.config.Clients[clientName]
+	if !exists {
+		return fmt.Errorf("client '%s' not found", clientName)
+	}
+
+	for i := range events {
+		enabled := contains(client.Enabled, events[i].Server)
+		if err := s.clientConfigService.UpdateMCPServerStatus(clientName, events[i].Server, enabled); err != nil {
+			return fmt.Errorf("failed to heal '%s' for client '%s': %w", events[i].Server, clientName, err)
+		}
+		events[i].Healed = true
+	}
+
+	s.recordDrift(events)
+	return nil
+}
+
+// RecordDrift stores drift events without changing any files (notify mode)
+func (s *MCPManagerService) RecordDrift(events []DriftEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recordDrift(events)
+}
+
+func (s *MCPManagerService) recordDrift(events []DriftEvent) {
+	if len(events) > 0 {
+		s.events.Publish(EventDriftDetected, events)
+	}
+	for _, event := range events {
+		driftEventsTotal.Inc(event.Client, event.Kind)
+	}
+
+	s.shared.driftEvents = append(s.shared.driftEvents, events...)
+	if overflow := len(s.shared.driftEvents) - maxDriftEvents; overflow > 0 {
+		s.shared.driftEvents = s.shared.driftEvents[overflow:]
+	}
+}
+
+// GetDriftEvents returns recorded drift events, oldest first
+func (s *MCPManagerService) GetDriftEvents() []DriftEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]DriftEvent, len(s.shared.driftEvents))
+	copy(events, s.shared.driftEvents)
+	return events
+}
+
+// jsonEqual compares two values after a JSON round trip, so that YAML-decoded
+// ints and JSON-decoded float64s compare equal
+func jsonEqual(a, b interface{}) bool {
+	return reflect.DeepEqual(normalizeJSON(a), normalizeJSON(b))
+}
+
+func normalizeJSON(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return v
+	}
+	return normalized
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types published on the event bus and streamed by GET /api/events
+const (
+	EventServerAdded    = "server_added"    // A server was added to config.yaml
+	EventServerToggled  = "server_toggled"  // A server was enabled or disabled for a client or project
+	EventSynced         = "synced"          // Client or project files were rewritten from config.yaml
+	EventDriftDetected  = "drift_detected"  // A client file diverged from config.yaml
+	EventHealthChanged  = "health_changed"  // A server became healthy or unhealthy
+	EventConfigReloaded = "config_reloaded" // config.yaml changed as a whole, e.g. edited by hand or undone
+)
+
+// eventTypes lists every event type, for validating event filters
+var eventTypes = []string{
+	EventServerAdded, EventServerToggled, EventSynced,
+	EventDriftDetected, EventHealthChanged, EventConfigReloaded,
+}
+
+// eventBuffer is how many events a slow subscriber may fall behind before
+// further events are dropped for it
+const eventBuffer = 64
+
+// Event is a typed notification about a change in the manager
+type Event struct {
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// ServerAddedData is the data of EventServerAdded
+type ServerAddedData struct {
+	Server string `json:"server"`
+}
+
+// ServerToggledData is the data of EventServerToggled; either Clients or Project is set
+type ServerToggledData struct {
+	Clients []string `json:"clients,omitempty"`
+	Project string   `json:"project,omitempty"`
+	Servers []string `json:"servers"`
+	Enabled bool     `json:"enabled"`
+}
+
+// SyncedData is the data of EventSynced; either Clients or Project is set
+type SyncedData struct {
+	Clients []string `json:"clients,omitempty"`
+	Project string   `json:"project,omitempty"`
+}
+
+// ConfigReloadedData is the data of EventConfigReloaded. Reason is the audited
+// action that replaced the configuration, e.g. "undo" or "reload".
+type ConfigReloadedData struct {
+	Reason string `json:"reason"`
+}
+
+// EventBus fans events out to every subscriber. Publishing never blocks: a
+// subscriber that does not keep up misses events rather than stalling changes.
+type EventBus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewEventBus creates an event bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[chan Event]struct{})}
+}
+
+// Subscribe returns a channel receiving every event published from now on and a
+// function that unsubscribes and closes the channel
+func (b *EventBus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends an event of the given type to all subscribers
+func (b *EventBus) Publish(eventType string, data interface{}) {
+	event := Event{Type: eventType, Time: time.Now().UTC(), Data: data}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+// nextEvent waits briefly for an event on the channel
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("Expected an event, got none")
+		return Event{}
+	}
+}
+
+// assertNoEvent fails if an event is waiting on the channel
+func assertNoEvent(t *testing.T, events <-chan Event) {
+	t.Helper()
+	select {
+	case event := <-events:
+		t.Errorf("Expected no event, got %s", event.Type)
+	default:
+	}
+}
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+	first, unsubscribeFirst := bus.Subscribe()
+	second, unsubscribeSecond := bus.Subscribe()
+	defer unsubscribeSecond()
+
+	bus.Publish(EventSynced, SyncedData{Clients: []string{"cursor"}})
+	for _, events := range []<-chan Event{first, second} {
+		if event := nextEvent(t, events); event.Type != EventSynced {
+			t.Errorf("Expected %s, got %s", EventSynced, event.Type)
+		}
+	}
+
+	unsubscribeFirst()
+	unsubscribeFirst() // Safe to call twice
+	if _, open := <-first; open {
+		t.Error("Expected the channel to be closed after unsubscribing")
+	}
+
+	// A subscriber that never reads must not block publishing
+	for i := 0; i < eventBuffer*2; i++ {
+		bus.Publish(EventSynced, nil)
+	}
+}
+
+func TestManagerEvents(t *testing.T) {
+	service, _ := setupDriftTest(t)
+	events, unsubscribe := service.Events().Subscribe()
+	defer unsubscribe()
+
+	if err := service.ToggleClientMCPServer(testutil.TestClientName, "disabled-server", true); err != nil {
+		t.Fatalf("ToggleClientMCPServer failed: %v", err)
+	}
+	event := nextEvent(t, events)
+	data, ok := event.Data.(ServerToggledData)
+	if event.Type != EventServerToggled || !ok || !data.Enabled || data.Servers[0] != "disabled-server" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+
+	// Failed actions publish nothing
+	if err := service.ToggleClientMCPServer(testutil.TestClientName, "missing", true); err == nil {
+		t.Fatal("Expected toggling an unknown server to fail")
+	}
+	assertNoEvent(t, events)
+
+	if err := service.AddServer("new-server", map[string]interface{}{"command": "echo"}); err != nil {
+		t.Fatalf("AddServer failed: %v", err)
+	}
+	if event := nextEvent(t, events); event.Type != EventServerAdded {
+		t.Errorf("Expected %s, got %s", EventServerAdded, event.Type)
+	}
+
+	if _, err := service.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	event = nextEvent(t, events)
+	if reload, _ := event.Data.(ConfigReloadedData); event.Type != EventConfigReloaded || reload.Reason != "undo" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, testutil.TestConfigYAML)
+	clientPath := filepath.Join(tempDir, testutil.TestClientJSON)
+
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{
+			{Name: "server-a", Config: map[string]interface{}{"command": "echo"}},
+		},
+		Clients: map[string]*models.Client{
+			testutil.TestClientName: {ConfigPath: clientPath, Enabled: []string{"server-a"}},
+		},
+	}
+	if err := config.SaveConfig(cfg, configPath); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	service := NewMCPManagerService(cfg, configPath)
+	events, unsubscribe := service.Events().Subscribe()
+	defer unsubscribe()
+
+	// The file as the manager wrote it is not a change
+	if reloaded, err := service.ReloadConfig(); err != nil || reloaded {
+		t.Fatalf("Expected no reload for an unchanged file, got %v, %v", reloaded, err)
+	}
+
+	data, _ := os.ReadFile(configPath)
+	edited := strings.Replace(string(data), "enabled:\n            - server-a", "enabled: []", 1)
+	if edited == string(data) {
+		t.Fatalf("Test setup: could not edit config:\n%s", data)
+	}
+	testutil.WriteTestFile(t, configPath, edited)
+
+	reloaded, err := service.ReloadConfig()
+	if err != nil || !reloaded {
+		t.Fatalf("Expected a reload, got %v, %v", reloaded, err)
+	}
+	if enabled := service.GetClients()[testutil.TestClientName].Enabled; len(enabled) != 0 {
+		t.Errorf("Expected the edited enabled list, got %v", enabled)
+	}
+	if _, exists := clientServers(t, clientPath)["server-a"]; exists {
+		t.Error("Expected the client file to be synced after the reload")
+	}
+	if event := nextEvent(t, events); event.Type != EventConfigReloaded {
+		t.Errorf("Expected %s, got %s", EventConfigReloaded, event.Type)
+	}
+
+	// An invalid edit keeps the current configuration
+	testutil.WriteTestFile(t, configPath, "mcpServers: [")
+	if _, err := service.ReloadConfig(); err == nil {
+		t.Error("Expected an invalid config file to be rejected")
+	}
+	if len(service.GetMCPServers()) != 1 {
+		t.Errorf("Expected the configuration to be kept, got %v", service.GetMCPServers())
+	}
+	assertNoEvent(t, events)
+}
+
+func TestReloadConfig_Layers(t *testing.T) {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		t.Skip("No hostname")
+	}
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, testutil.TestConfigYAML)
+	hostPath := filepath.Join(tempDir, "config."+host+".yaml")
+	clientPath := filepath.Join(tempDir, testutil.TestClientJSON)
+
+	testutil.WriteTestFile(t, configPath, `mcpServers:
+  server-a:
+    command: echo
+    args: ["shared"]
+clients:
+  `+testutil.TestClientName+`:
+    config_path: "`+clientPath+`"
+`)
+	testutil.WriteTestFile(t, hostPath, "mcpServers:\n  server-a:\n    args: [\"local\"]\n")
+
+	cfg, _, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf(testutil.ErrLoadConfigFailedFmt, err)
+	}
+	service := NewMCPManagerService(cfg, configPath)
+
+	// The manager's own write to the layers is not an outside change
+	if err := service.ToggleClientMCPServer(testutil.TestClientName, "server-a", true); err != nil {
+		t.Fatalf("ToggleClientMCPServer failed: %v", err)
+	}
+	if reloaded, err := service.ReloadConfig(); err != nil || reloaded {
+		t.Fatalf("Expected no reload after the manager's own save, got %v, %v", reloaded, err)
+	}
+	if entry := clientServers(t, clientPath)["server-a"].(map[string]interface{}); entry["args"].([]interface{})[0] != "local" {
+		t.Errorf("Expected the host override in the client file, got %v", entry)
+	}
+
+	// Editing only the host file is picked up
+	testutil.WriteTestFile(t, hostPath, "mcpServers:\n  server-a:\n    args: [\"edited\"]\n")
+	reloaded, err := service.ReloadConfig()
+	if err != nil || !reloaded {
+		t.Fatalf("Expected a reload, got %v, %v", reloaded, err)
+	}
+	if entry := clientServers(t, clientPath)["server-a"].(map[string]interface{}); entry["args"].([]interface{})[0] != "edited" {
+		t.Errorf("Expected the edited override in the client file, got %v", entry)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if !strings.Contains(string(data), "shared") || strings.Contains(string(data), "edited") {
+		t.Errorf("Expected the base file to keep its args, got:\n%s", data)
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+const (
+	defaultHealthInterval = 60 * time.Second
+	defaultHealthTimeout  = 5 * time.Second
+)
+
+// HealthStatus is the result of the latest health check of a server
+type HealthStatus struct {
+	Server    string    `json:"server"`
+	Healthy   bool      `json:"healthy"`
+	Detail    string    `json:"detail"`
+	LatencyMs int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+
+	duration time.Duration // Exact check time for the metrics
+}
+
+// HealthChecker periodically checks every server without starting it: stdio
+// servers need their command on PATH, HTTP servers must answer below status 500.
+// Any answer, including 401 or 404, shows the endpoint is reachable.
+type HealthChecker struct {
+	manager  *MCPManagerService
+	interval time.Duration
+	client   *http.Client
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewHealthChecker creates a checker using the manager's health settings
+func NewHealthChecker(manager *MCPManagerService, healthCfg *models.HealthConfig) *HealthChecker {
+	h := &HealthChecker{
+		manager:  manager,
+		interval: defaultHealthInterval,
+		client:   &http.Client{Timeout: defaultHealthTimeout},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	if healthCfg != nil {
+		if healthCfg.IntervalSeconds > 0 {
+			h.interval = time.Duration(healthCfg.IntervalSeconds) * time.Second
+		}
+		if healthCfg.TimeoutSeconds > 0 {
+			h.client.Timeout = time.Duration(healthCfg.TimeoutSeconds) * time.Second
+		}
+	}
+
+	return h
+}
+
+// Start checks all servers right away and then at every interval in the background
+func (h *HealthChecker) Start() {
+	go h.run()
+}
+
+// Stop halts checking and waits for the background goroutine to exit
+func (h *HealthChecker) Stop() {
+	h.once.Do(func() { close(h.stop) })
+	<-h.done
+}
+
+func (h *HealthChecker) run() {
+	defer close(h.done)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		h.CheckAll()
+
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll checks every server once and records the results with the manager.
+// Checks run without holding the manager lock, so a slow URL does not block changes.
+func (h *HealthChecker) CheckAll() {
+	for _, srv := range h.manager.serversSnapshot() {
+		h.manager.RecordHealth(h.checkServer(srv))
+	}
+}
+
+func (h *HealthChecker) checkServer(srv models.MCPServer) HealthStatus {
+	start := time.Now()
+	status := HealthStatus{Server: srv.Name, CheckedAt: start.UTC()}
+
+	transportType, value, err := detectTransportType(srv.Config)
+	switch {
+	case err != nil:
+		status.Detail = err.Error()
+	case transportType == TransportCommand:
+		if path, err := exec.LookPath(value); err != nil {
+			status.Detail = fmt.Sprintf("command '%s' not found", value)
+		} else {
+			status.Healthy = true
+			status.Detail = path
+		}
+	default:
+		status.Healthy, status.Detail = h.checkURL(value, srv.Config)
+	}
+
+	status.duration = time.Since(start)
+	status.LatencyMs = status.duration.Milliseconds()
+	return status
+}
+
+// checkURL requests the server URL with its configured headers. Only the status
+// line is read, so streaming endpoints do not hold the check open.
+func (h *HealthChecker) checkURL(url string, serverConfig map[string]interface{}) (bool, string) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return false, fmt.Sprintf("invalid URL '%s': %v", url, err)
+	}
+	if headers, ok := serverConfig["headers"].(map[string]interface{}); ok {
+		for key, value := range headers {
+			if s, ok := value.(string); ok {
+				req.Header.Set(key, s)
+			}
+		}
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return false, err.Error()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return false, resp.Status
+	}
+	return true, resp.Status
+}
+
+// serversSnapshot copies the server list, with variables expanded as in client
+// files, so it can be used without the lock
+func (s *MCPManagerService) serversSnapshot() []models.MCPServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vars := newVariables(s.config.Vars, "")
+	servers := make([]models.MCPServer, len(s.config.MCPServers))
+	for i, srv := range s.config.MCPServers {
+		srv.Config = interpolateServerConfig(srv.Config, vars)
+		servers[i] = srv
+	}
+	return servers
+}
+
+// RecordHealth stores a health check result and publishes EventHealthChanged
+// when the server's health differs from the previous check
+func (s *MCPManagerService) RecordHealth(status HealthStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	observeHealth(status)
+	if s.shared.health == nil {
+		s.shared.health = make(map[string]HealthStatus)
+	}
+
+	previous, checked := s.shared.health[status.Server]
+	s.shared.health[status.Server] = status
+
+	if !checked || previous.Healthy != status.Healthy {
+		if !status.Healthy {
+			s.logger().Warn("Server is unhealthy", "server", status.Server, "detail", status.Detail)
+		} else {
+			s.logger().Info("Server is healthy", "server", status.Server, "detail", status.Detail)
+		}
+		s.events.Publish(EventHealthChanged, status)
+	}
+}
+
+// GetHealth returns the latest health check result of every checked server
+func (s *MCPManagerService) GetHealth() map[string]HealthStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	health := make(map[string]HealthStatus, len(s.shared.health))
+	for name, status := range s.shared.health {
+		health[name] = status
+	}
+	return health
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+func TestHealthChecker_CheckServer(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/broken":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/private":
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				t.Errorf("Expected the configured headers to be sent")
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer upstream.Close()
+
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		healthy bool
+	}{
+		{"Command on PATH", map[string]interface{}{"command": "sh"}, true},
+		{"Missing command", map[string]interface{}{"command": "no-such-command-xyz"}, false},
+		{"Reachable URL", map[string]interface{}{"url": upstream.URL + "/sse"}, true},
+		{"Auth error still reachable", map[string]interface{}{
+			"httpUrl": upstream.URL + "/private",
+			"headers": map[string]interface{}{"Authorization": "Bearer secret"},
+		}, true},
+		{"Server error", map[string]interface{}{"httpUrl": upstream.URL + "/broken"}, false},
+		{"Unreachable URL", map[string]interface{}{"url": "http://127.0.0.1:1/sse"}, false},
+	}
+
+	checker := NewHealthChecker(nil, &models.HealthConfig{TimeoutSeconds: 2})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := checker.checkServer(models.MCPServer{Name: "srv", Config: tt.config})
+			if status.Healthy != tt.healthy {
+				t.Errorf("Expected healthy=%v, got %+v", tt.healthy, status)
+			}
+		})
+	}
+}
+
+func TestRecordHealth(t *testing.T) {
+	service, _ := setupDriftTest(t)
+	events, unsubscribe := service.Events().Subscribe()
+	defer unsubscribe()
+
+	service.RecordHealth(HealthStatus{Server: testutil.TestServerName, Healthy: true})
+	if event := nextEvent(t, events); event.Type != EventHealthChanged {
+		t.Errorf("Expected %s for the first result, got %s", EventHealthChanged, event.Type)
+	}
+
+	service.RecordHealth(HealthStatus{Server: testutil.TestServerName, Healthy: true})
+	assertNoEvent(t, events)
+
+	service.RecordHealth(HealthStatus{Server: testutil.TestServerName, Healthy: false, Detail: "down"})
+	event := nextEvent(t, events)
+	if status, _ := event.Data.(HealthStatus); status.Healthy {
+		t.Errorf("Expected an unhealthy status, got %+v", event.Data)
+	}
+
+	if health := service.GetHealth()[testutil.TestServerName]; health.Detail != "down" {
+		t.Errorf("Expected the latest result to be kept, got %+v", health)
+	}
+}
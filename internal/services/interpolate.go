@@ -0,0 +1,131 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+)
+
+// Built-in variables, available besides the ones in the vars section
+const (
+	VarHome      = "HOME"      // The user's home directory
+	VarWorkspace = "workspace" // The project directory when writing project files
+)
+
+// variablePattern matches ${NAME} references in server config values
+var variablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// variableNamePattern limits variable names to what a ${NAME} reference can hold
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// variables holds the values ${NAME} references expand to
+type variables map[string]string
+
+// newVariables returns HOME, the vars section and, for project files, the
+// workspace. Values in the vars section may refer to the built-in variables; a
+// workspace in the vars section is the default for global client files.
+func newVariables(vars map[string]string, workspace string) variables {
+	builtins := make(variables, 2)
+	if home, err := os.UserHomeDir(); err == nil {
+		builtins[VarHome] = home
+	}
+	if workspace != "" {
+		builtins[VarWorkspace] = workspace
+	}
+
+	values := make(variables, len(vars)+len(builtins))
+	for name, value := range vars {
+		values[name] = builtins.expand(value)
+	}
+	for name, value := range builtins {
+		values[name] = value
+	}
+	return values
+}
+
+// expand replaces references to known variables; others (e.g. "${API_KEY}",
+// which clients resolve themselves) are kept as written
+func (v variables) expand(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return variablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if value, known := v[ref[2:len(ref)-1]]; known {
+			return value
+		}
+		return ref
+	})
+}
+
+// expandPath expands variables and a leading "~"
+func (v variables) expandPath(s string) string {
+	s = v.expand(s)
+	if s == "~" || strings.HasPrefix(s, "~/") {
+		return config.ExpandPath(s)
+	}
+	return s
+}
+
+// interpolateServerConfig returns a copy of a server config with variables
+// expanded in command, args, cwd, url, httpUrl and env values, and a leading "~"
+// in command and cwd only: an argument starting with "~" may be meant literally.
+// Other fields are shared with the original.
+func interpolateServerConfig(serverConfig map[string]interface{}, vars variables) map[string]interface{} {
+	interpolated := make(map[string]interface{}, len(serverConfig))
+	for key, value := range serverConfig {
+		interpolated[key] = value
+	}
+
+	for _, key := range []string{"command", "cwd"} {
+		if s, ok := serverConfig[key].(string); ok {
+			interpolated[key] = vars.expandPath(s)
+		}
+	}
+	for _, key := range []string{"url", "httpUrl"} {
+		if s, ok := serverConfig[key].(string); ok {
+			interpolated[key] = vars.expand(s)
+		}
+	}
+
+	if args, ok := serverConfig["args"].([]interface{}); ok {
+		expanded := make([]interface{}, len(args))
+		for i, arg := range args {
+			if s, ok := arg.(string); ok {
+				expanded[i] = vars.expand(s)
+			} else {
+				expanded[i] = arg
+			}
+		}
+		interpolated["args"] = expanded
+	}
+
+	if env, ok := serverConfig["env"].(map[string]interface{}); ok {
+		expanded := make(map[string]interface{}, len(env))
+		for name, value := range env {
+			if s, ok := value.(string); ok {
+				expanded[name] = vars.expand(s)
+			} else {
+				expanded[name] = value
+			}
+		}
+		interpolated["env"] = expanded
+	}
+
+	return interpolated
+}
+
+// validateVars checks the names of the vars section; HOME is built in
+func validateVars(vars map[string]string) error {
+	for name := range vars {
+		if !variableNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid variable name '%s'", name)
+		}
+		if name == VarHome {
+			return fmt.Errorf("variable '%s' is built in and cannot be set in vars", name)
+		}
+	}
+	return nil
+}
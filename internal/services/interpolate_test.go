@@ -0,0 +1,113 @@
+package services
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+func TestInterpolateServerConfig(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("No home directory")
+	}
+
+	vars := newVariables(map[string]string{"code": "${HOME}/code", "workspace": "/default"}, "/work/repo")
+	serverConfig := map[string]interface{}{
+		"command": "~/bin/server",
+		"args":    []interface{}{"--root", "${workspace}", "${code}/lib", "${API_KEY}", "~/literal", 3},
+		"cwd":     "${code}",
+		"env":     map[string]interface{}{"DATA": "${HOME}/data", "TOKEN": "${TOKEN}"},
+		"headers": map[string]interface{}{"X-Dir": "${code}"},
+	}
+
+	got := interpolateServerConfig(serverConfig, vars)
+	want := map[string]interface{}{
+		"command": filepath.Join(home, "bin/server"),
+		"args":    []interface{}{"--root", "/work/repo", home + "/code/lib", "${API_KEY}", "~/literal", 3},
+		"cwd":     home + "/code",
+		"env":     map[string]interface{}{"DATA": home + "/data", "TOKEN": "${TOKEN}"},
+		"headers": map[string]interface{}{"X-Dir": "${code}"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected interpolation:\n got %v\nwant %v", got, want)
+	}
+	if serverConfig["cwd"] != "${code}" || serverConfig["args"].([]interface{})[1] != "${workspace}" {
+		t.Error("Interpolation changed the original config")
+	}
+
+	// Outside a project the workspace from vars is used
+	global := interpolateServerConfig(map[string]interface{}{"url": "http://${host}/${workspace}"}, newVariables(map[string]string{"workspace": "/default"}, ""))
+	if global["url"] != "http://${host}//default" {
+		t.Errorf("Unexpected url: %v", global["url"])
+	}
+}
+
+func TestInterpolation_ClientAndProjectFiles(t *testing.T) {
+	service, projectDir := setupProjectTest(t, "claude_code")
+	cfg := service.config
+	cfg.Vars = map[string]string{"workspace": "/default", "data": "/srv/data"}
+	cfg.MCPServers = append(cfg.MCPServers, models.MCPServer{Name: "vars", Config: map[string]interface{}{
+		"command": "echo",
+		"args":    []interface{}{"${workspace}", "${data}", "${API_KEY}"},
+	}})
+	clientPath := cfg.Clients[testutil.TestClientName].ConfigPath
+
+	if err := service.ToggleClientMCPServer(testutil.TestClientName, "vars", true); err != nil {
+		t.Fatalf("ToggleClientMCPServer failed: %v", err)
+	}
+	entry := readServersKey(t, clientPath, "mcpServers")["vars"].(map[string]interface{})
+	if args := entry["args"]; !reflect.DeepEqual(args, []interface{}{"/default", "/srv/data", "${API_KEY}"}) {
+		t.Errorf("Unexpected args in the client file: %v", args)
+	}
+
+	if _, err := service.ToggleProjectServer("repo", "vars", true, false); err != nil {
+		t.Fatalf("ToggleProjectServer failed: %v", err)
+	}
+	entry = readServersKey(t, filepath.Join(projectDir, ".mcp.json"), "mcpServers")["vars"].(map[string]interface{})
+	if args := entry["args"]; !reflect.DeepEqual(args, []interface{}{projectDir, "/srv/data", "${API_KEY}"}) {
+		t.Errorf("Unexpected args in the project file: %v", args)
+	}
+
+	// Expanded entries are what config.yaml asks for, not drift
+	events, err := service.DetectClientDrift(testutil.TestClientName)
+	if err != nil {
+		t.Fatalf("DetectClientDrift failed: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Expected no drift, got %+v", events)
+	}
+}
+
+func TestValidateInterpolatedConfig(t *testing.T) {
+	echoPath, err := exec.LookPath("echo")
+	if err != nil {
+		t.Skip("echo not found")
+	}
+
+	validator := NewValidatorService()
+	validator.SetVars(map[string]string{"bin": filepath.Dir(echoPath), "api": testutil.TestExampleURL})
+
+	valid := []map[string]interface{}{
+		{"command": "${bin}/echo"},
+		{"command": "${workspace}/run.sh"}, // Only known once written to a project
+		{"httpUrl": "${api}/mcp"},
+	}
+	for _, serverConfig := range valid {
+		if err := validator.ValidateMCPServerConfig("srv", serverConfig); err != nil {
+			t.Errorf("Expected %v to be valid, got %v", serverConfig, err)
+		}
+	}
+
+	validator.SetValidation(&models.ValidationConfig{MissingCommands: models.SeverityError})
+	err = validator.ValidateMCPServerConfig("srv", map[string]interface{}{"command": "${bin}/missing-command"})
+	testutil.AssertErrorContains(t, err, "not found in PATH")
+
+	testutil.AssertErrorContains(t, validateVars(map[string]string{"HOME": "/tmp"}), "built in")
+	testutil.AssertErrorContains(t, validateVars(map[string]string{"my-var": "x"}), "invalid variable name")
+}
@@ -1,7 +1,14 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/vlazic/mcp-server-manager/internal/config"
 	"github.com/vlazic/mcp-server-manager/internal/models"
@@ -12,29 +19,153 @@ type MCPManagerService struct {
 	clientConfigService *ClientConfigService
 	validator           *ValidatorService
 	configPath          string
+
+	// actor is credited with changes in the audit log. WithActor returns a copy
+	// that shares everything else, so mutable state must live behind pointers.
+	actor Actor
+
+	// mu serializes mutations; the client watcher runs in its own goroutine
+	mu       *sync.Mutex
+	shared   *sharedState
+	auditLog *auditLog
+	repo     *configRepo // Set when git versioning is enabled
+	events   *EventBus
+	catalog  *CatalogService
+	oauth    *OAuthService
+}
+
+// sharedState is the mutable state common to every actor's view of the manager
+type sharedState struct {
+	driftEvents []DriftEvent
+
+	saved      []byte     // config.yaml content as last written
+	undo, redo []undoStep // Oldest first
+
+	health map[string]HealthStatus // Latest health check result per server
 }
 
 func NewMCPManagerService(cfg *models.Config, configPath string) *MCPManagerService {
-	return &MCPManagerService{
+	validator := NewValidatorService()
+	validator.SetPolicy(cfg.Policy)
+	validator.SetVars(cfg.Vars)
+	validator.SetValidation(cfg.Validation)
+
+	auditDir := filepath.Dir(config.DefaultConfigPath)
+	if configPath != "" {
+		auditDir = filepath.Dir(configPath)
+	}
+
+	saved, err := config.MarshalConfig(cfg)
+	if err != nil {
+		slog.Warn("Undo history unavailable for the initial config", "error", err)
+	}
+
+	oauth := NewOAuthService(filepath.Join(auditDir, OAuthTokenFile))
+	clientConfigService := NewClientConfigService(cfg)
+	clientConfigService.oauth = oauth
+
+	s := &MCPManagerService{
 		config:              cfg,
-		clientConfigService: NewClientConfigService(cfg),
-		validator:           NewValidatorService(),
+		clientConfigService: clientConfigService,
+		validator:           validator,
 		configPath:          configPath,
+		actor:               Actor{Source: SourceCLI},
+		mu:                  &sync.Mutex{},
+		shared:              &sharedState{saved: saved},
+		auditLog:            newAuditLog(filepath.Join(auditDir, AuditLogFile), cfg),
+		events:              NewEventBus(),
+		catalog:             NewCatalogService(cfg.Catalog, configPath),
+		oauth:               oauth,
 	}
+
+	if cfg.Git != nil && cfg.Git.Enabled && configPath != "" {
+		repo, err := openConfigRepo(configPath, cfg.Git, cfg)
+		if err != nil {
+			slog.Warn("Git versioning disabled", "path", configPath, "error", err)
+		} else {
+			s.repo = repo
+		}
+	}
+
+	return s
+}
+
+// WithActor returns a view of the manager whose changes are credited to actor
+// in the audit log
+func (s *MCPManagerService) WithActor(actor Actor) *MCPManagerService {
+	view := *s
+	view.actor = actor
+	view.clientConfigService = s.clientConfigService.withLogger(view.logger())
+	return &view
+}
+
+// logger returns the default logger annotated with the actor, so service logs
+// can be matched with the request that caused them
+func (s *MCPManagerService) logger() *slog.Logger {
+	logger := slog.Default().With("actor", s.actor.Source)
+	if s.actor.RequestID != "" {
+		logger = logger.With("request_id", s.actor.RequestID)
+	}
+	return logger
 }
 
-// GetMCPServers returns the ordered server slice
+// GetAuditLog returns up to limit audit entries, newest first (all when limit <= 0)
+func (s *MCPManagerService) GetAuditLog(limit int) ([]AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.auditLog.read(limit)
+}
+
+// audit records an action in the audit log and the operation metrics; callers
+// hold s.mu and defer it with a pointer to their named error result
+func (s *MCPManagerService) audit(action, target string, errp *error) {
+	observeOperation(action, *errp)
+	if *errp != nil {
+		s.logger().Warn("Operation failed", "operation", action, "target", target, "error", *errp)
+	} else {
+		s.logger().Info("Operation completed", "operation", action, "target", target)
+	}
+
+	if err := s.auditLog.record(s.actor, action, target, s.config, *errp); err != nil {
+		s.logger().Warn("Failed to write audit log", "path", s.auditLog.path, "error", err)
+	}
+}
+
+// Events returns the bus on which changes are published
+func (s *MCPManagerService) Events() *EventBus {
+	return s.events
+}
+
+// publish sends an event once the action it describes has succeeded; callers
+// defer it with a pointer to their named error result
+func (s *MCPManagerService) publish(eventType string, data interface{}, errp *error) {
+	if *errp == nil {
+		s.events.Publish(eventType, data)
+	}
+}
+
+// GetMCPServers returns a copy of the ordered server slice
 func (s *MCPManagerService) GetMCPServers() []models.MCPServer {
-	return s.config.MCPServers
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyServers(s.config.MCPServers)
 }
 
-// GetClients returns the client map
+// GetClients returns a copy of the client map
 func (s *MCPManagerService) GetClients() map[string]*models.Client {
-	return s.config.Clients
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyClients(s.config.Clients)
 }
 
 // ToggleClientMCPServer enables or disables a server for a specific client
-func (s *MCPManagerService) ToggleClientMCPServer(clientName, serverName string, enabled bool) error {
+func (s *MCPManagerService) ToggleClientMCPServer(clientName, serverName string, enabled bool) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("toggle", clientName+"/"+serverName, &err)
+	defer s.publish(EventServerToggled, ServerToggledData{Clients: []string{clientName}, Servers: []string{serverName}, Enabled: enabled}, &err)
+
 	// Validate client exists
 	client, exists := s.config.Clients[clientName]
 	if !exists {
@@ -77,49 +208,133 @@ func (s *MCPManagerService) serverExists(serverName string) bool {
 	return false
 }
 
-// GetServerStatus returns server configuration by name
+// GetServerStatus returns a copy of a server's configuration by name
 func (s *MCPManagerService) GetServerStatus(serverName string) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	for _, srv := range s.config.MCPServers {
 		if srv.Name == serverName {
-			return srv.Config, nil
+			return copyConfigMap(srv.Config), nil
 		}
 	}
 	return nil, fmt.Errorf("MCP server '%s' not found", serverName)
 }
 
 // SyncAllClients synchronizes all client configurations based on enabled lists
-func (s *MCPManagerService) SyncAllClients() error {
-	for clientName, client := range s.config.Clients {
-		// Build set of enabled servers for quick lookup
-		enabledSet := make(map[string]bool)
-		for _, serverName := range client.Enabled {
-			enabledSet[serverName] = true
-		}
+func (s *MCPManagerService) SyncAllClients() (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("sync", "", &err)
+	defer s.publish(EventSynced, SyncedData{Clients: s.clientNames()}, &err)
+
+	return s.syncAllClients()
+}
 
-		// Sync each server in the config
-		for _, srv := range s.config.MCPServers {
-			enabled := enabledSet[srv.Name]
-			if err := s.clientConfigService.UpdateMCPServerStatus(clientName, srv.Name, enabled); err != nil {
-				return fmt.Errorf("failed to sync client '%s': %w", clientName, err)
-			}
+// clientNames returns the sorted names of all clients
+func (s *MCPManagerService) clientNames() []string {
+	names := make([]string, 0, len(s.config.Clients))
+	for name := range s.config.Clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *MCPManagerService) syncAllClients() error {
+	for clientName := range s.config.Clients {
+		// One read and one write per client, whatever the number of servers
+		if err := s.clientConfigService.SyncClient(clientName); err != nil {
+			return fmt.Errorf("failed to sync client '%s': %w", clientName, err)
 		}
 	}
 	return nil
 }
 
+// GetConfig returns a copy of the configuration
 func (s *MCPManagerService) GetConfig() *models.Config {
-	return s.config
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyConfig(s.config)
+}
+
+// ConfigLayers lists the files the configuration is merged from and the file
+// each value comes from
+func (s *MCPManagerService) ConfigLayers() (*config.ConfigLayers, error) {
+	path := s.configPath
+	if path == "" {
+		path = config.DefaultConfigPath
+	}
+	return config.ReadConfigLayers(path)
+}
+
+// CheckConfigFile reports every problem in the config file and its overlays as
+// currently on disk, with locations
+func (s *MCPManagerService) CheckConfigFile() ([]config.Diagnostic, error) {
+	path := s.configPath
+	if path == "" {
+		path = config.DefaultConfigPath
+	}
+	s.mu.Lock()
+	validator := *s.validator
+	s.mu.Unlock()
+	return validator.ValidateConfigFile(path)
 }
 
 func (s *MCPManagerService) ValidateConfig() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.validator.ValidateConfig(s.config)
 }
 
-// AddServer adds a new MCP server to the configuration
+// CheckConfig returns every problem in the current configuration, including
+// warnings and infos that do not block saving
+func (s *MCPManagerService) CheckConfig() []ConfigProblem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.validator.CheckConfig(s.config)
+}
+
+// ServerProblems groups the problems of the current configuration by server name
+func (s *MCPManagerService) ServerProblems() map[string][]ConfigProblem {
+	problems := make(map[string][]ConfigProblem)
+	for _, problem := range s.CheckConfig() {
+		if name := strings.TrimPrefix(problem.Path, "mcpServers."); name != problem.Path {
+			problems[name] = append(problems[name], problem)
+		}
+	}
+	return problems
+}
+
+// AddServer adds a new MCP server to the configuration. Commands outside the
+// policy allowlist are refused with an error wrapping ErrConfirmationRequired.
 func (s *MCPManagerService) AddServer(serverName string, serverConfig map[string]interface{}) error {
+	return s.addServer(serverName, serverConfig, false)
+}
+
+// AddConfirmedServer adds a server whose command the user has confirmed, so only
+// the allowlist is waived; denied arguments are still rejected
+func (s *MCPManagerService) AddConfirmedServer(serverName string, serverConfig map[string]interface{}) error {
+	return s.addServer(serverName, serverConfig, true)
+}
+
+func (s *MCPManagerService) addServer(serverName string, serverConfig map[string]interface{}, confirmed bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addServerLocked(serverName, serverConfig, confirmed)
+}
+
+// addServerLocked adds a server; the caller holds s.mu
+func (s *MCPManagerService) addServerLocked(serverName string, serverConfig map[string]interface{}, confirmed bool) (err error) {
+	defer s.audit("add_server", serverName, &err)
+	defer s.publish(EventServerAdded, ServerAddedData{Server: serverName}, &err)
+
 	// Validate the server config
 	if err := s.validator.ValidateMCPServerConfig(serverName, serverConfig); err != nil {
-		return fmt.Errorf("server validation failed: %w", err)
+		if !confirmed || !errors.Is(err, ErrConfirmationRequired) {
+			return fmt.Errorf("server validation failed: %w", err)
+		}
 	}
 
 	// Check if server with this name already exists
@@ -129,19 +344,111 @@ func (s *MCPManagerService) AddServer(serverName string, serverConfig map[string
 		}
 	}
 
-	// Add the server to the config (appends to end)
-	s.config.MCPServers = append(s.config.MCPServers, models.MCPServer{
-		Name:   serverName,
-		Config: serverConfig,
-	})
+	// Tags and the template reference are manager metadata, not part of the
+	// passthrough config. Add the server to the config (appends to end).
+	s.config.MCPServers = append(s.config.MCPServers, config.NewServer(serverName, serverConfig))
 
 	// Save the config
 	return s.saveConfig()
 }
 
+// ReorderServers rearranges the servers to match the given list, which must name
+// every server exactly once, then rewrites all client files in the new order
+func (s *MCPManagerService) ReorderServers(order []string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("reorder_servers", "", &err)
+	defer s.publish(EventConfigReloaded, ConfigReloadedData{Reason: "reorder_servers"}, &err)
+
+	if len(order) != len(s.config.MCPServers) {
+		return fmt.Errorf("order must list all %d servers, got %d", len(s.config.MCPServers), len(order))
+	}
+
+	byName := make(map[string]models.MCPServer, len(s.config.MCPServers))
+	for _, srv := range s.config.MCPServers {
+		byName[srv.Name] = srv
+	}
+
+	reordered := make([]models.MCPServer, 0, len(order))
+	for _, name := range order {
+		srv, exists := byName[name]
+		if !exists {
+			return fmt.Errorf("MCP server '%s' not found or listed twice", name)
+		}
+		reordered = append(reordered, srv)
+		delete(byName, name)
+	}
+
+	s.config.MCPServers = reordered
+	if err := s.saveConfig(); err != nil {
+		return err
+	}
+
+	return s.syncAllClients()
+}
+
 func (s *MCPManagerService) saveConfig() error {
-	if err := s.ValidateConfig(); err != nil {
+	return s.saveConfigWithMessage("")
+}
+
+// saveConfigWithMessage saves config.yaml and records the previous state for undo
+func (s *MCPManagerService) saveConfigWithMessage(message string) error {
+	before := s.shared.saved
+	if err := s.writeConfig(message); err != nil {
+		return err
+	}
+
+	s.recordUndo(before)
+	return nil
+}
+
+// saveConfigData writes data, which must parse to the current configuration, to
+// config.yaml as it is and records the previous state for undo
+func (s *MCPManagerService) saveConfigData(data []byte, message string) error {
+	if err := s.validator.ValidateConfig(s.config); err != nil {
+		return fmt.Errorf("config validation failed: %w", err)
+	}
+
+	path := s.configPath
+	if path == "" {
+		path = config.DefaultConfigPath
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	s.logger().Debug("Wrote config", "path", path)
+
+	before := s.shared.saved
+	s.shared.saved = data
+	s.commitConfig(message)
+	s.recordUndo(before)
+	return nil
+}
+
+// writeConfig validates and writes config.yaml and, with git versioning, commits
+// it with the given message or one generated from the change
+func (s *MCPManagerService) writeConfig(message string) error {
+	if err := s.validator.ValidateConfig(s.config); err != nil {
 		return fmt.Errorf("config validation failed: %w", err)
 	}
-	return config.SaveConfig(s.config, s.configPath)
+	if err := config.SaveConfig(s.config, s.configPath); err != nil {
+		return err
+	}
+	s.logger().Debug("Wrote config", "path", s.configPath)
+
+	if data, err := config.MarshalConfig(s.config); err == nil {
+		s.shared.saved = data
+	}
+
+	s.commitConfig(message)
+	return nil
+}
+
+// commitConfig commits config.yaml when git versioning is enabled
+func (s *MCPManagerService) commitConfig(message string) {
+	if s.repo != nil {
+		if err := s.repo.commitChange(s.config, message); err != nil {
+			s.logger().Warn("Failed to commit config", "path", s.configPath, "error", err)
+		}
+	}
 }
\ No newline at end of file
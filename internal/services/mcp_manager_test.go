@@ -3,6 +3,7 @@ package services
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/vlazic/mcp-server-manager/internal/config"
@@ -27,9 +28,8 @@ import (
 // Test isolation: Each sub-test creates fresh Config instances to prevent state pollution
 // across test runs. This ensures tests can run independently and in any order.
 //
-// KNOWN LIMITATIONS:
-// - Order preservation is only verified for LoadConfig, not save/reload cycles
-// - See TestOrderPreservation_MultipleServers for documented SaveConfig limitation
+// Order preservation is verified through save/reload cycles as well as LoadConfig
+// (see TestSaveConfig_Integration and TestOrderPreservation_MultipleServers).
 
 func TestNewMCPManagerService(t *testing.T) {
 	cfg := &models.Config{
@@ -76,6 +76,12 @@ func TestGetMCPServers(t *testing.T) {
 	if servers[0].Name != "server1" {
 		t.Errorf("Expected first server 'server1', got '%s'", servers[0].Name)
 	}
+
+	// The result is a copy; changing it leaves the service untouched
+	servers[0].Config["command"] = "rm"
+	if command := service.GetMCPServers()[0].Config["command"]; command != "echo" {
+		t.Errorf("Changing the returned servers changed the config: command is %v", command)
+	}
 }
 
 func TestGetClients(t *testing.T) {
@@ -97,6 +103,11 @@ func TestGetClients(t *testing.T) {
 	if _, exists := clients["client1"]; !exists {
 		t.Error("client1 not found")
 	}
+
+	clients["client1"].Enabled = append(clients["client1"].Enabled, "server1")
+	if enabled := service.GetClients()["client1"].Enabled; len(enabled) != 0 {
+		t.Errorf("Changing the returned clients changed the config: enabled is %v", enabled)
+	}
 }
 
 // setupToggleTest creates a test environment for toggle tests
@@ -435,8 +446,8 @@ func TestGetConfig(t *testing.T) {
 	service := NewMCPManagerService(cfg, "")
 	retrievedCfg := service.GetConfig()
 
-	if retrievedCfg != cfg {
-		t.Error("GetConfig did not return the same config instance")
+	if retrievedCfg == cfg {
+		t.Error("GetConfig returned the live config instead of a copy")
 	}
 
 	if retrievedCfg.ServerPort != 8080 {
@@ -498,18 +509,13 @@ func TestSaveConfig_Integration(t *testing.T) {
 		t.Error("another-server not found after reload")
 	}
 
-	// LIMITATION: Order verification disabled due to known issue in SaveConfig
-	// SaveConfig uses map[string]interface{} which loses order during iteration
-	// See config/loader.go:240-245
-	// TODO: Fix SaveConfig to use yaml.MapSlice or preserve order via Node API
-	//
-	// Expected behavior (currently broken):
-	// if loadedCfg.MCPServers[0].Name != testutil.TestServerName {
-	//     t.Errorf("Order not preserved: expected 'test-server' first, got '%s'", loadedCfg.MCPServers[0].Name)
-	// }
-	// if loadedCfg.MCPServers[1].Name != "another-server" {
-	//     t.Errorf("Order not preserved: expected 'another-server' second, got '%s'", loadedCfg.MCPServers[1].Name)
-	// }
+	// SaveConfig writes mcpServers as an ordered YAML node, so order survives the round trip
+	if loadedCfg.MCPServers[0].Name != testutil.TestServerName {
+		t.Errorf("Order not preserved: expected 'test-server' first, got '%s'", loadedCfg.MCPServers[0].Name)
+	}
+	if loadedCfg.MCPServers[1].Name != "another-server" {
+		t.Errorf("Order not preserved: expected 'another-server' second, got '%s'", loadedCfg.MCPServers[1].Name)
+	}
 }
 
 func TestOrderPreservation_MultipleServers(t *testing.T) {
@@ -561,7 +567,6 @@ clients:
 	}
 
 	// Now test that SaveConfig + LoadConfig round-trip preserves order
-	// NOTE: This test documents the current limitation - it will likely fail
 	service := NewMCPManagerService(cfg, configPath)
 
 	// Force a save
@@ -581,15 +586,111 @@ clients:
 		t.Logf("  [%d] %s", i, srv.Name)
 	}
 
-	// NOTE: This assertion is commented out because SaveConfig doesn't preserve order
-	// Uncomment after fixing SaveConfig to use yaml.MapSlice or Node API
-	//
 	// Expected order after append: server-c, server-a, server-b, server-d
-	// expectedAfterSave := []string{"server-c", "server-a", "server-b", "server-d"}
-	// for i, expected := range expectedAfterSave {
-	//     if reloadedCfg.MCPServers[i].Name != expected {
-	//         t.Errorf("After save - Server[%d]: expected %s, got %s",
-	//                  i, expected, reloadedCfg.MCPServers[i].Name)
-	//     }
-	// }
-}
\ No newline at end of file
+	expectedAfterSave := []string{"server-c", "server-a", "server-b", "server-d"}
+	if len(reloadedCfg.MCPServers) != len(expectedAfterSave) {
+		t.Fatalf("Expected %d servers after save, got %d", len(expectedAfterSave), len(reloadedCfg.MCPServers))
+	}
+	for i, expected := range expectedAfterSave {
+		if reloadedCfg.MCPServers[i].Name != expected {
+			t.Errorf("After save - Server[%d]: expected %s, got %s",
+				i, expected, reloadedCfg.MCPServers[i].Name)
+		}
+	}
+}
+func TestReorderServers(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, testutil.TestConfigYAML)
+	clientPath := filepath.Join(tempDir, testutil.TestClientJSON)
+
+	// An unmanaged entry must stay in the file, after the managed ones
+	testutil.WriteTestFile(t, clientPath, `{"mcpServers": {"aaa-unmanaged": {"command": "other"}}}`)
+
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{
+			{Name: "server-a", Config: map[string]interface{}{"command": "echo"}},
+			{Name: "server-b", Config: map[string]interface{}{"command": "echo"}},
+			{Name: "server-c", Config: map[string]interface{}{"command": "echo"}},
+		},
+		Clients: map[string]*models.Client{
+			testutil.TestClientName: {ConfigPath: clientPath, Enabled: []string{"server-a", "server-b", "server-c"}},
+		},
+	}
+
+	service := NewMCPManagerService(cfg, configPath)
+
+	t.Run("Rejects incomplete or invalid orders", func(t *testing.T) {
+		invalid := [][]string{
+			{"server-c", "server-a"},
+			{"server-c", "server-a", "server-a"},
+			{"server-c", "server-a", "missing"},
+		}
+		for _, order := range invalid {
+			if err := service.ReorderServers(order); err == nil {
+				t.Errorf("Expected error for order %v", order)
+			}
+		}
+		if cfg.MCPServers[0].Name != "server-a" {
+			t.Error("Invalid order modified the config")
+		}
+	})
+
+	t.Run("Saves config and writes client files in the new order", func(t *testing.T) {
+		order := []string{"server-c", "server-a", "server-b"}
+		if err := service.ReorderServers(order); err != nil {
+			t.Fatalf("ReorderServers failed: %v", err)
+		}
+
+		reloadedCfg, _, err := config.LoadConfig(configPath)
+		if err != nil {
+			t.Fatalf("Failed to reload config: %v", err)
+		}
+		for i, name := range order {
+			if reloadedCfg.MCPServers[i].Name != name {
+				t.Errorf("Server[%d]: expected %s, got %s", i, name, reloadedCfg.MCPServers[i].Name)
+			}
+		}
+
+		data, err := os.ReadFile(clientPath)
+		if err != nil {
+			t.Fatalf("Failed to read client config: %v", err)
+		}
+		content := string(data)
+
+		expected := []string{`"server-c"`, `"server-a"`, `"server-b"`, `"aaa-unmanaged"`}
+		last := -1
+		for _, key := range expected {
+			index := strings.Index(content, key)
+			if index < 0 {
+				t.Fatalf("Client file is missing %s:\n%s", key, content)
+			}
+			if index < last {
+				t.Errorf("Client file entries out of order, expected %v:\n%s", expected, content)
+				break
+			}
+			last = index
+		}
+	})
+}
+
+func TestToggleClientMCPServer_MissingCommandElsewhere(t *testing.T) {
+	service, cfg, _ := setupToggleTest(t, []string{})
+	cfg.MCPServers = append(cfg.MCPServers, models.MCPServer{
+		Name:   "not-installed",
+		Config: map[string]interface{}{"command": "nonexistent-command-xyz"},
+	})
+
+	// A server whose command is missing on this machine does not block others
+	if err := service.ToggleClientMCPServer(testutil.TestClientName, testutil.TestServerName, true); err != nil {
+		t.Fatalf("ToggleClientMCPServer failed: %v", err)
+	}
+	if err := service.ToggleClientMCPServer(testutil.TestClientName, "not-installed", true); err != nil {
+		t.Fatalf("ToggleClientMCPServer of the missing command failed: %v", err)
+	}
+
+	problems := service.ServerProblems()
+	if len(problems) != 1 || len(problems["not-installed"]) != 1 || problems["not-installed"][0].Severity != models.SeverityWarning {
+		t.Errorf("Expected one warning for not-installed, got %+v", problems)
+	}
+}
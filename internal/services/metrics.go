@@ -0,0 +1,53 @@
+package services
+
+import "github.com/vlazic/mcp-server-manager/internal/metrics"
+
+// Metrics exported on /metrics. Operations are the audited manager actions
+// (toggle, sync, add_server, ...); client files are labeled by path because
+// project files have no client name.
+var (
+	operationsTotal = metrics.Default.NewCounterVec("mcp_manager_operations_total",
+		"Manager operations by action.", "operation")
+	operationFailuresTotal = metrics.Default.NewCounterVec("mcp_manager_operation_failures_total",
+		"Manager operations that returned an error, by action.", "operation")
+
+	clientFileWriteSeconds = metrics.Default.NewHistogramVec("mcp_manager_client_file_write_duration_seconds",
+		"Time taken to back up and write a client or project config file.", metrics.DefaultBuckets, "path")
+	clientFileWriteFailuresTotal = metrics.Default.NewCounterVec("mcp_manager_client_file_write_failures_total",
+		"Client or project config file writes that failed.", "path")
+	backupsTotal = metrics.Default.NewCounterVec("mcp_manager_backups_total",
+		"Backups made before rewriting a client or project config file.", "path")
+	backupBytesTotal = metrics.Default.NewCounterVec("mcp_manager_backup_bytes_total",
+		"Bytes written to client and project config backups.", "path")
+
+	serverUp = metrics.Default.NewGaugeVec("mcp_manager_server_up",
+		"Whether the latest health check of a server passed (1) or failed (0).", "server")
+	healthChecksTotal = metrics.Default.NewCounterVec("mcp_manager_health_checks_total",
+		"Health checks by server and result (healthy or unhealthy).", "server", "result")
+	healthCheckSeconds = metrics.Default.NewHistogramVec("mcp_manager_health_check_duration_seconds",
+		"Time taken by a health check.", metrics.DefaultBuckets, "server")
+
+	driftEventsTotal = metrics.Default.NewCounterVec("mcp_manager_drift_events_total",
+		"Drifted server entries detected in client files, by client and kind.", "client", "kind")
+)
+
+// observeOperation counts a manager action and whether it failed
+func observeOperation(action string, err error) {
+	operationsTotal.Inc(action)
+	if err != nil {
+		operationFailuresTotal.Inc(action)
+	}
+}
+
+// observeHealth records a health check result
+func observeHealth(status HealthStatus) {
+	result := "unhealthy"
+	up := 0.0
+	if status.Healthy {
+		result = "healthy"
+		up = 1
+	}
+	serverUp.Set(up, status.Server)
+	healthChecksTotal.Inc(status.Server, result)
+	healthCheckSeconds.Observe(status.duration.Seconds(), status.Server)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/metrics"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+// metricsText returns the current exposition of the default registry
+func metricsText(t *testing.T) string {
+	t.Helper()
+	var out strings.Builder
+	if err := metrics.Default.WriteText(&out); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	return out.String()
+}
+
+func TestManagerMetrics(t *testing.T) {
+	service, clientConfigPath := setupDriftTest(t)
+
+	if err := service.ToggleClientMCPServer(testutil.TestClientName, "disabled-server", true); err != nil {
+		t.Fatalf("ToggleClientMCPServer failed: %v", err)
+	}
+	service.ToggleClientMCPServer(testutil.TestClientName, "missing", true)
+	service.RecordDrift([]DriftEvent{{Client: testutil.TestClientName, Server: "disabled-server", Kind: DriftMissing}})
+	service.RecordHealth(HealthStatus{Server: testutil.TestServerName, Healthy: false})
+
+	text := metricsText(t)
+	for _, want := range []string{
+		`mcp_manager_operations_total{operation="toggle"}`,
+		`mcp_manager_operation_failures_total{operation="toggle"}`,
+		`mcp_manager_client_file_write_duration_seconds_count{path="` + clientConfigPath + `"}`,
+		`mcp_manager_backups_total{path="` + clientConfigPath + `"}`,
+		`mcp_manager_backup_bytes_total{path="` + clientConfigPath + `"}`,
+		`mcp_manager_drift_events_total{client="` + testutil.TestClientName + `",kind="missing"}`,
+		`mcp_manager_server_up{server="` + testutil.TestServerName + `"} 0`,
+		`mcp_manager_health_checks_total{server="` + testutil.TestServerName + `",result="unhealthy"}`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected metrics to contain %s", want)
+		}
+	}
+}
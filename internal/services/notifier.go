@@ -0,0 +1,272 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// Webhook request headers. The signature is "sha256=" followed by the hex
+// HMAC-SHA256 of the body, keyed with the webhook secret.
+const (
+	WebhookEventHeader     = "X-MCP-Event"
+	WebhookSignatureHeader = "X-MCP-Signature-256"
+)
+
+const (
+	defaultWebhookRetries = 3
+	defaultWebhookBackoff = time.Second // Doubled after every failed attempt
+	webhookTimeout        = 10 * time.Second
+	desktopNotifyCommand  = "notify-send"
+)
+
+// defaultDesktopEvents are shown on the desktop when no event filter is configured
+var defaultDesktopEvents = []string{EventHealthChanged, EventDriftDetected}
+
+// Notifier forwards events from the manager's event bus to webhooks and desktop
+// notifications. Deliveries run in the background, so a slow or failing webhook
+// never holds up the manager.
+type Notifier struct {
+	events   *EventBus
+	webhooks []models.WebhookConfig
+	desktop  *models.DesktopNotifyConfig
+
+	client         *http.Client
+	backoff        time.Duration
+	desktopCommand string
+
+	// failing remembers unhealthy servers so that recoveries are reported but
+	// the first healthy result of every server is not
+	failing map[string]bool
+
+	stop       chan struct{}
+	done       chan struct{}
+	once       sync.Once
+	deliveries sync.WaitGroup
+}
+
+// NewNotifier creates a notifier for the configured webhooks and desktop notifications
+func NewNotifier(events *EventBus, cfg *models.NotificationsConfig) *Notifier {
+	n := &Notifier{
+		events:         events,
+		client:         &http.Client{Timeout: webhookTimeout},
+		backoff:        defaultWebhookBackoff,
+		desktopCommand: desktopNotifyCommand,
+		failing:        make(map[string]bool),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+
+	if cfg != nil {
+		n.webhooks = cfg.Webhooks
+		if cfg.Desktop != nil && cfg.Desktop.Enabled {
+			n.desktop = cfg.Desktop
+		}
+	}
+
+	return n
+}
+
+// Start subscribes to the event bus and forwards events in the background
+func (n *Notifier) Start() {
+	if n.desktop != nil {
+		if _, err := exec.LookPath(n.desktopCommand); err != nil {
+			slog.Warn("Desktop notifications need notify-send (libnotify)", "command", n.desktopCommand, "error", err)
+		}
+	}
+
+	events, unsubscribe := n.events.Subscribe()
+	go n.run(events, unsubscribe)
+}
+
+// Stop halts forwarding, abandons pending retries and waits for running deliveries
+func (n *Notifier) Stop() {
+	n.once.Do(func() { close(n.stop) })
+	<-n.done
+	n.deliveries.Wait()
+}
+
+func (n *Notifier) run(events <-chan Event, unsubscribe func()) {
+	defer close(n.done)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-n.stop:
+			return
+		case event := <-events:
+			n.handle(event)
+		}
+	}
+}
+
+// handle sends one event to every matching target
+func (n *Notifier) handle(event Event) {
+	if !n.worthNotifying(event) {
+		return
+	}
+
+	for _, webhook := range n.webhooks {
+		if len(webhook.Events) > 0 && !contains(webhook.Events, event.Type) {
+			continue
+		}
+		n.deliveries.Add(1)
+		go func(webhook models.WebhookConfig) {
+			defer n.deliveries.Done()
+			if err := n.deliverWebhook(webhook, event); err != nil {
+				slog.Warn("Webhook delivery failed", "url", webhook.URL, "event", event.Type, "error", err)
+			}
+		}(webhook)
+	}
+
+	if n.desktop != nil {
+		filter := n.desktop.Events
+		if len(filter) == 0 {
+			filter = defaultDesktopEvents
+		}
+		if contains(filter, event.Type) {
+			if err := n.notifyDesktop(event); err != nil {
+				slog.Warn("Desktop notification failed", "event", event.Type, "error", err)
+			}
+		}
+	}
+}
+
+// worthNotifying drops health results that are neither a new failure nor a recovery
+func (n *Notifier) worthNotifying(event Event) bool {
+	status, ok := event.Data.(HealthStatus)
+	if event.Type != EventHealthChanged || !ok {
+		return true
+	}
+
+	wasFailing := n.failing[status.Server]
+	n.failing[status.Server] = !status.Healthy
+	return !status.Healthy || wasFailing
+}
+
+// deliverWebhook posts the event, retrying network errors, 429 and 5xx answers
+// with exponential backoff. Other answers are final.
+func (n *Notifier) deliverWebhook(webhook models.WebhookConfig, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event for webhook '%s': %w", webhook.URL, err)
+	}
+
+	retries := defaultWebhookRetries
+	if webhook.MaxRetries != nil {
+		retries = *webhook.MaxRetries
+	}
+
+	delay := n.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := n.postWebhook(webhook, event.Type, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= retries {
+			return fmt.Errorf("webhook '%s' failed after %d attempts: %w", webhook.URL, attempt+1, err)
+		}
+
+		select {
+		case <-n.stop:
+			return fmt.Errorf("webhook '%s' abandoned on shutdown: %w", webhook.URL, err)
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// postWebhook sends one attempt and reports whether a failure is worth retrying
+func (n *Notifier) postWebhook(webhook models.WebhookConfig, eventType string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, eventType)
+	if webhook.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhookBody(webhook.Secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("HTTP %s", resp.Status)
+	default:
+		return false, fmt.Errorf("HTTP %s", resp.Status)
+	}
+}
+
+// SignWebhookBody returns the signature header value for a webhook body
+func SignWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// notifyDesktop shows the event through notify-send, which talks to the
+// notification daemon over D-Bus
+func (n *Notifier) notifyDesktop(event Event) error {
+	summary, body, urgency := describeEvent(event)
+
+	cmd := exec.Command(n.desktopCommand, "--app-name=MCP Server Manager", "--urgency="+urgency, summary, body)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("desktop notification failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// describeEvent returns a notification summary, body and urgency for an event
+func describeEvent(event Event) (string, string, string) {
+	switch data := event.Data.(type) {
+	case HealthStatus:
+		if data.Healthy {
+			return fmt.Sprintf("MCP server '%s' recovered", data.Server), data.Detail, "normal"
+		}
+		return fmt.Sprintf("MCP server '%s' is failing", data.Server), data.Detail, "critical"
+	case []DriftEvent:
+		lines := make([]string, 0, len(data))
+		for _, drift := range data {
+			line := fmt.Sprintf("%s: %s (%s)", drift.Client, drift.Server, drift.Kind)
+			if drift.Healed {
+				line += ", healed"
+			}
+			lines = append(lines, line)
+		}
+		return "Client config drift detected", strings.Join(lines, "\n"), "normal"
+	case ServerToggledData:
+		action := "disabled"
+		if data.Enabled {
+			action = "enabled"
+		}
+		target := data.Project
+		if target == "" {
+			target = strings.Join(data.Clients, ", ")
+		}
+		return fmt.Sprintf("MCP servers %s", action), fmt.Sprintf("%s for %s", strings.Join(data.Servers, ", "), target), "low"
+	case ServerAddedData:
+		return "MCP server added", data.Server, "low"
+	case ConfigReloadedData:
+		return "MCP configuration changed", data.Reason, "low"
+	default:
+		return "MCP Server Manager", event.Type, "low"
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+// webhookRecorder is a webhook endpoint that fails a given number of times
+// before accepting deliveries
+type webhookRecorder struct {
+	mu         sync.Mutex
+	failures   int
+	attempts   int
+	events     []string
+	signatures []string
+	bodies     [][]byte
+}
+
+func (r *webhookRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	if r.attempts <= r.failures {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	r.events = append(r.events, req.Header.Get(WebhookEventHeader))
+	r.signatures = append(r.signatures, req.Header.Get(WebhookSignatureHeader))
+	r.bodies = append(r.bodies, body)
+}
+
+func (r *webhookRecorder) delivered() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func TestNotifier_Webhooks(t *testing.T) {
+	recorder := &webhookRecorder{failures: 2}
+	endpoint := httptest.NewServer(recorder)
+	defer endpoint.Close()
+
+	bus := NewEventBus()
+	notifier := NewNotifier(bus, &models.NotificationsConfig{
+		Webhooks: []models.WebhookConfig{{
+			URL:    endpoint.URL,
+			Events: []string{EventHealthChanged, EventDriftDetected},
+			Secret: "s3cret",
+		}},
+	})
+	notifier.backoff = time.Millisecond
+	notifier.Start()
+	defer notifier.Stop()
+
+	bus.Publish(EventSynced, SyncedData{})                                     // Filtered out
+	bus.Publish(EventHealthChanged, HealthStatus{Server: "a", Healthy: true})  // First result, healthy
+	bus.Publish(EventHealthChanged, HealthStatus{Server: "a", Healthy: false}) // Starts failing
+
+	waitFor(t, func() bool { return len(recorder.delivered()) == 1 })
+
+	recorder.mu.Lock()
+	if recorder.attempts != 3 {
+		t.Errorf("Expected two retries before success, got %d attempts", recorder.attempts)
+	}
+	if recorder.events[0] != EventHealthChanged {
+		t.Errorf("Expected a health_changed delivery, got %s", recorder.events[0])
+	}
+	if want := SignWebhookBody("s3cret", recorder.bodies[0]); recorder.signatures[0] != want {
+		t.Errorf("Expected signature %s, got %s", want, recorder.signatures[0])
+	}
+	recorder.mu.Unlock()
+
+	// The recovery is reported too
+	bus.Publish(EventHealthChanged, HealthStatus{Server: "a", Healthy: true})
+	waitFor(t, func() bool { return len(recorder.delivered()) == 2 })
+}
+
+func TestNotifier_WebhookGivesUp(t *testing.T) {
+	retries := 1
+	recorder := &webhookRecorder{failures: 10}
+	endpoint := httptest.NewServer(recorder)
+	defer endpoint.Close()
+
+	notifier := NewNotifier(NewEventBus(), nil)
+	notifier.backoff = time.Millisecond
+
+	err := notifier.deliverWebhook(models.WebhookConfig{URL: endpoint.URL, MaxRetries: &retries}, Event{Type: EventSynced})
+	testutil.AssertErrorContains(t, err, "after 2 attempts")
+
+	// Client errors are not retried
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer rejecting.Close()
+	err = notifier.deliverWebhook(models.WebhookConfig{URL: rejecting.URL}, Event{Type: EventSynced})
+	testutil.AssertErrorContains(t, err, "after 1 attempts")
+}
+
+func TestNotifier_Desktop(t *testing.T) {
+	tempDir := t.TempDir()
+	logPath := filepath.Join(tempDir, "notifications.log")
+	script := filepath.Join(tempDir, "notify-send")
+	testutil.WriteTestFile(t, script, "#!/bin/sh\nprintf '%s|' \"$@\" >> "+logPath+"\necho >> "+logPath+"\n")
+	if err := os.Chmod(script, 0755); err != nil {
+		t.Fatalf("Failed to make script executable: %v", err)
+	}
+
+	bus := NewEventBus()
+	notifier := NewNotifier(bus, &models.NotificationsConfig{Desktop: &models.DesktopNotifyConfig{Enabled: true}})
+	notifier.desktopCommand = script
+	notifier.Start()
+
+	bus.Publish(EventServerAdded, ServerAddedData{Server: "a"}) // Not a default desktop event
+	bus.Publish(EventDriftDetected, []DriftEvent{{Client: "cursor", Server: "a", Kind: DriftMissing}})
+	bus.Publish(EventHealthChanged, HealthStatus{Server: "b", Healthy: false, Detail: "connection refused"})
+
+	waitFor(t, func() bool {
+		data, _ := os.ReadFile(logPath)
+		return strings.Count(string(data), "\n") == 2
+	})
+	notifier.Stop()
+
+	data, _ := os.ReadFile(logPath)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if !strings.Contains(lines[0], "cursor: a (missing)") {
+		t.Errorf("Expected a drift notification, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "--urgency=critical") || !strings.Contains(lines[1], "MCP server 'b' is failing") {
+		t.Errorf("Expected a critical health notification, got %q", lines[1])
+	}
+}
+
+func TestValidateNotificationsConfig(t *testing.T) {
+	negative := -1
+	tests := []struct {
+		name    string
+		config  *models.NotificationsConfig
+		wantErr string
+	}{
+		{"Valid", &models.NotificationsConfig{
+			Webhooks: []models.WebhookConfig{{URL: "https://example.com/hook", Events: []string{EventDriftDetected}}},
+			Desktop:  &models.DesktopNotifyConfig{Enabled: true},
+		}, ""},
+		{"Relative URL", &models.NotificationsConfig{Webhooks: []models.WebhookConfig{{URL: "/hook"}}}, "invalid URL"},
+		{"Negative retries", &models.NotificationsConfig{Webhooks: []models.WebhookConfig{{URL: "http://localhost/hook", MaxRetries: &negative}}}, "max_retries"},
+		{"Unknown webhook event", &models.NotificationsConfig{Webhooks: []models.WebhookConfig{{URL: "http://localhost/hook", Events: []string{"deleted"}}}}, "unknown event type 'deleted'"},
+		{"Unknown desktop event", &models.NotificationsConfig{Desktop: &models.DesktopNotifyConfig{Events: []string{"x"}}}, "desktop notifications"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateNotificationsConfig(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			testutil.AssertErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
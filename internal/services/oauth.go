@@ -0,0 +1,685 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// OAuthTokenFile is created next to config.yaml and holds the client
+// registrations and tokens of servers using OAuth, readable by the owner only
+const OAuthTokenFile = "oauth-tokens.json"
+
+const (
+	oauthFlowTimeout  = 10 * time.Minute // How long a started sign-in waits for the callback
+	oauthRefreshAhead = 5 * time.Minute  // Tokens expiring within this are refreshed
+	oauthHTTPTimeout  = 10 * time.Second
+	oauthClientName   = "MCP Server Manager"
+)
+
+// ErrOAuthNotSignedIn is returned for a server using OAuth without a token
+var ErrOAuthNotSignedIn = errors.New("not signed in")
+
+// OAuthStatus describes the sign-in state of a server using OAuth
+type OAuthStatus struct {
+	Server      string     `json:"server"`
+	SignedIn    bool       `json:"signed_in"`
+	Issuer      string     `json:"issuer,omitempty"`
+	Scope       string     `json:"scope,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // Nil for tokens without expiry
+	Refreshable bool       `json:"refreshable"`
+}
+
+// oauthRegistration is what the manager learned about a server's authorization
+// server and the client it registered there
+type oauthRegistration struct {
+	Resource              string `json:"resource"` // The server URL the tokens are for
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	ClientID              string `json:"client_id"`
+	ClientSecret          string `json:"client_secret,omitempty"`
+	AuthMethod            string `json:"token_endpoint_auth_method,omitempty"`
+	RedirectURI           string `json:"redirect_uri"`
+	Scope                 string `json:"scope,omitempty"`
+}
+
+type oauthToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"` // Zero when the token does not expire
+}
+
+type oauthEntry struct {
+	Registration oauthRegistration `json:"registration"`
+	Token        *oauthToken       `json:"token,omitempty"`
+}
+
+// oauthFlow is a sign-in waiting for the browser to come back with a code
+type oauthFlow struct {
+	server       string
+	verifier     string
+	registration oauthRegistration
+	started      time.Time
+}
+
+// OAuthService signs in to remote servers with OAuth 2.1: it discovers the
+// authorization server from the server URL (RFC 9728, RFC 8414), registers
+// itself as a client (RFC 7591), runs the authorization code flow with PKCE in
+// the browser and keeps the tokens fresh
+type OAuthService struct {
+	path   string
+	client *http.Client
+
+	mu      sync.Mutex
+	entries map[string]*oauthEntry // Server name -> registration and token
+	pending map[string]*oauthFlow  // State -> sign-in in progress
+}
+
+// NewOAuthService creates the service, keeping tokens in the given file
+func NewOAuthService(path string) *OAuthService {
+	s := &OAuthService{
+		path:    path,
+		client:  &http.Client{Timeout: oauthHTTPTimeout, CheckRedirect: checkOAuthRedirect},
+		entries: make(map[string]*oauthEntry),
+		pending: make(map[string]*oauthFlow),
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &s.entries)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		slog.Warn("Ignoring unreadable OAuth token file", "path", path, "error", err)
+	}
+	if s.entries == nil {
+		s.entries = make(map[string]*oauthEntry)
+	}
+	return s
+}
+
+// save writes the token file; the caller holds s.mu
+func (s *OAuthService) save() error {
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal OAuth tokens: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for '%s': %w", s.path, err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write OAuth tokens '%s': %w", s.path, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write OAuth tokens '%s': %w", s.path, err)
+	}
+	return nil
+}
+
+// Authorize starts signing in to a server and returns the URL of the
+// authorization server's login page. The browser returns to redirectURI,
+// whose handler calls Complete.
+func (s *OAuthService) Authorize(srv models.MCPServer, redirectURI string) (string, error) {
+	resource := serverURL(srv.Config)
+	if srv.OAuth == nil || resource == "" {
+		return "", fmt.Errorf("server '%s' does not use OAuth", srv.Name)
+	}
+
+	registration, err := s.registration(srv, resource, redirectURI)
+	if err != nil {
+		return "", fmt.Errorf("failed to prepare OAuth sign-in for '%s': %w", srv.Name, err)
+	}
+
+	state, err := randomString(24)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomString(48)
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	s.mu.Lock()
+	for key, flow := range s.pending {
+		if time.Since(flow.started) > oauthFlowTimeout {
+			delete(s.pending, key)
+		}
+	}
+	s.pending[state] = &oauthFlow{server: srv.Name, verifier: verifier, registration: registration, started: time.Now()}
+	s.mu.Unlock()
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {registration.ClientID},
+		"redirect_uri":          {redirectURI},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+		"resource":              {resource},
+	}
+	if registration.Scope != "" {
+		query.Set("scope", registration.Scope)
+	}
+
+	separator := "?"
+	if strings.Contains(registration.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return registration.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Complete exchanges the code the authorization server sent back for tokens and
+// stores them; it returns the name of the server signed in to
+func (s *OAuthService) Complete(state, code string) (string, error) {
+	s.mu.Lock()
+	flow, found := s.pending[state]
+	delete(s.pending, state)
+	s.mu.Unlock()
+
+	if !found || time.Since(flow.started) > oauthFlowTimeout {
+		return "", fmt.Errorf("unknown or expired OAuth sign-in; start it again")
+	}
+	if code == "" {
+		return "", fmt.Errorf("authorization server returned no code for '%s'", flow.server)
+	}
+
+	token, err := s.requestToken(flow.registration, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {flow.registration.RedirectURI},
+		"code_verifier": {flow.verifier},
+		"resource":      {flow.registration.Resource},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign in to '%s': %w", flow.server, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[flow.server] = &oauthEntry{Registration: flow.registration, Token: token}
+	return flow.server, s.save()
+}
+
+// Refresh replaces the access token of a server using its refresh token
+func (s *OAuthService) Refresh(serverName string) error {
+	s.mu.Lock()
+	entry, found := s.entries[serverName]
+	var registration oauthRegistration
+	var refreshToken string
+	if found && entry.Token != nil {
+		registration, refreshToken = entry.Registration, entry.Token.RefreshToken
+	}
+	s.mu.Unlock()
+
+	if refreshToken == "" {
+		return fmt.Errorf("server '%s' has no refresh token: %w", serverName, ErrOAuthNotSignedIn)
+	}
+
+	token, err := s.requestToken(registration, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"resource":      {registration.Resource},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to refresh OAuth token of '%s': %w", serverName, err)
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken // Kept unless the server rotates it
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, found := s.entries[serverName]; found {
+		entry.Token = token
+	}
+	return s.save()
+}
+
+// SignOut forgets the tokens of a server; the client registration is kept
+func (s *OAuthService) SignOut(serverName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, found := s.entries[serverName]
+	if !found || entry.Token == nil {
+		return nil
+	}
+	entry.Token = nil
+	return s.save()
+}
+
+// AccessToken returns the stored access token of a server, even when expired;
+// refreshing is left to RefreshDue
+func (s *OAuthService) AccessToken(serverName string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, found := s.entries[serverName]; found && entry.Token != nil {
+		return entry.Token.AccessToken, true
+	}
+	return "", false
+}
+
+// Status returns the sign-in state of a server
+func (s *OAuthService) Status(serverName string) OAuthStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := OAuthStatus{Server: serverName}
+	entry, found := s.entries[serverName]
+	if !found || entry.Token == nil {
+		return status
+	}
+	status.SignedIn = true
+	status.Issuer = entry.Registration.Issuer
+	status.Scope = entry.Token.Scope
+	status.Refreshable = entry.Token.RefreshToken != ""
+	if !entry.Token.Expiry.IsZero() {
+		expiry := entry.Token.Expiry
+		status.ExpiresAt = &expiry
+	}
+	return status
+}
+
+// RefreshDue lists the servers among names whose tokens expire soon and can be
+// refreshed
+func (s *OAuthService) RefreshDue(names []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []string
+	for _, name := range names {
+		entry, found := s.entries[name]
+		if !found || entry.Token == nil || entry.Token.RefreshToken == "" || entry.Token.Expiry.IsZero() {
+			continue
+		}
+		if time.Until(entry.Token.Expiry) < oauthRefreshAhead {
+			due = append(due, name)
+		}
+	}
+	return due
+}
+
+// registration returns the client registration for a server, reusing the stored
+// one while the server URL, redirect URI and settings are unchanged
+func (s *OAuthService) registration(srv models.MCPServer, resource, redirectURI string) (oauthRegistration, error) {
+	scope := strings.Join(srv.OAuth.Scopes, " ")
+
+	s.mu.Lock()
+	entry, found := s.entries[srv.Name]
+	s.mu.Unlock()
+	if found {
+		stored := entry.Registration
+		if stored.Resource == resource && stored.RedirectURI == redirectURI && (scope == "" || stored.Scope == scope) &&
+			(srv.OAuth.ClientID == "" || srv.OAuth.ClientID == stored.ClientID) &&
+			(srv.OAuth.AuthorizationServer == "" || srv.OAuth.AuthorizationServer == stored.Issuer) {
+			return stored, nil
+		}
+	}
+
+	metadata, err := s.discover(resource, srv.OAuth.AuthorizationServer)
+	if err != nil {
+		return oauthRegistration{}, err
+	}
+	if scope == "" {
+		scope = strings.Join(metadata.resourceScopes, " ")
+	}
+
+	registration := oauthRegistration{
+		Resource:              resource,
+		Issuer:                metadata.Issuer,
+		AuthorizationEndpoint: metadata.AuthorizationEndpoint,
+		TokenEndpoint:         metadata.TokenEndpoint,
+		ClientID:              srv.OAuth.ClientID,
+		ClientSecret:          srv.OAuth.ClientSecret,
+		RedirectURI:           redirectURI,
+		Scope:                 scope,
+	}
+	if registration.ClientSecret != "" {
+		registration.AuthMethod = "client_secret_basic"
+	}
+
+	if registration.ClientID == "" {
+		if metadata.RegistrationEndpoint == "" {
+			return oauthRegistration{}, fmt.Errorf("authorization server '%s' does not support dynamic client registration; set oauth.client_id", metadata.Issuer)
+		}
+		if err := s.register(metadata.RegistrationEndpoint, &registration); err != nil {
+			return oauthRegistration{}, err
+		}
+	}
+	return registration, nil
+}
+
+// authServerMetadata is the subset of RFC 8414 metadata the flow needs
+type authServerMetadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	RegistrationEndpoint          string   `json:"registration_endpoint"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+
+	resourceScopes []string // scopes_supported of the protected resource
+}
+
+// resourceMetadataPattern finds resource_metadata in a WWW-Authenticate header
+var resourceMetadataPattern = regexp.MustCompile(`resource_metadata="([^"]+)"`)
+
+// discover finds the authorization server of a resource. The server's
+// protected resource metadata names it; servers without metadata are their own
+// authorization server, as in earlier MCP revisions.
+func (s *OAuthService) discover(resource, issuer string) (*authServerMetadata, error) {
+	if err := requireSecureURL(resource); err != nil {
+		return nil, err
+	}
+
+	var scopes []string
+	if issuer == "" {
+		var resourceMetadata struct {
+			AuthorizationServers []string `json:"authorization_servers"`
+			ScopesSupported      []string `json:"scopes_supported"`
+		}
+
+		candidates := wellKnownURLs(resource, "oauth-protected-resource")
+		if advertised := s.advertisedResourceMetadata(resource); advertised != "" && requireSecureURL(advertised) == nil {
+			candidates = append([]string{advertised}, candidates...)
+		}
+		for _, candidate := range candidates {
+			if found, err := s.getJSON(candidate, &resourceMetadata); err != nil {
+				return nil, err
+			} else if found {
+				break
+			}
+		}
+
+		if len(resourceMetadata.AuthorizationServers) > 0 {
+			issuer = resourceMetadata.AuthorizationServers[0]
+		} else {
+			parsed, _ := url.Parse(resource)
+			issuer = parsed.Scheme + "://" + parsed.Host
+		}
+		scopes = resourceMetadata.ScopesSupported
+	}
+
+	if err := requireSecureURL(issuer); err != nil {
+		return nil, fmt.Errorf("authorization server: %w", err)
+	}
+
+	metadata := &authServerMetadata{}
+	found := false
+	candidates := append(wellKnownURLs(issuer, "oauth-authorization-server"), wellKnownURLs(issuer, "openid-configuration")...)
+	for _, candidate := range candidates {
+		var err error
+		if found, err = s.getJSON(candidate, metadata); err != nil {
+			return nil, err
+		} else if found {
+			break
+		}
+	}
+	if !found {
+		// Default endpoints of servers without metadata
+		base := strings.TrimSuffix(issuer, "/")
+		metadata = &authServerMetadata{
+			Issuer:                base,
+			AuthorizationEndpoint: base + "/authorize",
+			TokenEndpoint:         base + "/token",
+			RegistrationEndpoint:  base + "/register",
+		}
+	}
+
+	if metadata.Issuer == "" {
+		metadata.Issuer = issuer
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" {
+		return nil, fmt.Errorf("authorization server '%s' lists no authorization or token endpoint", issuer)
+	}
+	for _, endpoint := range []string{metadata.AuthorizationEndpoint, metadata.TokenEndpoint, metadata.RegistrationEndpoint} {
+		if endpoint == "" {
+			continue
+		}
+		if err := requireSecureURL(endpoint); err != nil {
+			return nil, fmt.Errorf("authorization server '%s': %w", issuer, err)
+		}
+	}
+	if len(metadata.CodeChallengeMethodsSupported) > 0 && !contains(metadata.CodeChallengeMethodsSupported, "S256") {
+		return nil, fmt.Errorf("authorization server '%s' does not support PKCE with S256", issuer)
+	}
+	metadata.resourceScopes = scopes
+	return metadata, nil
+}
+
+// advertisedResourceMetadata asks the server itself: an unauthenticated request
+// is answered with 401 and a WWW-Authenticate header pointing at its metadata
+func (s *OAuthService) advertisedResourceMetadata(resource string) string {
+	resp, err := s.client.Get(resource)
+	if err != nil {
+		return ""
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return ""
+	}
+	if match := resourceMetadataPattern.FindStringSubmatch(resp.Header.Get("WWW-Authenticate")); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// requireSecureURL refuses plain HTTP for OAuth requests, which carry codes,
+// tokens and client secrets, unless they stay on this machine
+func requireSecureURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL '%s': %w", rawURL, err)
+	}
+	if parsed.Scheme == "https" || (parsed.Scheme == "http" && IsLoopbackAddress(parsed.Hostname())) {
+		return nil
+	}
+	return fmt.Errorf("'%s' must use https unless it is on this machine", rawURL)
+}
+
+// checkOAuthRedirect keeps redirects from downgrading OAuth requests to HTTP
+func checkOAuthRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return requireSecureURL(req.URL.String())
+}
+
+// wellKnownURLs returns where metadata of the given kind is published for a URL:
+// the well-known path inserted before the URL's path, then at the root
+func wellKnownURLs(rawURL, kind string) []string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return nil
+	}
+
+	base := parsed.Scheme + "://" + parsed.Host + "/.well-known/" + kind
+	path := strings.TrimSuffix(parsed.EscapedPath(), "/")
+	if path == "" {
+		return []string{base}
+	}
+	return []string{base + path, base}
+}
+
+// getJSON fetches a metadata document; a missing document is not an error
+func (s *OAuthService) getJSON(rawURL string, v interface{}) (bool, error) {
+	resp, err := s.client.Get(rawURL)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch '%s': %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, nil
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v); err != nil {
+		return false, fmt.Errorf("invalid metadata at '%s': %w", rawURL, err)
+	}
+	return true, nil
+}
+
+// register registers the manager as a public client (RFC 7591) and fills in the
+// client ID it was given
+func (s *OAuthService) register(endpoint string, registration *oauthRegistration) error {
+	request := map[string]interface{}{
+		"client_name":                oauthClientName,
+		"redirect_uris":              []string{registration.RedirectURI},
+		"grant_types":                []string{"authorization_code", "refresh_token"},
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+	}
+	if registration.Scope != "" {
+		request["scope"] = registration.Scope
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	if err := requireSecureURL(endpoint); err != nil {
+		return fmt.Errorf("failed to register client: %w", err)
+	}
+
+	resp, err := s.client.Post(endpoint, "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		return fmt.Errorf("failed to register client at '%s': %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		AuthMethod   string `json:"token_endpoint_auth_method"`
+	}
+	if err := decodeOAuthResponse(resp, &result); err != nil {
+		return fmt.Errorf("failed to register client at '%s': %w", endpoint, err)
+	}
+	if result.ClientID == "" {
+		return fmt.Errorf("failed to register client at '%s': no client_id returned", endpoint)
+	}
+
+	registration.ClientID = result.ClientID
+	registration.ClientSecret = result.ClientSecret
+	registration.AuthMethod = result.AuthMethod
+	return nil
+}
+
+// requestToken posts a token request and returns the token with its expiry
+func (s *OAuthService) requestToken(registration oauthRegistration, form url.Values) (*oauthToken, error) {
+	form.Set("client_id", registration.ClientID)
+	if registration.ClientSecret != "" && registration.AuthMethod == "client_secret_post" {
+		form.Set("client_secret", registration.ClientSecret)
+	}
+
+	if err := requireSecureURL(registration.TokenEndpoint); err != nil {
+		return nil, fmt.Errorf("token endpoint: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, registration.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if registration.ClientSecret != "" && registration.AuthMethod != "client_secret_post" {
+		req.SetBasicAuth(url.QueryEscape(registration.ClientID), url.QueryEscape(registration.ClientSecret))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken  string      `json:"access_token"`
+		TokenType    string      `json:"token_type"`
+		RefreshToken string      `json:"refresh_token"`
+		Scope        string      `json:"scope"`
+		ExpiresIn    json.Number `json:"expires_in"`
+	}
+	if err := decodeOAuthResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	if result.AccessToken == "" {
+		return nil, fmt.Errorf("token endpoint returned no access_token")
+	}
+	if result.TokenType != "" && !strings.EqualFold(result.TokenType, "bearer") {
+		return nil, fmt.Errorf("unsupported token type '%s'", result.TokenType)
+	}
+
+	token := &oauthToken{AccessToken: result.AccessToken, RefreshToken: result.RefreshToken, Scope: result.Scope}
+	if token.Scope == "" {
+		token.Scope = registration.Scope
+	}
+	if seconds, err := strconv.ParseInt(result.ExpiresIn.String(), 10, 64); err == nil && seconds > 0 {
+		token.Expiry = time.Now().Add(time.Duration(seconds) * time.Second).UTC()
+	}
+	return token, nil
+}
+
+// decodeOAuthResponse decodes a successful JSON response, or turns an OAuth
+// error response into an error
+func decodeOAuthResponse(resp *http.Response, v interface{}) error {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(data, &oauthErr) == nil && oauthErr.Error != "" {
+			if oauthErr.Description != "" {
+				return fmt.Errorf("%s: %s", oauthErr.Error, oauthErr.Description)
+			}
+			return errors.New(oauthErr.Error)
+		}
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// serverURL returns the URL of a remote server, empty for stdio servers
+func serverURL(serverConfig map[string]interface{}) string {
+	if value := extractTransportValue(serverConfig, "url"); value != "" {
+		return value
+	}
+	return extractTransportValue(serverConfig, "httpUrl")
+}
+
+// randomString returns n random bytes, base64url encoded
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+const defaultOAuthRefreshInterval = time.Minute
+
+// OAuth returns the service holding the OAuth tokens of remote servers
+func (s *MCPManagerService) OAuth() *OAuthService {
+	return s.oauth
+}
+
+// oauthServer returns a server using OAuth, with variables expanded as in client files
+func (s *MCPManagerService) oauthServer(serverName string) (models.MCPServer, error) {
+	for _, srv := range s.serversSnapshot() {
+		if srv.Name != serverName {
+			continue
+		}
+		if srv.OAuth == nil {
+			return models.MCPServer{}, fmt.Errorf("server '%s' does not use OAuth", serverName)
+		}
+		return srv, nil
+	}
+	return models.MCPServer{}, fmt.Errorf("MCP server '%s' not found", serverName)
+}
+
+// StartOAuth begins signing in to a server and returns the URL to send the
+// browser to. The authorization server redirects back to redirectURI.
+func (s *MCPManagerService) StartOAuth(serverName, redirectURI string) (string, error) {
+	srv, err := s.oauthServer(serverName)
+	if err != nil {
+		return "", err
+	}
+	return s.oauth.Authorize(srv, redirectURI)
+}
+
+// CompleteOAuth finishes a sign-in started with StartOAuth and writes the new
+// Authorization header to every client file
+func (s *MCPManagerService) CompleteOAuth(state, code string) (serverName string, err error) {
+	// The token request runs without the lock, like health checks
+	serverName, err = s.oauth.Complete(state, code)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("oauth_sign_in", serverName, &err)
+	defer s.publish(EventSynced, SyncedData{Clients: s.clientNames()}, &err)
+
+	return serverName, s.syncAllClients()
+}
+
+// SignOutOAuth forgets the tokens of a server and removes its Authorization
+// header from client files
+func (s *MCPManagerService) SignOutOAuth(serverName string) (err error) {
+	if _, err := s.oauthServer(serverName); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("oauth_sign_out", serverName, &err)
+	defer s.publish(EventSynced, SyncedData{Clients: s.clientNames()}, &err)
+
+	if err := s.oauth.SignOut(serverName); err != nil {
+		return err
+	}
+	return s.syncAllClients()
+}
+
+// OAuthStatuses returns the sign-in state of every server using OAuth
+func (s *MCPManagerService) OAuthStatuses() map[string]OAuthStatus {
+	statuses := make(map[string]OAuthStatus)
+	for _, srv := range s.serversSnapshot() {
+		if srv.OAuth != nil {
+			statuses[srv.Name] = s.oauth.Status(srv.Name)
+		}
+	}
+	return statuses
+}
+
+// RefreshOAuthTokens refreshes the tokens about to expire and rewrites client
+// files when any changed. Servers whose refresh fails keep their old token
+// until signed in again.
+func (s *MCPManagerService) RefreshOAuthTokens() error {
+	var names []string
+	for _, srv := range s.serversSnapshot() {
+		if srv.OAuth != nil {
+			names = append(names, srv.Name)
+		}
+	}
+
+	var refreshed []string
+	var firstErr error
+	for _, name := range s.oauth.RefreshDue(names) {
+		if err := s.oauth.Refresh(name); err != nil {
+			s.logger().Warn("OAuth token refresh failed", "server", name, "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		s.logger().Info("Refreshed OAuth token", "server", name)
+		refreshed = append(refreshed, name)
+	}
+	if len(refreshed) == 0 {
+		return firstErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.syncAllClients(); err != nil {
+		return err
+	}
+	s.events.Publish(EventSynced, SyncedData{Clients: s.clientNames()})
+	return firstErr
+}
+
+// OAuthRefresher refreshes OAuth tokens in the background before they expire,
+// so client files always carry a usable Authorization header
+type OAuthRefresher struct {
+	manager  *MCPManagerService
+	interval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewOAuthRefresher creates a refresher checking the manager's tokens every minute
+func NewOAuthRefresher(manager *MCPManagerService) *OAuthRefresher {
+	return &OAuthRefresher{
+		manager:  manager,
+		interval: defaultOAuthRefreshInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start refreshes due tokens right away and then at every interval in the background
+func (r *OAuthRefresher) Start() {
+	go r.run()
+}
+
+// Stop halts refreshing and waits for the background goroutine to exit
+func (r *OAuthRefresher) Stop() {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+}
+
+func (r *OAuthRefresher) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.manager.RefreshOAuthTokens()
+
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
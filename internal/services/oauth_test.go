@@ -0,0 +1,288 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+const oauthTestRedirectURI = "http://127.0.0.1:6543/oauth/callback"
+
+func setupOAuthTest(t *testing.T) (*MCPManagerService, *testutil.OAuthServer, string) {
+	t.Helper()
+	authServer := testutil.NewOAuthServer(t)
+	tempDir := t.TempDir()
+	clientConfigPath := filepath.Join(tempDir, testutil.TestClientJSON)
+
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{
+			{Name: "remote", OAuth: &models.OAuthConfig{}, Config: map[string]interface{}{
+				"type":    "http",
+				"url":     authServer.ResourceURL(),
+				"headers": map[string]interface{}{"Accept": "application/json"},
+			}},
+			{Name: testutil.TestServerName, Config: map[string]interface{}{"command": "echo"}},
+		},
+		Clients: map[string]*models.Client{
+			testutil.TestClientName: {ConfigPath: clientConfigPath, Enabled: []string{"remote", testutil.TestServerName}},
+		},
+	}
+
+	service := NewMCPManagerService(cfg, filepath.Join(tempDir, testutil.TestConfigYAML))
+	if err := service.SyncAllClients(); err != nil {
+		t.Fatalf("SyncAllClients failed: %v", err)
+	}
+	return service, authServer, clientConfigPath
+}
+
+// signIn plays the browser: it opens the authorization URL, which the stand-in
+// answers by redirecting to the callback, and completes the sign-in from there
+func signIn(t *testing.T, service *MCPManagerService) {
+	t.Helper()
+	authURL, err := service.StartOAuth("remote", oauthTestRedirectURI)
+	if err != nil {
+		t.Fatalf("StartOAuth failed: %v", err)
+	}
+
+	browser := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := browser.Get(authURL)
+	if err != nil {
+		t.Fatalf("Opening the authorization URL failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected a redirect to the callback, got %s", resp.Status)
+	}
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || !strings.HasPrefix(callback.String(), oauthTestRedirectURI+"?") {
+		t.Fatalf("Unexpected callback %q", resp.Header.Get("Location"))
+	}
+	if _, err := service.CompleteOAuth(callback.Query().Get("state"), callback.Query().Get("code")); err != nil {
+		t.Fatalf("CompleteOAuth failed: %v", err)
+	}
+}
+
+// clientAuthorization returns the Authorization header of the remote server in a client file
+func clientAuthorization(t *testing.T, clientConfigPath string) string {
+	t.Helper()
+	data, err := os.ReadFile(clientConfigPath)
+	if err != nil {
+		t.Fatalf("Failed to read client config: %v", err)
+	}
+	var clientConfig struct {
+		MCPServers map[string]struct {
+			Headers map[string]string `json:"headers"`
+		} `json:"mcpServers"`
+	}
+	if err := json.Unmarshal(data, &clientConfig); err != nil {
+		t.Fatalf("Failed to parse client config: %v", err)
+	}
+	if clientConfig.MCPServers["remote"].Headers["Accept"] != "application/json" {
+		t.Errorf("Configured headers must be kept, got %v", clientConfig.MCPServers["remote"].Headers)
+	}
+	return clientConfig.MCPServers["remote"].Headers["Authorization"]
+}
+
+func TestOAuth_SignInRefreshSignOut(t *testing.T) {
+	service, authServer, clientConfigPath := setupOAuthTest(t)
+	authServer.ExpiresIn = 60 // Due for refresh right away
+
+	if header := clientAuthorization(t, clientConfigPath); header != "" {
+		t.Fatalf("Expected no Authorization header before signing in, got %q", header)
+	}
+	if status := service.OAuthStatuses()["remote"]; status.SignedIn {
+		t.Fatalf("Expected not signed in, got %+v", status)
+	}
+
+	signIn(t, service)
+
+	header := clientAuthorization(t, clientConfigPath)
+	if !strings.HasPrefix(header, "Bearer ") || !authServer.ValidToken(strings.TrimPrefix(header, "Bearer ")) {
+		t.Fatalf("Expected a valid bearer token in the client file, got %q", header)
+	}
+	requests := authServer.TokenRequests()
+	if len(requests) != 1 || requests[0].Get("code_verifier") == "" || requests[0].Get("resource") != authServer.ResourceURL() {
+		t.Errorf("Expected a PKCE token request for the resource, got %v", requests)
+	}
+	status := service.OAuthStatuses()["remote"]
+	if !status.SignedIn || !status.Refreshable || status.ExpiresAt == nil || status.Issuer != authServer.Issuer() || status.Scope != "mcp:read mcp:write" {
+		t.Errorf("Unexpected status after signing in: %+v", status)
+	}
+	if _, leaked := service.GetMCPServers()[0].Config["headers"].(map[string]interface{})["Authorization"]; leaked {
+		t.Error("The token must not be stored in config.yaml")
+	}
+
+	// The client can now reach the server with the header it was given
+	req, _ := http.NewRequest(http.MethodGet, authServer.ResourceURL(), nil)
+	req.Header.Set("Authorization", header)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request to the server failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the server to accept the token, got %s", resp.Status)
+	}
+
+	// Tokens survive a restart, readable by the owner only
+	tokenFile := filepath.Join(filepath.Dir(clientConfigPath), OAuthTokenFile)
+	if info, err := os.Stat(tokenFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected %s with mode 0600, got %v %v", OAuthTokenFile, info, err)
+	}
+	if token, ok := NewOAuthService(tokenFile).AccessToken("remote"); !ok || "Bearer "+token != header {
+		t.Errorf("Expected the token to be stored, got %q", token)
+	}
+
+	// Project files are often committed and never get the token
+	entry, err := service.clientConfigService.serverEntry("remote", t.TempDir())
+	if err != nil {
+		t.Fatalf("serverEntry failed: %v", err)
+	}
+	if _, leaked := entry["headers"].(map[string]interface{})["Authorization"]; leaked {
+		t.Error("Project files must not carry the token")
+	}
+
+	// A token about to expire is refreshed and written to clients
+	if err := service.RefreshOAuthTokens(); err != nil {
+		t.Fatalf("RefreshOAuthTokens failed: %v", err)
+	}
+	refreshed := clientAuthorization(t, clientConfigPath)
+	if refreshed == header || !authServer.ValidToken(strings.TrimPrefix(refreshed, "Bearer ")) {
+		t.Errorf("Expected a new valid token after refreshing, got %q (was %q)", refreshed, header)
+	}
+
+	// Long-lived tokens are left alone; signing in again reuses the registration
+	authServer.ExpiresIn = 3600
+	signIn(t, service)
+	before := len(authServer.TokenRequests())
+	if err := service.RefreshOAuthTokens(); err != nil || len(authServer.TokenRequests()) != before {
+		t.Errorf("Expected no refresh for a fresh token, got %v", err)
+	}
+	if authServer.Registrations() != 1 {
+		t.Errorf("Expected the client registration to be reused, got %d registrations", authServer.Registrations())
+	}
+
+	if err := service.SignOutOAuth("remote"); err != nil {
+		t.Fatalf("SignOutOAuth failed: %v", err)
+	}
+	if header := clientAuthorization(t, clientConfigPath); header != "" {
+		t.Errorf("Expected the header to be removed after signing out, got %q", header)
+	}
+	if status := service.OAuthStatuses()["remote"]; status.SignedIn {
+		t.Errorf("Expected signed out, got %+v", status)
+	}
+}
+
+func TestOAuth_NoDriftWhenSignedIn(t *testing.T) {
+	service, _, clientConfigPath := setupOAuthTest(t)
+	signIn(t, service)
+
+	events, err := service.DetectClientDrift(testutil.TestClientName)
+	if err != nil {
+		t.Fatalf("DetectClientDrift failed: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Expected no drift with the Authorization header written, got %+v", events)
+	}
+
+	// A client file carrying an outdated token has drifted
+	writeClientServers(t, clientConfigPath, map[string]interface{}{
+		"remote": map[string]interface{}{
+			"type":    "http",
+			"url":     service.GetMCPServers()[0].Config["url"],
+			"headers": map[string]interface{}{"Accept": "application/json", "Authorization": "Bearer stale"},
+		},
+		testutil.TestServerName: map[string]interface{}{"command": "echo"},
+	})
+	events, err = service.DetectClientDrift(testutil.TestClientName)
+	if err != nil {
+		t.Fatalf("DetectClientDrift failed: %v", err)
+	}
+	if len(events) != 1 || events[0].Server != "remote" || events[0].Kind != DriftChanged {
+		t.Errorf("Expected the stale token to be reported as changed, got %+v", events)
+	}
+}
+
+func TestOAuth_Errors(t *testing.T) {
+	service, _, _ := setupOAuthTest(t)
+
+	_, err := service.CompleteOAuth("unknown-state", "code")
+	testutil.AssertErrorContains(t, err, "unknown or expired OAuth sign-in")
+
+	_, err = service.StartOAuth(testutil.TestServerName, oauthTestRedirectURI)
+	testutil.AssertErrorContains(t, err, "does not use OAuth")
+
+	_, err = service.StartOAuth("missing", oauthTestRedirectURI)
+	testutil.AssertErrorContains(t, err, "not found")
+
+	// A code that does not match the verifier is refused by the token endpoint
+	authURL, err := service.StartOAuth("remote", oauthTestRedirectURI)
+	if err != nil {
+		t.Fatalf("StartOAuth failed: %v", err)
+	}
+	parsed, _ := url.Parse(authURL)
+	_, err = service.CompleteOAuth(parsed.Query().Get("state"), "forged-code")
+	testutil.AssertErrorContains(t, err, "invalid_grant")
+}
+
+func TestOAuth_RequiresHTTPS(t *testing.T) {
+	for rawURL, wantErr := range map[string]bool{
+		"https://auth.example.com/token": false,
+		"http://127.0.0.1:8080/token":    false,
+		"http://localhost/token":         false,
+		"http://[::1]:8080/token":        false,
+		"http://auth.example.com/token":  true,
+		"http://10.0.0.5/token":          true,
+		"ftp://auth.example.com/token":   true,
+	} {
+		if err := requireSecureURL(rawURL); (err != nil) != wantErr {
+			t.Errorf("requireSecureURL(%s) = %v, want error %v", rawURL, err, wantErr)
+		}
+	}
+
+	// Refused before anything is sent
+	oauth := NewOAuthService(filepath.Join(t.TempDir(), OAuthTokenFile))
+	_, err := oauth.discover("http://mcp.example.com/mcp", "")
+	testutil.AssertErrorContains(t, err, "must use https")
+	_, err = oauth.discover("https://mcp.example.com/mcp", "http://auth.example.com")
+	testutil.AssertErrorContains(t, err, "authorization server: 'http://auth.example.com' must use https")
+	_, err = oauth.requestToken(oauthRegistration{TokenEndpoint: "http://auth.example.com/token"}, url.Values{})
+	testutil.AssertErrorContains(t, err, "token endpoint")
+}
+
+func TestValidateServerOAuth(t *testing.T) {
+	validator := NewValidatorService()
+	tests := []struct {
+		name        string
+		server      models.MCPServer
+		errContains string
+	}{
+		{"Remote server", models.MCPServer{Name: "a", OAuth: &models.OAuthConfig{}, Config: map[string]interface{}{"httpUrl": "https://example.com/mcp"}}, ""},
+		{"No OAuth", models.MCPServer{Name: "a", Config: map[string]interface{}{"command": "echo"}}, ""},
+		{"Stdio server", models.MCPServer{Name: "a", OAuth: &models.OAuthConfig{}, Config: map[string]interface{}{"command": "echo"}}, "oauth requires a url or httpUrl transport"},
+		{"Invalid issuer", models.MCPServer{Name: "a", OAuth: &models.OAuthConfig{AuthorizationServer: "auth.example.com"}, Config: map[string]interface{}{"url": "https://example.com/sse"}}, "invalid oauth authorization_server"},
+		{"Secret without client", models.MCPServer{Name: "a", OAuth: &models.OAuthConfig{ClientSecret: "s"}, Config: map[string]interface{}{"url": "https://example.com/sse"}}, "client_secret requires client_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.validateServerOAuth(tt.server)
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			testutil.AssertErrorContains(t, err, tt.errContains)
+		})
+	}
+}
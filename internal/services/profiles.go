@@ -0,0 +1,77 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// ProfileList describes the available profiles and which one was activated last
+type ProfileList struct {
+	Names  []string `json:"names"`
+	Active string   `json:"active,omitempty"`
+}
+
+// GetProfiles returns the profile names in alphabetical order
+func (s *MCPManagerService) GetProfiles() ProfileList {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.config.Profiles))
+	for name := range s.config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return ProfileList{Names: names, Active: s.config.ActiveProfile}
+}
+
+// ActivateProfile rewrites every client's enabled list from the named profile and
+// syncs all client files. Clients the profile does not mention end up with no servers.
+func (s *MCPManagerService) ActivateProfile(name string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("activate_profile", name, &err)
+	defer s.publish(EventConfigReloaded, ConfigReloadedData{Reason: "activate_profile"}, &err)
+
+	profile, exists := s.config.Profiles[name]
+	if !exists {
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+
+	// Check references before touching any client so a bad profile changes nothing
+	if err := validateProfile(name, profile, s.config.Clients, buildServerNameSet(s.config.MCPServers)); err != nil {
+		return err
+	}
+
+	for clientName, client := range s.config.Clients {
+		enabled := make([]string, 0, len(profile[clientName]))
+		for _, serverName := range profile[clientName] {
+			enabled = addUnique(enabled, serverName)
+		}
+		client.Enabled = enabled
+	}
+	s.config.ActiveProfile = name
+
+	if err := s.saveConfig(); err != nil {
+		return err
+	}
+
+	return s.syncAllClients()
+}
+
+// validateProfile checks that a profile only references existing clients and servers
+func validateProfile(name string, profile models.Profile, clients map[string]*models.Client, serverNames map[string]bool) error {
+	for clientName, servers := range profile {
+		if _, exists := clients[clientName]; !exists {
+			return fmt.Errorf("profile '%s' references non-existent client '%s'", name, clientName)
+		}
+		for _, serverName := range servers {
+			if !serverNames[serverName] {
+				return fmt.Errorf("profile '%s' references non-existent server '%s' for client '%s'", name, serverName, clientName)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+// setupProfileTest creates a manager with two clients and a "work" and "demo" profile
+func setupProfileTest(t *testing.T) (*MCPManagerService, *models.Config, string) {
+	t.Helper()
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, testutil.TestConfigYAML)
+
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{
+			{Name: "server-a", Config: map[string]interface{}{"command": "echo"}},
+			{Name: "server-b", Config: map[string]interface{}{"command": "echo"}},
+		},
+		Clients: map[string]*models.Client{
+			"client1": {ConfigPath: filepath.Join(tempDir, "client1.json"), Enabled: []string{"server-a"}},
+			"client2": {ConfigPath: filepath.Join(tempDir, "client2.json"), Enabled: []string{"server-a"}},
+		},
+		Profiles: map[string]models.Profile{
+			"work": {"client1": {"server-a", "server-b"}},
+			"demo": {"client1": {"server-b"}, "client2": {"server-b"}},
+		},
+	}
+
+	return NewMCPManagerService(cfg, configPath), cfg, configPath
+}
+
+func TestActivateProfile(t *testing.T) {
+	service, cfg, configPath := setupProfileTest(t)
+
+	if err := service.ActivateProfile("work"); err != nil {
+		t.Fatalf("ActivateProfile failed: %v", err)
+	}
+
+	if got := cfg.Clients["client1"].Enabled; len(got) != 2 {
+		t.Errorf("client1: expected 2 enabled servers, got %v", got)
+	}
+
+	// Clients not mentioned by the profile are cleared
+	if got := cfg.Clients["client2"].Enabled; len(got) != 0 {
+		t.Errorf("client2: expected no enabled servers, got %v", got)
+	}
+
+	// Client files are synced
+	enabled, err := service.clientConfigService.GetMCPServerStatus("client1", "server-b")
+	if err != nil || !enabled {
+		t.Errorf("Expected server-b to be written to client1 file (err=%v)", err)
+	}
+
+	// Active profile is persisted
+	loaded, _, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf(testutil.ErrLoadConfigFailedFmt, err)
+	}
+	if loaded.ActiveProfile != "work" {
+		t.Errorf("Expected active profile 'work', got '%s'", loaded.ActiveProfile)
+	}
+	if len(loaded.Profiles) != 2 {
+		t.Errorf("Expected profiles to survive save, got %v", loaded.Profiles)
+	}
+}
+
+func TestActivateProfile_Errors(t *testing.T) {
+	t.Run("Unknown profile", func(t *testing.T) {
+		service, _, _ := setupProfileTest(t)
+		testutil.AssertErrorContains(t, service.ActivateProfile("missing"), "not found")
+	})
+
+	t.Run("Unknown server leaves clients untouched", func(t *testing.T) {
+		service, cfg, _ := setupProfileTest(t)
+		cfg.Profiles["broken"] = models.Profile{"client1": {"server-x"}}
+
+		testutil.AssertErrorContains(t, service.ActivateProfile("broken"), "non-existent server")
+
+		if got := cfg.Clients["client1"].Enabled; len(got) != 1 || got[0] != "server-a" {
+			t.Errorf("Expected client1 to keep its enabled list, got %v", got)
+		}
+	})
+
+	t.Run("Unknown client", func(t *testing.T) {
+		service, cfg, _ := setupProfileTest(t)
+		cfg.Profiles["broken"] = models.Profile{"client9": {"server-a"}}
+
+		testutil.AssertErrorContains(t, service.ActivateProfile("broken"), "non-existent client")
+	})
+}
+
+func TestGetProfiles(t *testing.T) {
+	service, cfg, _ := setupProfileTest(t)
+	cfg.ActiveProfile = "demo"
+
+	profiles := service.GetProfiles()
+	if len(profiles.Names) != 2 || profiles.Names[0] != "demo" || profiles.Names[1] != "work" {
+		t.Errorf("Expected sorted profile names [demo work], got %v", profiles.Names)
+	}
+	if profiles.Active != "demo" {
+		t.Errorf("Expected active profile 'demo', got '%s'", profiles.Active)
+	}
+}
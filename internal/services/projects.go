@@ -0,0 +1,295 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// projectFileKind describes where a client reads its project-scoped servers from
+type projectFileKind struct {
+	RelPath    string // Path relative to the project directory
+	ServersKey string // Top-level key holding the server entries
+}
+
+// projectFileKinds lists the supported project-scoped client files
+var projectFileKinds = map[string]projectFileKind{
+	"claude_code": {RelPath: ".mcp.json", ServersKey: "mcpServers"},
+	"cursor":      {RelPath: filepath.Join(".cursor", "mcp.json"), ServersKey: "mcpServers"},
+	"vscode":      {RelPath: filepath.Join(".vscode", "mcp.json"), ServersKey: "servers"},
+}
+
+// secretKeyPattern matches env and header names that usually carry credentials
+var secretKeyPattern = regexp.MustCompile(`(?i)(key|token|secret|password|passwd|auth|credential|cookie)`)
+
+// ProjectSyncResult lists the files written for a project and any secret warnings
+type ProjectSyncResult struct {
+	Files    []string `json:"files"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// GetProjects returns a copy of the registered projects
+func (s *MCPManagerService) GetProjects() map[string]*models.Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyProjects(s.config.Projects)
+}
+
+// AddProject registers a project directory
+func (s *MCPManagerService) AddProject(name string, project *models.Project) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("add_project", name, &err)
+	defer s.publish(EventConfigReloaded, ConfigReloadedData{Reason: "add_project"}, &err)
+
+	if _, exists := s.config.Projects[name]; exists {
+		return fmt.Errorf("project with name '%s' already exists", name)
+	}
+
+	if err := validateProject(name, project, buildServerNameSet(s.config.MCPServers)); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(config.ExpandPath(project.Path))
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("project path '%s' is not a directory", project.Path)
+	}
+
+	if s.config.Projects == nil {
+		s.config.Projects = make(map[string]*models.Project)
+	}
+	s.config.Projects[name] = project
+
+	return s.saveConfig()
+}
+
+// ToggleProjectServer enables or disables a server for a project and rewrites its
+// files. Enabling a server that would put credentials into a file git tracks or
+// would pick up is refused with an error wrapping ErrConfirmationRequired unless
+// confirmed; nothing is changed then.
+func (s *MCPManagerService) ToggleProjectServer(projectName, serverName string, enabled, confirmed bool) (result *ProjectSyncResult, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("toggle_project", projectName+"/"+serverName, &err)
+	defer s.publish(EventServerToggled, ServerToggledData{Project: projectName, Servers: []string{serverName}, Enabled: enabled}, &err)
+
+	project, exists := s.config.Projects[projectName]
+	if !exists {
+		return nil, fmt.Errorf("project '%s' not found", projectName)
+	}
+
+	if !s.serverExists(serverName) {
+		return nil, fmt.Errorf("MCP server '%s' not found", serverName)
+	}
+
+	if enabled {
+		if err := s.checkProjectSecrets(project, []string{serverName}, confirmed); err != nil {
+			return nil, err
+		}
+		project.Enabled = addUnique(project.Enabled, serverName)
+	} else {
+		project.Enabled = removeItem(project.Enabled, serverName)
+	}
+
+	if err := s.saveConfig(); err != nil {
+		return nil, err
+	}
+
+	return s.syncProject(projectName, project, true)
+}
+
+// SyncProject writes every project-scoped file of a project. Like
+// ToggleProjectServer, credentials going into files git would commit need
+// confirmed.
+func (s *MCPManagerService) SyncProject(projectName string, confirmed bool) (result *ProjectSyncResult, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("sync_project", projectName, &err)
+	defer s.publish(EventSynced, SyncedData{Project: projectName}, &err)
+
+	project, exists := s.config.Projects[projectName]
+	if !exists {
+		return nil, fmt.Errorf("project '%s' not found", projectName)
+	}
+
+	return s.syncProject(projectName, project, confirmed)
+}
+
+// syncProject writes the files of a project. Unless confirmed, nothing is written
+// when an enabled server would put credentials into a file git would commit.
+func (s *MCPManagerService) syncProject(projectName string, project *models.Project, confirmed bool) (*ProjectSyncResult, error) {
+	if err := s.checkProjectSecrets(project, project.Enabled, confirmed); err != nil {
+		return nil, fmt.Errorf("project '%s': %w", projectName, err)
+	}
+
+	projectDir := config.ExpandPath(project.Path)
+	result := &ProjectSyncResult{}
+
+	for _, kindName := range project.Clients {
+		kind := projectFileKinds[kindName]
+		filePath := filepath.Join(projectDir, kind.RelPath)
+
+		if err := s.clientConfigService.SyncServers(filePath, kind.ServersKey, "", project.Enabled, projectDir); err != nil {
+			return result, fmt.Errorf("failed to sync project '%s' (%s): %w", projectName, kindName, err)
+		}
+		result.Files = append(result.Files, filePath)
+
+		result.Warnings = append(result.Warnings, s.secretWarnings(projectDir, kind.RelPath, project.Enabled)...)
+	}
+
+	return result, nil
+}
+
+// checkProjectSecrets returns an error wrapping ErrConfirmationRequired, before
+// anything is written, when servers would put credentials into a project file
+// that git tracks or would pick up
+func (s *MCPManagerService) checkProjectSecrets(project *models.Project, servers []string, confirmed bool) error {
+	if confirmed {
+		return nil
+	}
+
+	projectDir := config.ExpandPath(project.Path)
+	var warnings []string
+	for _, kindName := range project.Clients {
+		warnings = append(warnings, s.secretWarnings(projectDir, projectFileKinds[kindName].RelPath, servers)...)
+	}
+	if len(warnings) > 0 {
+		return fmt.Errorf("%w: %s", ErrConfirmationRequired, strings.Join(warnings, "; "))
+	}
+	return nil
+}
+
+// secretWarnings reports enabled servers that would put credentials into a file
+// that git tracks, or would track on the next "git add". Entries are checked as
+// written, so variables holding credentials count as well.
+func (s *MCPManagerService) secretWarnings(projectDir, relPath string, enabled []string) []string {
+	var messages []string
+	for _, srv := range s.config.MCPServers {
+		if !contains(enabled, srv.Name) {
+			continue
+		}
+		entry, err := s.clientConfigService.serverEntry(srv.Name, projectDir)
+		if err != nil {
+			continue
+		}
+		if fields := findSecretFields(entry); len(fields) > 0 {
+			messages = append(messages, fmt.Sprintf("contains secrets from '%s' (%s)", srv.Name, strings.Join(fields, ", ")))
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	var reason string
+	switch gitFileStatus(projectDir, relPath) {
+	case gitTracked:
+		reason = "is tracked by git"
+	case gitUntracked:
+		reason = "is not ignored by git"
+	default:
+		return nil
+	}
+
+	warnings := make([]string, 0, len(messages))
+	for _, message := range messages {
+		warnings = append(warnings, fmt.Sprintf("%s %s but %s", relPath, reason, message))
+	}
+	return warnings
+}
+
+// findSecretFields returns the env and header fields that look like literal credentials.
+// Values that reference a variable (e.g. "${API_KEY}") are not secrets themselves.
+func findSecretFields(serverConfig map[string]interface{}) []string {
+	var fields []string
+	for _, section := range []string{"env", "headers"} {
+		values, ok := serverConfig[section].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, value := range values {
+			str, ok := value.(string)
+			if !ok || strings.TrimSpace(str) == "" || strings.Contains(str, "${") {
+				continue
+			}
+			if secretKeyPattern.MatchString(key) {
+				fields = append(fields, section+"."+key)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Git status of a project file
+const (
+	gitUnknown   = iota // Not a git repository, or git is unavailable
+	gitTracked          // File is in the index
+	gitUntracked        // File is neither tracked nor ignored
+	gitIgnored          // File matches a .gitignore rule
+)
+
+// gitFileStatus reports whether git tracks, ignores or would pick up a file
+func gitFileStatus(repoDir, relPath string) int {
+	if _, err := exec.LookPath("git"); err != nil {
+		return gitUnknown
+	}
+
+	if err := exec.Command("git", "-C", repoDir, "rev-parse", "--is-inside-work-tree").Run(); err != nil {
+		return gitUnknown
+	}
+
+	if err := exec.Command("git", "-C", repoDir, "ls-files", "--error-unmatch", "--", relPath).Run(); err == nil {
+		return gitTracked
+	}
+
+	if err := exec.Command("git", "-C", repoDir, "check-ignore", "-q", "--", relPath).Run(); err == nil {
+		return gitIgnored
+	}
+
+	return gitUntracked
+}
+
+// validateProject checks a project's path, file kinds and server references
+func validateProject(name string, project *models.Project, serverNames map[string]bool) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("project name cannot be empty")
+	}
+
+	if project == nil || strings.TrimSpace(project.Path) == "" {
+		return fmt.Errorf("project '%s': path cannot be empty", name)
+	}
+
+	if len(project.Clients) == 0 {
+		return fmt.Errorf("project '%s': at least one client is required", name)
+	}
+
+	for _, kind := range project.Clients {
+		if _, ok := projectFileKinds[kind]; !ok {
+			return fmt.Errorf("project '%s': unsupported client '%s' (supported: %s)", name, kind, supportedProjectClients())
+		}
+	}
+
+	for _, serverName := range project.Enabled {
+		if !serverNames[serverName] {
+			return fmt.Errorf("project '%s' references non-existent server '%s'", name, serverName)
+		}
+	}
+
+	return nil
+}
+
+func supportedProjectClients() string {
+	kinds := make([]string, 0, len(projectFileKinds))
+	for kind := range projectFileKinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return strings.Join(kinds, ", ")
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+// setupProjectTest creates a manager with one project directory using the given file kinds
+func setupProjectTest(t *testing.T, kinds ...string) (*MCPManagerService, string) {
+	t.Helper()
+	tempDir := t.TempDir()
+	projectDir := filepath.Join(tempDir, "repo")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{
+			{Name: testutil.TestServerName, Config: map[string]interface{}{"command": "echo"}},
+			{Name: "with-secret", Config: map[string]interface{}{
+				"command": "echo",
+				"env":     map[string]interface{}{"API_KEY": "sk-live-123", "LOG_LEVEL": "debug"},
+			}},
+		},
+		Clients: map[string]*models.Client{
+			testutil.TestClientName: {ConfigPath: filepath.Join(tempDir, testutil.TestClientJSON)},
+		},
+	}
+
+	service := NewMCPManagerService(cfg, filepath.Join(tempDir, testutil.TestConfigYAML))
+	if err := service.AddProject("repo", &models.Project{Path: projectDir, Clients: kinds}); err != nil {
+		t.Fatalf("AddProject failed: %v", err)
+	}
+	return service, projectDir
+}
+
+func readServersKey(t *testing.T, path, key string) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("Failed to parse %s: %v", path, err)
+	}
+	servers, _ := raw[key].(map[string]interface{})
+	return servers
+}
+
+func TestToggleProjectServer(t *testing.T) {
+	service, projectDir := setupProjectTest(t, "claude_code", "vscode")
+
+	result, err := service.ToggleProjectServer("repo", testutil.TestServerName, true, false)
+	if err != nil {
+		t.Fatalf("ToggleProjectServer failed: %v", err)
+	}
+	if len(result.Files) != 2 {
+		t.Errorf("Expected 2 files written, got %v", result.Files)
+	}
+
+	if servers := readServersKey(t, filepath.Join(projectDir, ".mcp.json"), "mcpServers"); servers[testutil.TestServerName] == nil {
+		t.Error("Server not written to .mcp.json")
+	}
+	if servers := readServersKey(t, filepath.Join(projectDir, ".vscode", "mcp.json"), "servers"); servers[testutil.TestServerName] == nil {
+		t.Error("Server not written under 'servers' in .vscode/mcp.json")
+	}
+
+	// Global client file is not touched by project toggles
+	if _, err := os.Stat(filepath.Join(filepath.Dir(projectDir), testutil.TestClientJSON)); !os.IsNotExist(err) {
+		t.Error("Project toggle wrote the global client file")
+	}
+
+	if _, err := service.ToggleProjectServer("repo", testutil.TestServerName, false, false); err != nil {
+		t.Fatalf("ToggleProjectServer failed: %v", err)
+	}
+	if servers := readServersKey(t, filepath.Join(projectDir, ".mcp.json"), "mcpServers"); servers[testutil.TestServerName] != nil {
+		t.Error("Server not removed from .mcp.json")
+	}
+}
+
+func TestAddProject_Errors(t *testing.T) {
+	service, projectDir := setupProjectTest(t, "claude_code")
+
+	tests := []struct {
+		name        string
+		projectName string
+		project     *models.Project
+		errContains string
+	}{
+		{"Duplicate name", "repo", &models.Project{Path: projectDir, Clients: []string{"cursor"}}, "already exists"},
+		{"Unsupported client", "other", &models.Project{Path: projectDir, Clients: []string{"emacs"}}, "unsupported client"},
+		{"Missing directory", "other", &models.Project{Path: filepath.Join(projectDir, "nope"), Clients: []string{"cursor"}}, "not a directory"},
+		{"Unknown server", "other", &models.Project{Path: projectDir, Clients: []string{"cursor"}, Enabled: []string{"ghost"}}, "non-existent server"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertErrorContains(t, service.AddProject(tt.projectName, tt.project), tt.errContains)
+		})
+	}
+}
+
+func TestProjectSecretWarnings(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	git := func(t *testing.T, dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	t.Run("Tracked file", func(t *testing.T) {
+		service, projectDir := setupProjectTest(t, "claude_code")
+		git(t, projectDir, "init", "-q")
+		testutil.WriteTestFile(t, filepath.Join(projectDir, ".mcp.json"), "{}")
+		git(t, projectDir, "add", ".mcp.json")
+		git(t, projectDir, "commit", "-q", "-m", "init")
+
+		// Nothing is written until the user confirms
+		_, err := service.ToggleProjectServer("repo", "with-secret", true, false)
+		if !errors.Is(err, ErrConfirmationRequired) || !strings.Contains(err.Error(), "tracked by git") || !strings.Contains(err.Error(), "env.API_KEY") {
+			t.Fatalf("Expected a confirmation naming env.API_KEY, got %v", err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(projectDir, ".mcp.json")); string(data) != "{}" {
+			t.Errorf("Expected the project file untouched, got %s", data)
+		}
+		if enabled := service.GetProjects()["repo"].Enabled; len(enabled) != 0 {
+			t.Errorf("Expected the server to stay disabled, got %v", enabled)
+		}
+		if _, err := service.SyncProject("repo", false); err != nil {
+			t.Errorf("Expected a sync without the server to need no confirmation, got %v", err)
+		}
+
+		result, err := service.ToggleProjectServer("repo", "with-secret", true, true)
+		if err != nil {
+			t.Fatalf("ToggleProjectServer failed: %v", err)
+		}
+		if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "tracked by git") || !strings.Contains(result.Warnings[0], "env.API_KEY") {
+			t.Errorf("Expected tracked-file warning naming env.API_KEY, got %v", result.Warnings)
+		}
+		if _, err := service.SyncProject("repo", false); !errors.Is(err, ErrConfirmationRequired) {
+			t.Errorf("Expected SyncProject to need a confirmation, got %v", err)
+		}
+	})
+
+	t.Run("Credential from a variable", func(t *testing.T) {
+		service, projectDir := setupProjectTest(t, "claude_code")
+		git(t, projectDir, "init", "-q")
+		service.config.Vars = map[string]string{"GH": "ghp_secret"}
+		if err := service.AddServer("from-var", map[string]interface{}{
+			"command": "echo",
+			"env":     map[string]interface{}{"GITHUB_TOKEN": "${GH}"},
+		}); err != nil {
+			t.Fatalf(testutil.ErrAddServerFailedFmt, err)
+		}
+
+		_, err := service.ToggleProjectServer("repo", "from-var", true, false)
+		if !errors.Is(err, ErrConfirmationRequired) || !strings.Contains(err.Error(), "env.GITHUB_TOKEN") {
+			t.Errorf("Expected the resolved variable to need a confirmation, got %v", err)
+		}
+	})
+
+	t.Run("Ignored file", func(t *testing.T) {
+		service, projectDir := setupProjectTest(t, "claude_code")
+		git(t, projectDir, "init", "-q")
+		testutil.WriteTestFile(t, filepath.Join(projectDir, ".gitignore"), ".mcp.json\n")
+
+		result, err := service.ToggleProjectServer("repo", "with-secret", true, false)
+		if err != nil {
+			t.Fatalf("ToggleProjectServer failed: %v", err)
+		}
+		if len(result.Warnings) != 0 {
+			t.Errorf("Expected no warnings for an ignored file, got %v", result.Warnings)
+		}
+	})
+
+	t.Run("Server without secrets", func(t *testing.T) {
+		service, projectDir := setupProjectTest(t, "claude_code")
+		git(t, projectDir, "init", "-q")
+
+		result, err := service.ToggleProjectServer("repo", testutil.TestServerName, true, false)
+		if err != nil {
+			t.Fatalf("ToggleProjectServer failed: %v", err)
+		}
+		if len(result.Warnings) != 0 {
+			t.Errorf("Expected no warnings, got %v", result.Warnings)
+		}
+	})
+}
+
+func TestFindSecretFields(t *testing.T) {
+	fields := findSecretFields(map[string]interface{}{
+		"env": map[string]interface{}{
+			"GITHUB_TOKEN": "ghp_abc",
+			"API_KEY":      "${API_KEY}",
+			"NODE_ENV":     "production",
+		},
+		"headers": map[string]interface{}{
+			"Authorization": "Bearer xyz",
+		},
+	})
+
+	if len(fields) != 2 || fields[0] != "env.GITHUB_TOKEN" || fields[1] != "headers.Authorization" {
+		t.Errorf("Unexpected secret fields: %v", fields)
+	}
+}
@@ -1,5 +1,7 @@
 package services
 
+import "sort"
+
 // contains checks if a slice contains a specific item
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -27,4 +29,14 @@ func removeItem(slice []string, item string) []string {
 		}
 	}
 	return result
+}
+
+// sortedNames returns the keys of a map in sorted order
+func sortedNames[T any](m map[string]T) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
\ No newline at end of file
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+)
+
+// BulkResult reports which servers and clients a bulk operation touched
+type BulkResult struct {
+	Servers []string `json:"servers"`
+	Clients []string `json:"clients"`
+	Enabled bool     `json:"enabled"`
+}
+
+// GetTags returns every tag used by at least one server, sorted
+func (s *MCPManagerService) GetTags() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var tags []string
+	for _, srv := range s.config.MCPServers {
+		for _, tag := range srv.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// SetServerTags replaces the tags of a server
+func (s *MCPManagerService) SetServerTags(serverName string, tags []string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("set_tags", serverName, &err)
+	defer s.publish(EventConfigReloaded, ConfigReloadedData{Reason: "set_tags"}, &err)
+
+	for i := range s.config.MCPServers {
+		if s.config.MCPServers[i].Name == serverName {
+			s.config.MCPServers[i].Tags = config.ParseTags(tags)
+			return s.saveConfig()
+		}
+	}
+	return fmt.Errorf("MCP server '%s' not found", serverName)
+}
+
+// MatchServers resolves a selector to server names in config order.
+// Supported selectors: "*" (all servers), "tag:<tag>" and "name:<server>" or a bare server name.
+func (s *MCPManagerService) MatchServers(selector string) ([]string, error) {
+	selector = strings.TrimSpace(selector)
+	if selector == "" {
+		return nil, fmt.Errorf("selector cannot be empty")
+	}
+
+	var matched []string
+	for _, srv := range s.config.MCPServers {
+		switch {
+		case selector == "*":
+		case strings.HasPrefix(selector, "tag:"):
+			if !srv.HasTag(strings.TrimPrefix(selector, "tag:")) {
+				continue
+			}
+		case srv.Name != strings.TrimPrefix(selector, "name:"):
+			continue
+		}
+		matched = append(matched, srv.Name)
+	}
+
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no servers match selector '%s'", selector)
+	}
+	return matched, nil
+}
+
+// BulkSetEnabled enables or disables every server matching the selector for the given
+// clients (all clients when none are given). config.yaml is saved once and each
+// affected client file is written once.
+func (s *MCPManagerService) BulkSetEnabled(selector string, clientNames []string, enabled bool) (result *BulkResult, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("bulk_toggle", selector, &err)
+
+	servers, err := s.MatchServers(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(clientNames) == 0 {
+		for name := range s.config.Clients {
+			clientNames = append(clientNames, name)
+		}
+		sort.Strings(clientNames)
+	}
+
+	for _, clientName := range clientNames {
+		if _, exists := s.config.Clients[clientName]; !exists {
+			return nil, fmt.Errorf("client '%s' not found", clientName)
+		}
+	}
+
+	for _, clientName := range clientNames {
+		client := s.config.Clients[clientName]
+		for _, serverName := range servers {
+			if enabled {
+				client.Enabled = addUnique(client.Enabled, serverName)
+			} else {
+				client.Enabled = removeItem(client.Enabled, serverName)
+			}
+		}
+	}
+
+	if err := s.saveConfig(); err != nil {
+		return nil, err
+	}
+
+	for _, clientName := range clientNames {
+		if err := s.clientConfigService.SyncClient(clientName); err != nil {
+			return nil, fmt.Errorf("failed to sync client '%s': %w", clientName, err)
+		}
+	}
+
+	s.events.Publish(EventServerToggled, ServerToggledData{Clients: clientNames, Servers: servers, Enabled: enabled})
+	return &BulkResult{Servers: servers, Clients: clientNames, Enabled: enabled}, nil
+}
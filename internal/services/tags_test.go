@@ -0,0 +1,172 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+// setupTagTest creates a manager with tagged servers and two clients
+func setupTagTest(t *testing.T) (*MCPManagerService, *models.Config, string) {
+	t.Helper()
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, testutil.TestConfigYAML)
+
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{
+			{Name: "docs-a", Tags: []string{"docs"}, Config: map[string]interface{}{"command": "echo"}},
+			{Name: "git", Tags: []string{"vcs"}, Config: map[string]interface{}{"command": "echo"}},
+			{Name: "docs-b", Tags: []string{"docs", "remote"}, Config: map[string]interface{}{"url": testutil.TestExampleURL}},
+		},
+		Clients: map[string]*models.Client{
+			"client1": {ConfigPath: filepath.Join(tempDir, "client1.json")},
+			"client2": {ConfigPath: filepath.Join(tempDir, "client2.json"), Enabled: []string{"git"}},
+		},
+	}
+
+	return NewMCPManagerService(cfg, configPath), cfg, configPath
+}
+
+func TestMatchServers(t *testing.T) {
+	service, _, _ := setupTagTest(t)
+
+	tests := []struct {
+		selector string
+		want     []string
+		wantErr  bool
+	}{
+		{selector: "tag:docs", want: []string{"docs-a", "docs-b"}},
+		{selector: "tag:vcs", want: []string{"git"}},
+		{selector: "*", want: []string{"docs-a", "git", "docs-b"}},
+		{selector: "name:git", want: []string{"git"}},
+		{selector: "docs-b", want: []string{"docs-b"}},
+		{selector: "tag:missing", wantErr: true},
+		{selector: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := service.MatchServers(tt.selector)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MatchServers failed: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBulkSetEnabled(t *testing.T) {
+	t.Run("Enable tag for one client", func(t *testing.T) {
+		service, cfg, _ := setupTagTest(t)
+
+		result, err := service.BulkSetEnabled("tag:docs", []string{"client1"}, true)
+		if err != nil {
+			t.Fatalf("BulkSetEnabled failed: %v", err)
+		}
+		if len(result.Servers) != 2 || len(result.Clients) != 1 {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+
+		if got := cfg.Clients["client1"].Enabled; len(got) != 2 {
+			t.Errorf("Expected 2 enabled servers for client1, got %v", got)
+		}
+		if got := cfg.Clients["client2"].Enabled; len(got) != 1 {
+			t.Errorf("client2 should be untouched, got %v", got)
+		}
+
+		rawConfig, _ := service.clientConfigService.ReadClientConfig("client1")
+		servers := rawConfig["mcpServers"].(map[string]interface{})
+		if servers["docs-a"] == nil || servers["docs-b"] == nil {
+			t.Errorf("Expected docs servers in client1 file, got %v", servers)
+		}
+
+		// Tags are manager metadata and never reach client files
+		if entry := servers["docs-a"].(map[string]interface{}); entry[models.ServerTagsKey] != nil {
+			t.Error("Tags leaked into the client file")
+		}
+	})
+
+	t.Run("Disable for all clients", func(t *testing.T) {
+		service, cfg, _ := setupTagTest(t)
+
+		result, err := service.BulkSetEnabled("tag:vcs", nil, false)
+		if err != nil {
+			t.Fatalf("BulkSetEnabled failed: %v", err)
+		}
+		if len(result.Clients) != 2 {
+			t.Errorf("Expected all clients, got %v", result.Clients)
+		}
+		if got := cfg.Clients["client2"].Enabled; len(got) != 0 {
+			t.Errorf("Expected git disabled for client2, got %v", got)
+		}
+	})
+
+	t.Run("Unknown client", func(t *testing.T) {
+		service, _, _ := setupTagTest(t)
+		_, err := service.BulkSetEnabled("tag:docs", []string{"ghost"}, true)
+		testutil.AssertErrorContains(t, err, "not found")
+	})
+}
+
+func TestTags_SaveLoadRoundTrip(t *testing.T) {
+	service, _, configPath := setupTagTest(t)
+
+	if err := service.SetServerTags("git", []string{"vcs", "local"}); err != nil {
+		t.Fatalf("SetServerTags failed: %v", err)
+	}
+
+	loaded, _, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf(testutil.ErrLoadConfigFailedFmt, err)
+	}
+
+	for _, srv := range loaded.MCPServers {
+		if _, exists := srv.Config[models.ServerTagsKey]; exists {
+			t.Errorf("Server '%s': tags left in passthrough config", srv.Name)
+		}
+		if srv.Name == "git" && (len(srv.Tags) != 2 || !srv.HasTag("local")) {
+			t.Errorf("Expected tags [vcs local] for git, got %v", srv.Tags)
+		}
+	}
+
+	if tags := service.GetTags(); len(tags) != 4 {
+		t.Errorf("Expected 4 distinct tags, got %v", tags)
+	}
+}
+
+func TestAddServer_Tags(t *testing.T) {
+	service, cfg, _ := setupTagTest(t)
+
+	err := service.AddServer("tagged", map[string]interface{}{
+		"command":            "echo",
+		models.ServerTagsKey: []interface{}{"docs"},
+	})
+	if err != nil {
+		t.Fatalf(testutil.ErrAddServerFailedFmt, err)
+	}
+
+	added := cfg.MCPServers[len(cfg.MCPServers)-1]
+	if !added.HasTag("docs") {
+		t.Errorf("Expected tag 'docs', got %v", added.Tags)
+	}
+	if _, exists := added.Config[models.ServerTagsKey]; exists {
+		t.Error("Tags left in passthrough config")
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// GetTemplates returns a copy of the server templates by name
+func (s *MCPManagerService) GetTemplates() map[string]*models.ServerTemplate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyTemplates(s.config.Templates)
+}
+
+// TemplateServers returns the servers rendered from a template, in config order
+func (s *MCPManagerService) TemplateServers(templateName string) []string {
+	var names []string
+	for _, srv := range s.config.MCPServers {
+		if srv.Template != nil && srv.Template.Name == templateName {
+			names = append(names, srv.Name)
+		}
+	}
+	return names
+}
+
+// SetTemplate adds or replaces a template. Servers rendered from it keep their
+// config until RerenderTemplateServers is called; they are returned so the
+// caller can offer to do so. Like AddServer, a template that would give them a
+// command outside the policy allowlist needs confirmed.
+func (s *MCPManagerService) SetTemplate(name string, tmpl *models.ServerTemplate, confirmed bool) (servers []string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("set_template", name, &err)
+	defer s.publish(EventConfigReloaded, ConfigReloadedData{Reason: "set_template"}, &err)
+
+	if err := ValidateTemplate(name, tmpl); err != nil {
+		return nil, err
+	}
+
+	// Servers that no longer render are reported when re-rendered
+	for _, srv := range s.config.MCPServers {
+		if srv.Template == nil || srv.Template.Name != name {
+			continue
+		}
+		rendered, err := renderTemplate(name, tmpl, srv.Template.Params)
+		if err != nil {
+			continue
+		}
+		if err := s.validator.checkServerPolicy(rendered); err != nil && (!confirmed || !errors.Is(err, ErrConfirmationRequired)) {
+			return nil, fmt.Errorf("server '%s' rendered from template '%s': %w", srv.Name, name, err)
+		}
+	}
+
+	if s.config.Templates == nil {
+		s.config.Templates = make(map[string]*models.ServerTemplate)
+	}
+	s.config.Templates[name] = tmpl
+
+	if err := s.saveConfig(); err != nil {
+		return nil, err
+	}
+	return s.TemplateServers(name), nil
+}
+
+// DeleteTemplate removes a template that no server was rendered from
+func (s *MCPManagerService) DeleteTemplate(name string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("delete_template", name, &err)
+	defer s.publish(EventConfigReloaded, ConfigReloadedData{Reason: "delete_template"}, &err)
+
+	if _, exists := s.config.Templates[name]; !exists {
+		return fmt.Errorf("template '%s' not found", name)
+	}
+	if servers := s.TemplateServers(name); len(servers) > 0 {
+		return fmt.Errorf("template '%s' is used by %d servers", name, len(servers))
+	}
+
+	delete(s.config.Templates, name)
+	return s.saveConfig()
+}
+
+// AddServerFromTemplate renders a template with the given parameter values and
+// adds the result as a new server that remembers its template. Like AddServer,
+// commands outside the policy allowlist need confirmed.
+func (s *MCPManagerService) AddServerFromTemplate(templateName, serverName string, params map[string]string, confirmed bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmpl, exists := s.config.Templates[templateName]
+	if !exists {
+		return fmt.Errorf("template '%s' not found", templateName)
+	}
+
+	serverConfig, err := s.validator.RenderServerTemplate(serverName, templateName, tmpl, params, confirmed)
+	if err != nil {
+		return err
+	}
+
+	refParams := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		refParams[key] = value
+	}
+	serverConfig[models.ServerTemplateKey] = map[string]interface{}{"name": templateName, "params": refParams}
+
+	return s.addServerLocked(serverName, serverConfig, confirmed)
+}
+
+// RerenderTemplateServers renders the named servers (all servers made from the
+// template when none are named) again with their stored parameter values and
+// rewrites the client files. Nothing changes if any server fails to render;
+// commands outside the policy allowlist need confirmed, as for AddServer.
+func (s *MCPManagerService) RerenderTemplateServers(templateName string, serverNames []string, confirmed bool) (rendered []string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("rerender_template", templateName, &err)
+	defer s.publish(EventConfigReloaded, ConfigReloadedData{Reason: "rerender_template"}, &err)
+
+	tmpl, exists := s.config.Templates[templateName]
+	if !exists {
+		return nil, fmt.Errorf("template '%s' not found", templateName)
+	}
+
+	derived := s.TemplateServers(templateName)
+	if len(serverNames) == 0 {
+		serverNames = derived
+	}
+	for _, name := range serverNames {
+		if !contains(derived, name) {
+			return nil, fmt.Errorf("server '%s' was not created from template '%s'", name, templateName)
+		}
+	}
+
+	configs := make(map[string]map[string]interface{}, len(serverNames))
+	for _, srv := range s.config.MCPServers {
+		if contains(serverNames, srv.Name) {
+			serverConfig, err := s.validator.RenderServerTemplate(srv.Name, templateName, tmpl, srv.Template.Params, confirmed)
+			if err != nil {
+				return nil, err
+			}
+			configs[srv.Name] = serverConfig
+		}
+	}
+
+	for i := range s.config.MCPServers {
+		if serverConfig, ok := configs[s.config.MCPServers[i].Name]; ok {
+			s.config.MCPServers[i].Config = serverConfig
+		}
+	}
+
+	if err := s.saveConfig(); err != nil {
+		return nil, err
+	}
+	if err := s.syncAllClients(); err != nil {
+		return nil, err
+	}
+
+	return serverNames, nil
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+// testTemplate renders an echo server with one parameter of every type
+func testTemplate() *models.ServerTemplate {
+	return &models.ServerTemplate{
+		Description: "Echo server",
+		Params: []models.TemplateParam{
+			{Name: "dir", Type: models.ParamTypePath, Required: true},
+			{Name: "timeout", Type: models.ParamTypeInt, Default: "30000"},
+			{Name: "trust", Type: models.ParamTypeBool},
+			{Name: "mode", Type: models.ParamTypeEnum, Values: []string{"ro", "rw"}, Default: "ro"},
+		},
+		Config: map[string]interface{}{
+			"command": "echo",
+			"args":    []interface{}{"--dir", "{{.dir}}", "--mode={{.mode}}"},
+			"env":     map[string]interface{}{"MODE": "{{.mode}}"},
+			"timeout": "{{ .timeout }}",
+			"trust":   "{{.trust}}",
+		},
+	}
+}
+
+// setupTemplateTest creates a manager with the test template and one client
+func setupTemplateTest(t *testing.T) (*MCPManagerService, string) {
+	t.Helper()
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, testutil.TestConfigYAML)
+
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{},
+		Templates:  map[string]*models.ServerTemplate{"echo": testTemplate()},
+		Clients: map[string]*models.Client{
+			"client1": {ConfigPath: filepath.Join(tempDir, "client1.json")},
+		},
+	}
+
+	return NewMCPManagerService(cfg, configPath), configPath
+}
+
+func TestRenderServerTemplate(t *testing.T) {
+	validator := NewValidatorService()
+
+	got, err := validator.RenderServerTemplate("srv", "echo", testTemplate(), map[string]string{"dir": "/srv/data", "trust": "true"}, false)
+	if err != nil {
+		t.Fatalf("RenderServerTemplate failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"command": "echo",
+		"args":    []interface{}{"--dir", "/srv/data", "--mode=ro"},
+		"env":     map[string]interface{}{"MODE": "ro"},
+		"timeout": 30000,
+		"trust":   true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected render:\n got %v\nwant %v", got, want)
+	}
+
+	errorTests := []struct {
+		name   string
+		params map[string]string
+		want   string
+	}{
+		{name: "Missing required", params: map[string]string{}, want: "'dir' is required"},
+		{name: "Invalid int", params: map[string]string{"dir": "/d", "timeout": "soon"}, want: "must be an integer"},
+		{name: "Invalid bool", params: map[string]string{"dir": "/d", "trust": "maybe"}, want: "true or false"},
+		{name: "Invalid enum", params: map[string]string{"dir": "/d", "mode": "wo"}, want: "must be one of ro, rw"},
+		{name: "Undeclared parameter", params: map[string]string{"dir": "/d", "typo": "x"}, want: "no parameter 'typo'"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validator.RenderServerTemplate("srv", "echo", testTemplate(), tt.params, false)
+			testutil.AssertErrorContains(t, err, tt.want)
+		})
+	}
+
+	t.Run("Rendered config is validated", func(t *testing.T) {
+		tmpl := &models.ServerTemplate{Config: map[string]interface{}{"args": []interface{}{"x"}}}
+		_, err := validator.RenderServerTemplate("srv", "broken", tmpl, nil, false)
+		testutil.AssertErrorContains(t, err, "is invalid")
+	})
+}
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl *models.ServerTemplate
+		want string
+	}{
+		{name: "No config", tmpl: &models.ServerTemplate{}, want: "has no config"},
+		{name: "Bad parameter name", tmpl: &models.ServerTemplate{
+			Params: []models.TemplateParam{{Name: "my-dir"}}, Config: map[string]interface{}{"command": "echo"},
+		}, want: "invalid parameter name"},
+		{name: "Enum without values", tmpl: &models.ServerTemplate{
+			Params: []models.TemplateParam{{Name: "mode", Type: models.ParamTypeEnum}}, Config: map[string]interface{}{"command": "echo"},
+		}, want: "needs values"},
+		{name: "Unknown type", tmpl: &models.ServerTemplate{
+			Params: []models.TemplateParam{{Name: "n", Type: "float"}}, Config: map[string]interface{}{"command": "echo"},
+		}, want: "unknown type 'float'"},
+		{name: "Invalid default", tmpl: &models.ServerTemplate{
+			Params: []models.TemplateParam{{Name: "n", Type: models.ParamTypeInt, Default: "x"}}, Config: map[string]interface{}{"command": "echo"},
+		}, want: "invalid default"},
+		{name: "Template syntax", tmpl: &models.ServerTemplate{
+			Config: map[string]interface{}{"command": "echo {{.x"},
+		}, want: "command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertErrorContains(t, ValidateTemplate("t", tt.tmpl), tt.want)
+		})
+	}
+
+	if err := ValidateTemplate("echo", testTemplate()); err != nil {
+		t.Errorf("Expected a valid template, got %v", err)
+	}
+}
+
+func TestAddServerFromTemplate(t *testing.T) {
+	service, configPath := setupTemplateTest(t)
+
+	if err := service.AddServerFromTemplate("echo", "data", map[string]string{"dir": "/srv/data"}, false); err != nil {
+		t.Fatalf("AddServerFromTemplate failed: %v", err)
+	}
+
+	servers := service.GetMCPServers()
+	if len(servers) != 1 || servers[0].Template == nil || servers[0].Template.Name != "echo" ||
+		servers[0].Template.Params["dir"] != "/srv/data" {
+		t.Fatalf("Expected a server remembering its template, got %+v", servers)
+	}
+	if _, leaked := servers[0].Config[models.ServerTemplateKey]; leaked {
+		t.Error("Template reference should not be part of the server config")
+	}
+
+	// The template and its reference survive a save and reload
+	loaded, _, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	if loaded.Templates["echo"] == nil || len(loaded.Templates["echo"].Params) != 4 {
+		t.Errorf("Expected the template after reload, got %+v", loaded.Templates)
+	}
+	if len(loaded.MCPServers) != 1 || loaded.MCPServers[0].Template == nil || loaded.MCPServers[0].Template.Params["dir"] != "/srv/data" {
+		t.Errorf("Expected the template reference after reload, got %+v", loaded.MCPServers)
+	}
+	if timeout := loaded.MCPServers[0].Config["timeout"]; timeout != 30000 {
+		t.Errorf("Expected the typed timeout after reload, got %v", timeout)
+	}
+
+	err = service.AddServerFromTemplate("echo", "other", map[string]string{}, false)
+	testutil.AssertErrorContains(t, err, "is required")
+	err = service.AddServerFromTemplate("missing", "other", nil, false)
+	testutil.AssertErrorContains(t, err, "not found")
+}
+
+func TestSetTemplateAndRerender(t *testing.T) {
+	service, configPath := setupTemplateTest(t)
+	clientPath := filepath.Join(filepath.Dir(configPath), "client1.json")
+
+	if err := service.AddServerFromTemplate("echo", "data", map[string]string{"dir": "/srv/data"}, false); err != nil {
+		t.Fatalf("AddServerFromTemplate failed: %v", err)
+	}
+	if err := service.ToggleClientMCPServer("client1", "data", true); err != nil {
+		t.Fatalf("ToggleClientMCPServer failed: %v", err)
+	}
+
+	updated := testTemplate()
+	updated.Config["args"] = []interface{}{"--root", "{{.dir}}"}
+	servers, err := service.SetTemplate("echo", updated, false)
+	if err != nil {
+		t.Fatalf("SetTemplate failed: %v", err)
+	}
+	if !reflect.DeepEqual(servers, []string{"data"}) {
+		t.Errorf("Expected the derived server, got %v", servers)
+	}
+	if args := service.GetMCPServers()[0].Config["args"]; !reflect.DeepEqual(args, []interface{}{"--dir", "/srv/data", "--mode=ro"}) {
+		t.Errorf("Server should keep its config until re-rendered, got %v", args)
+	}
+
+	rendered, err := service.RerenderTemplateServers("echo", nil, false)
+	if err != nil {
+		t.Fatalf("RerenderTemplateServers failed: %v", err)
+	}
+	if !reflect.DeepEqual(rendered, []string{"data"}) {
+		t.Errorf("Expected the re-rendered server, got %v", rendered)
+	}
+	if args := service.GetMCPServers()[0].Config["args"]; !reflect.DeepEqual(args, []interface{}{"--root", "/srv/data"}) {
+		t.Errorf("Expected the new args, got %v", args)
+	}
+
+	data, err := os.ReadFile(clientPath)
+	if err != nil {
+		t.Fatalf("Failed to read client file: %v", err)
+	}
+	if !strings.Contains(string(data), "--root") {
+		t.Errorf("Expected the client file to be rewritten, got %s", data)
+	}
+
+	_, err = service.RerenderTemplateServers("echo", []string{"other"}, false)
+	testutil.AssertErrorContains(t, err, "was not created from template")
+
+	_, err = service.SetTemplate("echo", &models.ServerTemplate{}, false)
+	testutil.AssertErrorContains(t, err, "has no config")
+}
+
+func TestDeleteTemplate(t *testing.T) {
+	service, _ := setupTemplateTest(t)
+
+	if err := service.AddServerFromTemplate("echo", "data", map[string]string{"dir": "/srv"}, false); err != nil {
+		t.Fatalf("AddServerFromTemplate failed: %v", err)
+	}
+	testutil.AssertErrorContains(t, service.DeleteTemplate("echo"), "is used by 1 servers")
+
+	if _, err := service.SetTemplate("unused", testTemplate(), false); err != nil {
+		t.Fatalf("SetTemplate failed: %v", err)
+	}
+	if err := service.DeleteTemplate("unused"); err != nil {
+		t.Fatalf("DeleteTemplate failed: %v", err)
+	}
+	if _, exists := service.GetTemplates()["unused"]; exists {
+		t.Error("Expected the template to be deleted")
+	}
+	testutil.AssertErrorContains(t, service.DeleteTemplate("unused"), "not found")
+}
+
+func TestTemplates_CommandPolicy(t *testing.T) {
+	service, _ := setupTemplateTest(t)
+	service.validator.SetPolicy(&models.PolicyConfig{AllowedCommands: []string{"echo"}, DeniedArgs: []string{"^--exec"}})
+
+	if err := service.AddServerFromTemplate("echo", "data", map[string]string{"dir": "/srv/data"}, false); err != nil {
+		t.Fatalf("AddServerFromTemplate failed: %v", err)
+	}
+
+	updated := testTemplate()
+	updated.Config["command"] = "cat"
+	if _, err := service.SetTemplate("echo", updated, false); !errors.Is(err, ErrConfirmationRequired) {
+		t.Fatalf("Expected a confirmation for a command outside the allowlist, got %v", err)
+	}
+	if _, err := service.SetTemplate("echo", updated, true); err != nil {
+		t.Fatalf("SetTemplate failed after confirming: %v", err)
+	}
+
+	if _, err := service.RerenderTemplateServers("echo", nil, false); !errors.Is(err, ErrConfirmationRequired) {
+		t.Fatalf("Expected a confirmation before re-rendering, got %v", err)
+	}
+	if command := service.GetMCPServers()[0].Config["command"]; command != "echo" {
+		t.Errorf("Server should keep its command without confirmation, got %v", command)
+	}
+	if _, err := service.RerenderTemplateServers("echo", nil, true); err != nil {
+		t.Fatalf("RerenderTemplateServers failed after confirming: %v", err)
+	}
+	if command := service.GetMCPServers()[0].Config["command"]; command != "cat" {
+		t.Errorf("Expected the confirmed command, got %v", command)
+	}
+
+	err := service.AddServerFromTemplate("echo", "other", map[string]string{"dir": "/srv"}, false)
+	if !errors.Is(err, ErrConfirmationRequired) {
+		t.Errorf("Expected a confirmation when adding from the template, got %v", err)
+	}
+	if err := service.AddServerFromTemplate("echo", "other", map[string]string{"dir": "/srv"}, true); err != nil {
+		t.Errorf("AddServerFromTemplate failed after confirming: %v", err)
+	}
+
+	// Denied arguments are refused even when confirmed
+	denied := testTemplate()
+	denied.Config["args"] = []interface{}{"--exec={{.dir}}"}
+	_, err = service.SetTemplate("echo", denied, true)
+	testutil.AssertErrorContains(t, err, "blocked by the policy")
+}
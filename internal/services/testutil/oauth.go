@@ -0,0 +1,231 @@
+package testutil
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// OAuthServer is a stand-in for a remote MCP server and its OAuth 2.1
+// authorization server. It publishes protected resource and authorization
+// server metadata, accepts dynamic client registrations and issues tokens for
+// the authorization code flow with PKCE (S256 only) and for refresh tokens.
+// The MCP endpoint answers 401 without one of its access tokens.
+type OAuthServer struct {
+	*httptest.Server
+
+	ExpiresIn int // Lifetime of issued access tokens in seconds; 0 issues tokens without expiry
+
+	mu       sync.Mutex
+	clients  map[string]string     // Client ID -> registered redirect URI
+	codes    map[string]url.Values // Code -> client_id, redirect_uri, code_challenge, resource
+	access   map[string]bool       // Valid access tokens
+	refresh  map[string]string     // Refresh token -> client ID
+	issued   int
+	requests map[string][]url.Values // Path -> form values of token requests
+}
+
+// NewOAuthServer starts the stand-in; it is closed when the test ends
+func NewOAuthServer(t *testing.T) *OAuthServer {
+	t.Helper()
+	s := &OAuthServer{
+		ExpiresIn: 3600,
+		clients:   make(map[string]string),
+		codes:     make(map[string]url.Values),
+		access:    make(map[string]bool),
+		refresh:   make(map[string]string),
+		requests:  make(map[string][]url.Values),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", s.resource)
+	mux.HandleFunc("/.well-known/oauth-protected-resource/mcp", s.resourceMetadata)
+	mux.HandleFunc("/.well-known/oauth-authorization-server/auth", s.serverMetadata)
+	mux.HandleFunc("/auth/register", s.register)
+	mux.HandleFunc("/auth/authorize", s.authorize)
+	mux.HandleFunc("/auth/token", s.token)
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// ResourceURL is the MCP endpoint to configure as the server URL
+func (s *OAuthServer) ResourceURL() string {
+	return s.URL + "/mcp"
+}
+
+// Issuer is the URL of the authorization server
+func (s *OAuthServer) Issuer() string {
+	return s.URL + "/auth"
+}
+
+// ValidToken reports whether an access token was issued and not replaced
+func (s *OAuthServer) ValidToken(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.access[token]
+}
+
+// Registrations returns the number of registered clients
+func (s *OAuthServer) Registrations() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+// TokenRequests returns the form values of every token request so far
+func (s *OAuthServer) TokenRequests() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]url.Values(nil), s.requests["/auth/token"]...)
+}
+
+func (s *OAuthServer) resource(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if len(token) > 7 && s.ValidToken(token[7:]) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer resource_metadata="%s/.well-known/oauth-protected-resource/mcp"`, s.URL))
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+func (s *OAuthServer) resourceMetadata(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"resource":              s.ResourceURL(),
+		"authorization_servers": []string{s.Issuer()},
+		"scopes_supported":      []string{"mcp:read", "mcp:write"},
+	})
+}
+
+func (s *OAuthServer) serverMetadata(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                           s.Issuer(),
+		"authorization_endpoint":           s.Issuer() + "/authorize",
+		"token_endpoint":                   s.Issuer() + "/token",
+		"registration_endpoint":            s.Issuer() + "/register",
+		"response_types_supported":         []string{"code"},
+		"grant_types_supported":            []string{"authorization_code", "refresh_token"},
+		"code_challenge_methods_supported": []string{"S256"},
+	})
+}
+
+func (s *OAuthServer) register(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		RedirectURIs []string `json:"redirect_uris"`
+		AuthMethod   string   `json:"token_endpoint_auth_method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.RedirectURIs) != 1 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_client_metadata"})
+		return
+	}
+
+	s.mu.Lock()
+	clientID := fmt.Sprintf("client-%d", len(s.clients)+1)
+	s.clients[clientID] = request.RedirectURIs[0]
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"client_id":                  clientID,
+		"redirect_uris":              request.RedirectURIs,
+		"token_endpoint_auth_method": request.AuthMethod,
+	})
+}
+
+// authorize signs the user in at once and redirects back with a code
+func (s *OAuthServer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	registered, known := s.clients[query.Get("client_id")]
+	s.mu.Unlock()
+	switch {
+	case !known || registered != query.Get("redirect_uri"):
+		http.Error(w, "unknown client or redirect URI", http.StatusBadRequest)
+		return
+	case query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "":
+		http.Error(w, "PKCE with S256 required", http.StatusBadRequest)
+		return
+	case query.Get("resource") != s.ResourceURL():
+		http.Error(w, "unexpected resource "+query.Get("resource"), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.issued++
+	code := fmt.Sprintf("code-%d", s.issued)
+	s.codes[code] = query
+	s.mu.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *OAuthServer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	form := r.PostForm
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[r.URL.Path] = append(s.requests[r.URL.Path], form)
+
+	switch form.Get("grant_type") {
+	case "authorization_code":
+		authorization, found := s.codes[form.Get("code")]
+		delete(s.codes, form.Get("code"))
+		challenge := sha256.Sum256([]byte(form.Get("code_verifier")))
+		if !found || authorization.Get("client_id") != form.Get("client_id") ||
+			authorization.Get("redirect_uri") != form.Get("redirect_uri") ||
+			authorization.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "code or verifier mismatch"})
+			return
+		}
+	case "refresh_token":
+		clientID, found := s.refresh[form.Get("refresh_token")]
+		if !found || clientID != form.Get("client_id") {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "unknown refresh token"})
+			return
+		}
+		delete(s.refresh, form.Get("refresh_token")) // Rotated
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	s.issued++
+	accessToken := fmt.Sprintf("access-%d", s.issued)
+	refreshToken := fmt.Sprintf("refresh-%d", s.issued)
+	s.access = map[string]bool{accessToken: true}
+	s.refresh[refreshToken] = form.Get("client_id")
+
+	response := map[string]interface{}{
+		"access_token":  accessToken,
+		"token_type":    "Bearer",
+		"refresh_token": refreshToken,
+		"scope":         "mcp:read mcp:write",
+	}
+	if s.ExpiresIn > 0 {
+		response["expires_in"] = s.ExpiresIn
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
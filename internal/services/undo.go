@@ -0,0 +1,202 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+)
+
+// maxUndoSteps bounds the undo history kept in memory
+const maxUndoSteps = 50
+
+// ErrNothingToUndo and ErrNothingToRedo are returned when a history stack is empty
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// undoStep is the config.yaml content to go back to, with a description of the
+// operation it reverses
+type undoStep struct {
+	label  string
+	config []byte
+}
+
+// UndoStatus tells the UI what undo and redo would do
+type UndoStatus struct {
+	Undo string `json:"undo,omitempty"` // Operation reverted by the next undo
+	Redo string `json:"redo,omitempty"` // Operation re-applied by the next redo
+}
+
+// recordUndo pushes the state before a save onto the undo stack and clears the
+// redo stack; callers hold s.mu
+func (s *MCPManagerService) recordUndo(before []byte) {
+	if before == nil || bytes.Equal(before, s.shared.saved) {
+		return
+	}
+
+	// Compare both states as parsed from YAML so loader defaults do not show up as changes
+	label := "change configuration"
+	previous, previousErr := config.ParseConfig(before)
+	current, currentErr := config.ParseConfig(s.shared.saved)
+	if previousErr == nil && currentErr == nil {
+		label = describeConfigChange(configSnapshot(previous), configSnapshot(current))
+	}
+
+	s.shared.undo = append(s.shared.undo, undoStep{label: label, config: before})
+	if overflow := len(s.shared.undo) - maxUndoSteps; overflow > 0 {
+		s.shared.undo = s.shared.undo[overflow:]
+	}
+	s.shared.redo = nil
+}
+
+// GetUndoStatus describes the operations the next undo and redo would affect
+func (s *MCPManagerService) GetUndoStatus() UndoStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.undoStatus()
+}
+
+func (s *MCPManagerService) undoStatus() UndoStatus {
+	var status UndoStatus
+	if n := len(s.shared.undo); n > 0 {
+		status.Undo = s.shared.undo[n-1].label
+	}
+	if n := len(s.shared.redo); n > 0 {
+		status.Redo = s.shared.redo[n-1].label
+	}
+	return status
+}
+
+// Undo restores config.yaml to the state before the last operation and rewrites
+// the client and project files to match. It returns the reverted operation.
+func (s *MCPManagerService) Undo() (label string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// label is only known on return
+	defer func() { s.audit("undo", label, &err) }()
+	defer s.publish(EventConfigReloaded, ConfigReloadedData{Reason: "undo"}, &err)
+
+	n := len(s.shared.undo)
+	if n == 0 {
+		return "", ErrNothingToUndo
+	}
+	step := s.shared.undo[n-1]
+
+	current := s.shared.saved
+	if err := s.restoreConfig(step.config, "undo "+step.label); err != nil {
+		return "", err
+	}
+
+	s.shared.undo = s.shared.undo[:n-1]
+	s.shared.redo = append(s.shared.redo, undoStep{label: step.label, config: current})
+	return step.label, s.syncEverything()
+}
+
+// Redo re-applies the last undone operation. It returns the re-applied operation.
+func (s *MCPManagerService) Redo() (label string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer func() { s.audit("redo", label, &err) }()
+	defer s.publish(EventConfigReloaded, ConfigReloadedData{Reason: "redo"}, &err)
+
+	n := len(s.shared.redo)
+	if n == 0 {
+		return "", ErrNothingToRedo
+	}
+	step := s.shared.redo[n-1]
+
+	current := s.shared.saved
+	if err := s.restoreConfig(step.config, "redo "+step.label); err != nil {
+		return "", err
+	}
+
+	s.shared.redo = s.shared.redo[:n-1]
+	s.shared.undo = append(s.shared.undo, undoStep{label: step.label, config: current})
+	return step.label, s.syncEverything()
+}
+
+// restoreConfig replaces the configuration with saved config.yaml content and
+// writes it without touching the undo history
+func (s *MCPManagerService) restoreConfig(data []byte, message string) error {
+	restored, err := config.ParseConfig(data)
+	if err != nil {
+		return fmt.Errorf("failed to restore config: %w", err)
+	}
+
+	// Replace the contents rather than the pointer: the client config service
+	// and the handlers hold on to it
+	*s.config = *restored
+	s.validator.SetPolicy(s.config.Policy)
+	s.validator.SetVars(s.config.Vars)
+	s.validator.SetValidation(s.config.Validation)
+
+	return s.writeConfig(message)
+}
+
+// ReloadConfig picks up changes made to config.yaml outside the manager, e.g. in
+// an editor, and syncs the client and project files. It reports whether the file
+// differed from what the manager last wrote; an invalid file is left for the user
+// to fix and the current configuration is kept.
+func (s *MCPManagerService) ReloadConfig() (reloaded bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.configPath
+	if path == "" {
+		path = config.DefaultConfigPath
+	}
+	data, err := config.ReadConfigData(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read config file '%s': %w", path, err)
+	}
+	if bytes.Equal(data, s.shared.saved) {
+		return false, nil
+	}
+
+	defer s.audit("reload", "", &err)
+	defer s.publish(EventConfigReloaded, ConfigReloadedData{Reason: "reload"}, &err)
+
+	parsed, err := config.ParseConfig(data)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse config file '%s': %w", path, err)
+	}
+	if err := s.validator.ValidateConfig(parsed); err != nil {
+		return false, fmt.Errorf("config file '%s' is invalid: %w", path, err)
+	}
+
+	// Replace the contents rather than the pointer: the client config service
+	// and the handlers hold on to it
+	*s.config = *parsed
+	s.validator.SetPolicy(s.config.Policy)
+	s.validator.SetVars(s.config.Vars)
+	s.validator.SetValidation(s.config.Validation)
+
+	before := s.shared.saved
+	s.shared.saved = data
+	s.recordUndo(before)
+
+	return true, s.syncEverything()
+}
+
+// syncEverything rewrites all client and project files from the configuration.
+// Files that already match are left alone, and so are project files that would
+// get credentials git might commit; those need an explicit SyncProject.
+func (s *MCPManagerService) syncEverything() error {
+	if err := s.syncAllClients(); err != nil {
+		return err
+	}
+	for name, project := range s.config.Projects {
+		if _, err := s.syncProject(name, project, false); err != nil {
+			if errors.Is(err, ErrConfirmationRequired) {
+				s.logger().Warn("Project files not written", "project", name, "error", err)
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+// clientServers returns the server names in a client file
+func clientServers(t *testing.T, path string) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read client file: %v", err)
+	}
+	var content map[string]interface{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		t.Fatalf("Failed to parse client file: %v", err)
+	}
+	servers, _ := content["mcpServers"].(map[string]interface{})
+	return servers
+}
+
+func TestUndoRedo(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, testutil.TestConfigYAML)
+	clientPath := filepath.Join(tempDir, testutil.TestClientJSON)
+
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{
+			{Name: "server-a", Config: map[string]interface{}{"command": "echo"}},
+			{Name: "server-b", Config: map[string]interface{}{"command": "echo"}},
+		},
+		Clients: map[string]*models.Client{
+			testutil.TestClientName: {ConfigPath: clientPath, Enabled: []string{"server-a"}},
+		},
+	}
+	if err := config.SaveConfig(cfg, configPath); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	service := NewMCPManagerService(cfg, configPath)
+	if err := service.SyncAllClients(); err != nil {
+		t.Fatalf("SyncAllClients failed: %v", err)
+	}
+
+	if _, err := service.Undo(); err != ErrNothingToUndo {
+		t.Errorf("Expected ErrNothingToUndo before any change, got %v", err)
+	}
+
+	if err := service.ToggleClientMCPServer(testutil.TestClientName, "server-b", true); err != nil {
+		t.Fatalf("ToggleClientMCPServer failed: %v", err)
+	}
+	if _, exists := clientServers(t, clientPath)["server-b"]; !exists {
+		t.Fatal("Expected server-b in client file after toggle")
+	}
+
+	want := UndoStatus{Undo: "enable server-b for " + testutil.TestClientName}
+	if status := service.GetUndoStatus(); status != want {
+		t.Errorf("Expected status %+v, got %+v", want, status)
+	}
+
+	t.Run("Undo restores config and client file", func(t *testing.T) {
+		label, err := service.Undo()
+		if err != nil {
+			t.Fatalf("Undo failed: %v", err)
+		}
+		if label != want.Undo {
+			t.Errorf("Expected undone operation %q, got %q", want.Undo, label)
+		}
+
+		if _, exists := clientServers(t, clientPath)["server-b"]; exists {
+			t.Error("Expected server-b removed from client file after undo")
+		}
+		reloaded, _, err := config.LoadConfig(configPath)
+		if err != nil {
+			t.Fatalf("Failed to reload config: %v", err)
+		}
+		if enabled := reloaded.Clients[testutil.TestClientName].Enabled; len(enabled) != 1 || enabled[0] != "server-a" {
+			t.Errorf("Expected only server-a enabled after undo, got %v", enabled)
+		}
+		if status := service.GetUndoStatus(); status.Undo != "" || status.Redo != want.Undo {
+			t.Errorf("Expected only redo available, got %+v", status)
+		}
+	})
+
+	t.Run("Redo re-applies the change", func(t *testing.T) {
+		if _, err := service.Redo(); err != nil {
+			t.Fatalf("Redo failed: %v", err)
+		}
+		if _, exists := clientServers(t, clientPath)["server-b"]; !exists {
+			t.Error("Expected server-b back in client file after redo")
+		}
+		if _, err := service.Redo(); err != ErrNothingToRedo {
+			t.Errorf("Expected ErrNothingToRedo, got %v", err)
+		}
+	})
+
+	t.Run("Undo and redo are audited with the operation", func(t *testing.T) {
+		entries, err := service.GetAuditLog(2)
+		if err != nil {
+			t.Fatalf("GetAuditLog failed: %v", err)
+		}
+		if len(entries) != 2 || entries[0].Action != "redo" || entries[1].Action != "undo" {
+			t.Fatalf("Expected redo and undo entries, got %+v", entries)
+		}
+		for _, entry := range entries {
+			if entry.Target != want.Undo {
+				t.Errorf("Expected %s target %q, got %q", entry.Action, want.Undo, entry.Target)
+			}
+		}
+	})
+
+	t.Run("A new change clears redo", func(t *testing.T) {
+		if _, err := service.Undo(); err != nil {
+			t.Fatalf("Undo failed: %v", err)
+		}
+		if err := service.ReorderServers([]string{"server-b", "server-a"}); err != nil {
+			t.Fatalf("ReorderServers failed: %v", err)
+		}
+		if status := service.GetUndoStatus(); status.Redo != "" || status.Undo != "reorder servers" {
+			t.Errorf("Expected redo cleared after a new change, got %+v", status)
+		}
+	})
+}
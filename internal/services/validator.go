@@ -2,62 +2,335 @@ package services
 
 import (
 	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
 
+	"github.com/vlazic/mcp-server-manager/internal/logging"
 	"github.com/vlazic/mcp-server-manager/internal/models"
 )
 
-type ValidatorService struct{}
+type ValidatorService struct {
+	policy *models.PolicyConfig // Applied to servers added through ValidateMCPServerConfig
+	vars   map[string]string    // Expanded in commands and URLs before they are checked
+
+	validation *models.ValidationConfig // Severity of machine-dependent problems
+	deepChecks bool                     // Check packages and images even when validation.deep_checks is off
+	errorsOnly bool                     // Skip deep checks, which never find errors
+}
 
 func NewValidatorService() *ValidatorService {
 	return &ValidatorService{}
 }
 
-// ValidateConfig validates the entire configuration
+// SetPolicy sets the command policy enforced for newly added servers
+func (v *ValidatorService) SetPolicy(policy *models.PolicyConfig) {
+	v.policy = policy
+}
+
+// SetVars sets the vars section expanded in server configs before they are checked
+func (v *ValidatorService) SetVars(vars map[string]string) {
+	v.vars = vars
+}
+
+// SetValidation sets the severity of problems such as missing commands
+func (v *ValidatorService) SetValidation(validation *models.ValidationConfig) {
+	v.validation = validation
+}
+
+// SetDeepChecks turns on the package and image checks of stdio servers
+// regardless of validation.deep_checks, as "validate -deep" does
+func (v *ValidatorService) SetDeepChecks(enabled bool) {
+	v.deepChecks = enabled
+}
+
+// deepChecksEnabled reports whether stdio servers get the package and image
+// checks of checkCommandDetails
+func (v *ValidatorService) deepChecksEnabled() bool {
+	return !v.errorsOnly && (v.deepChecks || (v.validation != nil && v.validation.DeepChecks))
+}
+
+// forErrors returns a copy of the validator for checks that only look for
+// errors. Deep checks find warnings and infos only but may wait seconds for
+// docker, and saving validates with the manager locked.
+func (v *ValidatorService) forErrors() *ValidatorService {
+	quick := *v
+	quick.errorsOnly = true
+	return &quick
+}
+
+// ValidateConfig validates the entire configuration and returns the first
+// problem of error severity; warnings and infos do not fail validation
 func (v *ValidatorService) ValidateConfig(config *models.Config) error {
-	if err := v.validateBasicConfig(config); err != nil {
-		return err
+	for _, problem := range v.forErrors().CheckConfig(config) {
+		if problem.Severity == models.SeverityError {
+			return problem.Err
+		}
 	}
+	return nil
+}
+
+// ConfigProblem is a problem found by CheckConfig and the YAML path it applies
+// to, e.g. mcpServers.fs; empty when it concerns the config as a whole
+type ConfigProblem struct {
+	Path     string
+	Severity string // error, warning or info
+	Err      error
+}
 
-	if err := v.validateMCPServers(config.MCPServers); err != nil {
-		return err
+// CheckConfig runs every check of ValidateConfig and returns all problems found
+// of any severity, in the order ValidateConfig reports them
+func (v *ValidatorService) CheckConfig(config *models.Config) []ConfigProblem {
+	var problems []ConfigProblem
+	add := func(path string, err error) {
+		if err != nil {
+			problems = append(problems, ConfigProblem{Path: path, Severity: models.SeverityError, Err: err})
+		}
 	}
 
+	add(v.validateBasicConfig(config))
+	add("vars", validateVars(config.Vars))
+	add("validation.missing_commands", validateValidationConfig(config.Validation))
+
+	// Check the servers against the vars and severities of the config being validated
+	scoped := *v
+	scoped.vars = config.Vars
+	scoped.validation = config.Validation
+	v = &scoped
+
+	for _, server := range config.MCPServers {
+		for _, err := range v.checkServerConfig(server.Name, server.Config) {
+			severity := v.severity(err)
+			if severity == models.SeverityError {
+				err = fmt.Errorf("invalid MCP server '%s': %w", server.Name, err)
+			}
+			problems = append(problems, ConfigProblem{Path: "mcpServers." + server.Name, Severity: severity, Err: err})
+		}
+		add("mcpServers."+server.Name+".oauth", v.validateServerOAuth(server))
+	}
+
+	add("templates", validateTemplates(config.Templates, config.MCPServers))
+
 	serverNames := buildServerNameSet(config.MCPServers)
 
-	if err := v.validateClients(config.Clients, serverNames); err != nil {
-		return err
+	for _, clientName := range sortedNames(config.Clients) {
+		client := config.Clients[clientName]
+		if err := v.ValidateClient(clientName, client); err != nil {
+			add("clients."+clientName, fmt.Errorf("invalid client '%s': %w", clientName, err))
+			continue
+		}
+		add("clients."+clientName+".enabled", validateClientServerReferences(clientName, client, serverNames))
+	}
+
+	add("watch", validateWatchConfig(config.Watch))
+	add("health", validateHealthConfig(config.Health))
+	add("notifications", validateNotificationsConfig(config.Notifications))
+	add("catalog", validateCatalogConfig(config.Catalog))
+
+	if _, err := logging.NewLogger(config.Logging, io.Discard); err != nil {
+		add("logging", err)
+	}
+
+	add(validateListenConfig(config))
+	add("policy", validatePolicyConfig(config.Policy))
+
+	for _, name := range sortedNames(config.Projects) {
+		add("projects."+name, validateProject(name, config.Projects[name], serverNames))
+	}
+
+	for _, name := range sortedNames(config.Profiles) {
+		if strings.TrimSpace(name) == "" {
+			add("profiles", fmt.Errorf("profile name cannot be empty"))
+			continue
+		}
+		add("profiles."+name, validateProfile(name, config.Profiles[name], config.Clients, serverNames))
+	}
+
+	return problems
+}
+
+// validateValidationConfig checks the configured severities
+func validateValidationConfig(validation *models.ValidationConfig) error {
+	if validation == nil {
+		return nil
+	}
+
+	switch validation.MissingCommands {
+	case "", models.SeverityError, models.SeverityWarning, models.SeverityInfo:
+		return nil
+	}
+	return fmt.Errorf("invalid validation missing_commands '%s': must be %s, %s or %s",
+		validation.MissingCommands, models.SeverityError, models.SeverityWarning, models.SeverityInfo)
+}
+
+// validateWatchConfig checks the optional client watcher settings
+func validateWatchConfig(watch *models.WatchConfig) error {
+	if watch == nil {
+		return nil
+	}
+
+	switch watch.Mode {
+	case "", WatchModeAutoHeal, WatchModeNotify:
+	default:
+		return fmt.Errorf("invalid watch mode '%s': must be %s or %s", watch.Mode, WatchModeAutoHeal, WatchModeNotify)
+	}
+
+	if watch.IntervalMs < 0 || watch.DebounceMs < 0 {
+		return fmt.Errorf("watch interval and debounce cannot be negative")
 	}
 
 	return nil
 }
 
-// validateBasicConfig checks port and existence of servers/clients
-func (v *ValidatorService) validateBasicConfig(config *models.Config) error {
-	if config.ServerPort < 1 || config.ServerPort > 65535 {
-		return fmt.Errorf("invalid server port: %d", config.ServerPort)
+func validateHealthConfig(health *models.HealthConfig) error {
+	if health == nil {
+		return nil
 	}
 
-	if len(config.MCPServers) == 0 {
-		return fmt.Errorf("no MCP servers configured")
+	if health.IntervalSeconds < 0 || health.TimeoutSeconds < 0 {
+		return fmt.Errorf("health interval and timeout cannot be negative")
 	}
 
-	if len(config.Clients) == 0 {
-		return fmt.Errorf("no clients configured")
+	return nil
+}
+
+func validateNotificationsConfig(notifications *models.NotificationsConfig) error {
+	if notifications == nil {
+		return nil
+	}
+
+	for i, webhook := range notifications.Webhooks {
+		parsed, err := url.Parse(webhook.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("webhook %d: invalid URL '%s': must be an http or https URL", i+1, webhook.URL)
+		}
+		if webhook.MaxRetries != nil && *webhook.MaxRetries < 0 {
+			return fmt.Errorf("webhook %d: max_retries cannot be negative", i+1)
+		}
+		if err := validateEventFilter(webhook.Events); err != nil {
+			return fmt.Errorf("webhook %d: %w", i+1, err)
+		}
+	}
+
+	if notifications.Desktop != nil {
+		if err := validateEventFilter(notifications.Desktop.Events); err != nil {
+			return fmt.Errorf("desktop notifications: %w", err)
+		}
 	}
 
 	return nil
 }
 
-// validateMCPServers validates all MCP server configurations
-func (v *ValidatorService) validateMCPServers(servers []models.MCPServer) error {
-	for _, server := range servers {
-		if err := v.ValidateMCPServerConfig(server.Name, server.Config); err != nil {
-			return fmt.Errorf("invalid MCP server '%s': %w", server.Name, err)
+func validateCatalogConfig(catalog *models.CatalogConfig) error {
+	if catalog == nil || !strings.Contains(catalog.RegistryURL, "://") {
+		return nil
+	}
+
+	parsed, err := url.Parse(catalog.RegistryURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("catalog: invalid registry_url '%s': must be an http or https URL or a file path", catalog.RegistryURL)
+	}
+	return nil
+}
+
+func validateEventFilter(events []string) error {
+	for _, event := range events {
+		if !contains(eventTypes, event) {
+			return fmt.Errorf("unknown event type '%s': must be one of %s", event, strings.Join(eventTypes, ", "))
 		}
 	}
 	return nil
 }
 
+// minTokenLength rejects API tokens that are easy to guess
+const minTokenLength = 16
+
+// ValidateListenConfig checks the listener settings and credentials. Binding to anything
+// other than a loopback address exposes the manager to the network and requires auth;
+// a Unix socket replaces the TCP listener and is protected by its file mode instead.
+func (v *ValidatorService) ValidateListenConfig(config *models.Config) error {
+	_, err := validateListenConfig(config)
+	return err
+}
+
+// validateListenConfig implements ValidateListenConfig and also returns the
+// YAML path of the setting at fault
+func validateListenConfig(config *models.Config) (string, error) {
+	if tlsCfg := config.TLS; tlsCfg != nil && (tlsCfg.CertFile == "") != (tlsCfg.KeyFile == "") {
+		return "tls", fmt.Errorf("tls cert_file and key_file must be set together")
+	}
+
+	if socket := config.UnixSocket; socket != nil {
+		if strings.TrimSpace(socket.Path) == "" {
+			return "unix_socket.path", fmt.Errorf("unix_socket path cannot be empty")
+		}
+		if _, err := models.ParseSocketMode(socket.Mode); err != nil {
+			return "unix_socket.mode", err
+		}
+	}
+
+	if auth := config.Auth; auth != nil {
+		for _, token := range auth.Tokens {
+			if len(strings.TrimSpace(token)) < minTokenLength {
+				return "auth.tokens", fmt.Errorf("auth tokens must be at least %d characters long", minTokenLength)
+			}
+		}
+
+		if auth.PasswordHash != "" && !strings.HasPrefix(auth.PasswordHash, "$2") {
+			return "auth.password_hash", fmt.Errorf("auth password_hash must be a bcrypt hash")
+		}
+
+		if auth.SessionTTLHours < 0 {
+			return "auth.session_ttl_hours", fmt.Errorf("auth session_ttl_hours cannot be negative")
+		}
+	}
+
+	if config.PublicURL != "" {
+		u, err := url.Parse(config.PublicURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "public_url", fmt.Errorf("public_url '%s' must be an absolute http or https URL", config.PublicURL)
+		}
+	}
+
+	if config.UnixSocket != nil || config.BindAddress == "" || IsLoopbackAddress(config.BindAddress) {
+		return "", nil
+	}
+
+	if !config.Auth.Enabled() {
+		return "bind_address", fmt.Errorf("bind_address '%s' is reachable from the network: configure auth tokens or a password, or bind to 127.0.0.1", config.BindAddress)
+	}
+
+	return "", nil
+}
+
+// IsLoopbackAddress reports whether a bind address only accepts local connections
+func IsLoopbackAddress(address string) bool {
+	if address == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(address, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// validateBasicConfig checks port and existence of servers/clients and returns
+// the YAML path of the problem
+func (v *ValidatorService) validateBasicConfig(config *models.Config) (string, error) {
+	if config.ServerPort < 1 || config.ServerPort > 65535 {
+		return "server_port", fmt.Errorf("invalid server port: %d", config.ServerPort)
+	}
+
+	if len(config.MCPServers) == 0 {
+		return "mcpServers", fmt.Errorf("no MCP servers configured")
+	}
+
+	if len(config.Clients) == 0 {
+		return "clients", fmt.Errorf("no clients configured")
+	}
+
+	return "", nil
+}
+
 // buildServerNameSet creates a map of server names for lookup
 func buildServerNameSet(servers []models.MCPServer) map[string]bool {
 	serverNames := make(map[string]bool)
@@ -67,20 +340,6 @@ func buildServerNameSet(servers []models.MCPServer) map[string]bool {
 	return serverNames
 }
 
-// validateClients validates all client configurations and their server references
-func (v *ValidatorService) validateClients(clients map[string]*models.Client, serverNames map[string]bool) error {
-	for clientName, client := range clients {
-		if err := v.ValidateClient(clientName, client); err != nil {
-			return fmt.Errorf("invalid client '%s': %w", clientName, err)
-		}
-
-		if err := validateClientServerReferences(clientName, client, serverNames); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 // validateClientServerReferences checks that all enabled servers exist
 func validateClientServerReferences(clientName string, client *models.Client, serverNames map[string]bool) error {
 	for _, serverName := range client.Enabled {
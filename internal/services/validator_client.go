@@ -17,6 +17,12 @@ func (v *ValidatorService) ValidateClient(clientName string, client *models.Clie
 		return fmt.Errorf("client config path cannot be empty")
 	}
 
+	switch client.Format {
+	case "", models.ClientFormatJSON, models.ClientFormatJSONC:
+	default:
+		return fmt.Errorf("unsupported client format '%s' (supported: %s, %s)", client.Format, models.ClientFormatJSON, models.ClientFormatJSONC)
+	}
+
 	// Don't require the directory to exist - we'll create it if needed
 	return nil
 }
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// commandFinding is a result of the deep command checks (validation.deep_checks),
+// reported with its own severity
+type commandFinding struct {
+	severity string
+	message  string
+}
+
+func (f *commandFinding) Error() string {
+	return f.message
+}
+
+func findingf(severity, format string, args ...interface{}) *commandFinding {
+	return &commandFinding{severity: severity, message: fmt.Sprintf(format, args...)}
+}
+
+// deepCheckTTL is how long the result of a deep check is reused; the server
+// table re-validates on every refresh and docker lookups are slow
+const deepCheckTTL = time.Minute
+
+// deepCheckCache remembers deep check results by runner and package
+var deepCheckCache = struct {
+	sync.Mutex
+	entries map[string]deepCheckEntry
+}{entries: make(map[string]deepCheckEntry)}
+
+type deepCheckEntry struct {
+	findings []error
+	checked  time.Time
+}
+
+// cachedDeepCheck runs check unless it ran for key within deepCheckTTL
+func cachedDeepCheck(key string, check func() []error) []error {
+	deepCheckCache.Lock()
+	entry, found := deepCheckCache.entries[key]
+	deepCheckCache.Unlock()
+	if found && time.Since(entry.checked) < deepCheckTTL {
+		return entry.findings
+	}
+
+	findings := check()
+	deepCheckCache.Lock()
+	deepCheckCache.entries[key] = deepCheckEntry{findings: findings, checked: time.Now()}
+	deepCheckCache.Unlock()
+	return findings
+}
+
+// checkCommandDetails looks past the command at what it starts: the package an
+// npx or uvx server runs and the image of a docker server. The command itself
+// must already be available.
+func checkCommandDetails(command string, args []string) []error {
+	for _, arg := range args {
+		if variablePattern.MatchString(arg) {
+			return nil // Checked once the variables are known
+		}
+	}
+
+	switch runner := strings.TrimSuffix(filepath.Base(command), ".exe"); runner {
+	case "npx":
+		return checkNpxPackage(args)
+	case "uvx":
+		return checkUvxPackage(args)
+	case "docker":
+		return checkDockerImage(args)
+	}
+	return nil
+}
+
+// checkExecutable explains why a command given as a path cannot be run
+func checkExecutable(command string) error {
+	info, err := os.Stat(command)
+	switch {
+	case os.IsNotExist(err):
+		return &commandError{command: command, reason: "does not exist"}
+	case err != nil:
+		return &commandError{command: command, reason: fmt.Sprintf("cannot be checked: %v", err)}
+	case info.IsDir():
+		return &commandError{command: command, reason: "is a directory"}
+	case runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0:
+		return &commandError{command: command, reason: fmt.Sprintf("is not executable (mode %04o)", info.Mode().Perm())}
+	}
+	return nil
+}
+
+// commandError reports a stdio command that cannot be run on this machine; it
+// matches ErrCommandNotFound so it gets the severity of missing commands
+type commandError struct {
+	command string
+	reason  string
+}
+
+func (e *commandError) Error() string {
+	return fmt.Sprintf("command '%s' %s", e.command, e.reason)
+}
+
+func (e *commandError) Is(target error) bool {
+	return target == ErrCommandNotFound
+}
+
+// positionalArgs splits runner arguments into the values of the given options
+// and the positional arguments up to the first one (the package or image);
+// options in valueOptions take a value, as the next argument or after "="
+func positionalArgs(args []string, valueOptions map[string]bool) (options map[string][]string, first string) {
+	options = make(map[string][]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return options, arg
+		}
+		if arg == "--" {
+			if i+1 < len(args) {
+				return options, args[i+1]
+			}
+			return options, ""
+		}
+		if name, value, found := strings.Cut(arg, "="); found {
+			options[name] = append(options[name], value)
+			continue
+		}
+		if valueOptions[arg] && i+1 < len(args) {
+			options[arg] = append(options[arg], args[i+1])
+			i++
+		}
+	}
+	return options, ""
+}
+
+// npmNamePattern matches npm package names, optionally scoped
+var npmNamePattern = regexp.MustCompile(`^(@[a-z0-9-~][a-z0-9-._~]*/)?[a-z0-9-~][a-z0-9-._~]*$`)
+
+// exactVersionPattern matches a pinned version like 1.2.3 or 1.2.3-beta.1
+var exactVersionPattern = regexp.MustCompile(`^v?\d+\.\d+\.\d+([-+][0-9A-Za-z.-]+)?$`)
+
+var npxValueOptions = map[string]bool{"-p": true, "--package": true, "-c": true, "--call": true}
+
+// checkNpxPackage checks the packages an npx server runs: given with -p or
+// --package, otherwise the first positional argument
+func checkNpxPackage(args []string) []error {
+	options, first := positionalArgs(args, npxValueOptions)
+	specs := append(options["-p"], options["--package"]...)
+	if len(specs) == 0 && len(options["-c"]) == 0 && len(options["--call"]) == 0 {
+		if first == "" {
+			return []error{findingf(models.SeverityWarning, "npx is given no package to run")}
+		}
+		specs = []string{first}
+	}
+
+	var findings []error
+	for _, spec := range specs {
+		findings = append(findings, cachedDeepCheck("npx\x00"+spec, func() []error {
+			return checkNpmSpec(spec)
+		})...)
+	}
+	return findings
+}
+
+func checkNpmSpec(spec string) []error {
+	// Git URLs, tarballs and local folders are fetched as written
+	if strings.Contains(spec, ":") || strings.HasPrefix(spec, ".") || strings.HasPrefix(spec, "/") || strings.HasPrefix(spec, "~") {
+		return nil
+	}
+
+	name, version := spec, ""
+	if at := strings.LastIndex(spec, "@"); at > 0 {
+		name, version = spec[:at], spec[at+1:]
+	}
+	if len(name) > 214 || !npmNamePattern.MatchString(name) {
+		return []error{findingf(models.SeverityWarning, "npm package '%s' is not a valid package name", spec)}
+	}
+	if strings.HasSuffix(spec, "@") {
+		return []error{findingf(models.SeverityWarning, "npm package '%s' has an empty version", spec)}
+	}
+
+	versions := cachedNpmVersions(name)
+	switch {
+	case len(versions) == 0:
+		return []error{findingf(models.SeverityInfo, "npm package '%s' is not in the npx cache; npx downloads it on first start", spec)}
+	case exactVersionPattern.MatchString(version) && !contains(versions, strings.TrimPrefix(version, "v")):
+		return []error{findingf(models.SeverityInfo, "npm package '%s' is cached in version %s only; npx downloads %s on first start",
+			name, strings.Join(versions, ", "), version)}
+	}
+	return nil
+}
+
+// cachedNpmVersions lists the versions of a package in the npx cache
+// ($npm_config_cache/_npx, by default ~/.npm/_npx)
+func cachedNpmVersions(name string) []string {
+	cacheDir := os.Getenv("npm_config_cache")
+	if cacheDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		cacheDir = filepath.Join(home, ".npm")
+	}
+
+	manifests, _ := filepath.Glob(filepath.Join(cacheDir, "_npx", "*", "node_modules", filepath.FromSlash(name), "package.json"))
+	var versions []string
+	for _, manifest := range manifests {
+		data, err := os.ReadFile(manifest)
+		if err != nil {
+			continue
+		}
+		var pkg struct {
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(data, &pkg) == nil && pkg.Version != "" {
+			versions = addUnique(versions, pkg.Version)
+		}
+	}
+	return versions
+}
+
+// pythonSpecPattern matches a PyPI requirement: name, extras and a version
+// given as @1.0 or with a comparison like ==1.0
+var pythonSpecPattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?)(\[[A-Za-z0-9._,-]+\])?(@[A-Za-z0-9.*+!_-]+|\s*(==|>=|<=|~=|!=|>|<)\s*[A-Za-z0-9.*+!_-]+(\s*,\s*(==|>=|<=|~=|!=|>|<)\s*[A-Za-z0-9.*+!_-]+)*)?$`)
+
+var uvxValueOptions = map[string]bool{
+	"--from": true, "--with": true, "--with-editable": true, "--with-requirements": true,
+	"-p": true, "--python": true, "--index": true, "--index-url": true, "--extra-index-url": true,
+	"--default-index": true, "--directory": true, "--project": true, "--cache-dir": true,
+	"--config-file": true, "--python-preference": true, "-c": true, "--constraint": true,
+}
+
+// checkUvxPackage checks the package a uvx server runs: given with --from,
+// otherwise the first positional argument
+func checkUvxPackage(args []string) []error {
+	options, spec := positionalArgs(args, uvxValueOptions)
+	if from := options["--from"]; len(from) > 0 {
+		spec = from[len(from)-1]
+	}
+	if spec == "" {
+		return []error{findingf(models.SeverityWarning, "uvx is given no package to run")}
+	}
+
+	return cachedDeepCheck("uvx\x00"+spec, func() []error {
+		return checkPythonSpec(spec)
+	})
+}
+
+func checkPythonSpec(spec string) []error {
+	// VCS URLs, archives and local projects are fetched as written
+	if strings.Contains(spec, "://") || strings.HasPrefix(spec, "git+") || strings.HasPrefix(spec, ".") || strings.HasPrefix(spec, "/") {
+		return nil
+	}
+
+	match := pythonSpecPattern.FindStringSubmatch(spec)
+	if match == nil {
+		return []error{findingf(models.SeverityWarning, "Python package '%s' is not a valid requirement", spec)}
+	}
+
+	if !pythonPackageCached(match[1]) {
+		return []error{findingf(models.SeverityInfo, "Python package '%s' is not in the uv cache; uvx downloads it on first start", spec)}
+	}
+	return nil
+}
+
+// pythonNameSeparators matches the runs of separators PEP 503 normalizes
+var pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePythonName applies PEP 503 normalization with underscores, as in
+// the names of .dist-info directories
+func normalizePythonName(name string) string {
+	return strings.ToLower(pythonNameSeparators.ReplaceAllString(name, "_"))
+}
+
+// pythonPackageCached reports whether uv has the package unpacked in its cache
+// or installed as a tool
+func pythonPackageCached(name string) bool {
+	normalized := normalizePythonName(name)
+
+	var dirs []string
+	if toolDir := os.Getenv("UV_TOOL_DIR"); toolDir != "" {
+		dirs = append(dirs, filepath.Join(toolDir, "*", "lib", "*", "site-packages"))
+	}
+	for _, dir := range uvCacheDirs() {
+		dirs = append(dirs, filepath.Join(dir, "archive-v0", "*"))
+	}
+	if dataHome := xdgDir("XDG_DATA_HOME", ".local", "share"); dataHome != "" {
+		dirs = append(dirs, filepath.Join(dataHome, "uv", "tools", "*", "lib", "*", "site-packages"))
+	}
+
+	for _, dir := range dirs {
+		entries, _ := filepath.Glob(filepath.Join(dir, "*.dist-info"))
+		for _, entry := range entries {
+			distName := strings.SplitN(strings.TrimSuffix(filepath.Base(entry), ".dist-info"), "-", 2)[0]
+			if normalizePythonName(distName) == normalized {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// uvCacheDirs returns where uv keeps its cache: $UV_CACHE_DIR, else the
+// platform cache directory
+func uvCacheDirs() []string {
+	if dir := os.Getenv("UV_CACHE_DIR"); dir != "" {
+		return []string{dir}
+	}
+	var dirs []string
+	if cacheHome := xdgDir("XDG_CACHE_HOME", ".cache"); cacheHome != "" {
+		dirs = append(dirs, filepath.Join(cacheHome, "uv"))
+	}
+	if runtime.GOOS == "darwin" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dirs = append(dirs, filepath.Join(home, "Library", "Caches", "uv"))
+		}
+	}
+	return dirs
+}
+
+// xdgDir returns an XDG base directory or its default below the home directory
+func xdgDir(env string, defaultPath ...string) string {
+	if dir := os.Getenv(env); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(append([]string{home}, defaultPath...)...)
+}
+
+var dockerValueOptions = map[string]bool{
+	"-e": true, "--env": true, "--env-file": true, "-v": true, "--volume": true, "--mount": true,
+	"--name": true, "-p": true, "--publish": true, "--network": true, "--net": true, "-w": true,
+	"--workdir": true, "-u": true, "--user": true, "--entrypoint": true, "-l": true, "--label": true,
+	"--platform": true, "--pull": true, "-h": true, "--hostname": true, "--add-host": true,
+	"--cap-add": true, "--cap-drop": true, "--device": true, "--dns": true, "-m": true, "--memory": true,
+	"--cpus": true, "--restart": true, "--log-driver": true, "--log-opt": true, "--tmpfs": true,
+	"--security-opt": true, "--ulimit": true, "--shm-size": true, "--runtime": true, "--gpus": true,
+	"--group-add": true, "--ipc": true, "--pid": true, "--stop-signal": true, "--stop-timeout": true,
+}
+
+// imageReferencePattern matches docker image references like
+// ghcr.io/org/image:tag or image@sha256:<digest>
+var imageReferencePattern = regexp.MustCompile(`^[a-zA-Z0-9]+([._-][a-zA-Z0-9]+)*(:[0-9]+)?(/[a-z0-9]+([._-]+[a-z0-9]+)*)*(:[A-Za-z0-9_][A-Za-z0-9_.-]{0,127})?(@sha256:[a-f0-9]{64})?$`)
+
+// dockerImageExists asks the local docker daemon for an image; replaced in tests
+var dockerImageExists = func(image string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{.Id}}", image).CombinedOutput()
+	if err == nil {
+		return true, nil
+	}
+	if strings.Contains(strings.ToLower(string(output)), "no such image") {
+		return false, nil
+	}
+	if ctx.Err() != nil {
+		return false, fmt.Errorf("docker did not answer within 5s")
+	}
+	return false, fmt.Errorf("%s", strings.TrimSpace(string(output)))
+}
+
+// checkDockerImage checks the image of "docker run" (or "docker container run")
+func checkDockerImage(args []string) []error {
+	if len(args) > 1 && args[0] == "container" {
+		args = args[1:]
+	}
+	if len(args) == 0 || args[0] != "run" {
+		return nil
+	}
+
+	options, image := positionalArgs(args[1:], dockerValueOptions)
+	if image == "" {
+		return []error{findingf(models.SeverityWarning, "docker run is given no image")}
+	}
+	if !imageReferencePattern.MatchString(image) {
+		return []error{findingf(models.SeverityWarning, "docker image '%s' is not a valid image reference", image)}
+	}
+	pull := ""
+	if values := options["--pull"]; len(values) > 0 {
+		pull = values[len(values)-1]
+	}
+
+	return cachedDeepCheck("docker\x00"+image+"\x00"+pull, func() []error {
+		exists, err := dockerImageExists(image)
+		switch {
+		case err != nil:
+			return []error{findingf(models.SeverityInfo, "docker image '%s' could not be checked: %v", image, err)}
+		case exists:
+			return nil
+		case pull == "never":
+			return []error{findingf(models.SeverityWarning, "docker image '%s' is not available locally and --pull=never prevents pulling it", image)}
+		}
+		return []error{findingf(models.SeverityInfo, "docker image '%s' is not available locally; docker pulls it on first start", image)}
+	})
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// ValidateConfigFile checks the config file at configPath and its overlays
+// against the schema and, when they load, runs every check of ValidateConfig
+// on the merged configuration. Each problem is located at the key it concerns
+// in the layer that sets it. The error is only set when the files cannot be
+// read.
+func (v *ValidatorService) ValidateConfigFile(configPath string) ([]config.Diagnostic, error) {
+	diagnostics, err := config.ValidateSchemaFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := config.ReadConfigData(configPath)
+	if err != nil {
+		if len(diagnostics) == 0 {
+			diagnostics = append(diagnostics, config.Locate(configPath, "", models.SeverityError, err.Error()))
+		}
+		return diagnostics, nil
+	}
+	parsed, err := config.ParseConfig(data)
+	if err != nil {
+		// Wrong types are already reported with their location by the schema
+		if len(diagnostics) == 0 {
+			diagnostics = append(diagnostics, config.Locate(configPath, "", models.SeverityError, err.Error()))
+		}
+		return diagnostics, nil
+	}
+
+	schemaDiagnostics := diagnostics
+	for _, problem := range v.CheckConfig(parsed) {
+		if !reportedWithin(schemaDiagnostics, problem.Path) {
+			diagnostics = append(diagnostics, config.Locate(configPath, problem.Path, problem.Severity, problem.Err.Error()))
+		}
+	}
+	return diagnostics, nil
+}
+
+// reportedWithin reports whether the schema already found a problem at path or
+// below it, e.g. a negative mcpServers.fs.timeout for a problem with mcpServers.fs
+func reportedWithin(diagnostics []config.Diagnostic, path string) bool {
+	if path == "" {
+		return false
+	}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Path == path || strings.HasPrefix(diagnostic.Path, path+".") || strings.HasPrefix(diagnostic.Path, path+"[") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// ErrConfirmationRequired marks a server whose command is valid but not on the
+// policy allowlist; it can still be added once the user confirms it
+var ErrConfirmationRequired = errors.New("confirmation required")
+
+// codeLoadingEnv lists environment variables that make an interpreter load and
+// run extra code, so even an allowlisted runner could run anything with them
+var codeLoadingEnv = []string{"NODE_OPTIONS", "LD_PRELOAD", "PYTHONPATH", "PYTHONSTARTUP"}
+
+// codeLoadingEnvPrefixes lists prefixes of such variables, e.g. DYLD_INSERT_LIBRARIES
+var codeLoadingEnvPrefixes = []string{"DYLD_"}
+
+// deniedPatterns caches compiled denied_args patterns, so they are compiled once
+// when the policy is validated rather than on every check
+var deniedPatterns sync.Map // Pattern -> *regexp.Regexp
+
+// compileDeniedPattern returns the compiled form of a denied_args pattern
+func compileDeniedPattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := deniedPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy denied_args pattern '%s': %w", pattern, err)
+	}
+	deniedPatterns.Store(pattern, re)
+	return re, nil
+}
+
+// validatePolicyConfig checks that every denied argument pattern compiles
+func validatePolicyConfig(policy *models.PolicyConfig) error {
+	if policy == nil {
+		return nil
+	}
+
+	for _, command := range policy.AllowedCommands {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("policy allowed_commands cannot contain an empty command")
+		}
+	}
+
+	for _, pattern := range policy.DeniedArgs {
+		if _, err := compileDeniedPattern(pattern); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkCommandPolicy applies the policy to a stdio server. Arguments, env values
+// and cwd matching a denied pattern are always rejected; a command outside the
+// allowlist or env variables that load code return ErrConfirmationRequired.
+func checkCommandPolicy(policy *models.PolicyConfig, command string, serverConfig map[string]interface{}) error {
+	if policy == nil {
+		return nil
+	}
+
+	env, _ := serverConfig["env"].(map[string]interface{})
+	for _, pattern := range policy.DeniedArgs {
+		re, err := compileDeniedPattern(pattern)
+		if err != nil {
+			return err
+		}
+		for _, arg := range extractArgs(serverConfig) {
+			if re.MatchString(arg) {
+				return fmt.Errorf("argument '%s' is blocked by the policy: it matches denied pattern '%s'", arg, pattern)
+			}
+		}
+		for key, value := range env {
+			if str, ok := value.(string); ok && re.MatchString(str) {
+				return fmt.Errorf("env '%s' is blocked by the policy: it matches denied pattern '%s'", key, pattern)
+			}
+		}
+		if cwd, ok := serverConfig["cwd"].(string); ok && re.MatchString(cwd) {
+			return fmt.Errorf("cwd '%s' is blocked by the policy: it matches denied pattern '%s'", cwd, pattern)
+		}
+	}
+
+	if key := codeLoadingEnvKey(env); key != "" {
+		return fmt.Errorf("%w: env '%s' makes '%s' load extra code and could run anything on this machine",
+			ErrConfirmationRequired, key, command)
+	}
+
+	if len(policy.AllowedCommands) == 0 {
+		return nil
+	}
+
+	for _, allowed := range policy.AllowedCommands {
+		if command == allowed {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: command '%s' is not in the policy allowlist (%s) and could run anything on this machine",
+		ErrConfirmationRequired, command, strings.Join(policy.AllowedCommands, ", "))
+}
+
+// codeLoadingEnvKey returns the first env variable, in sorted order, that
+// makes an interpreter load extra code, or "" when there is none
+func codeLoadingEnvKey(env map[string]interface{}) string {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if contains(codeLoadingEnv, key) {
+			return key
+		}
+		for _, prefix := range codeLoadingEnvPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				return key
+			}
+		}
+	}
+	return ""
+}
+
+// extractArgs returns the string arguments of a stdio server config
+func extractArgs(serverConfig map[string]interface{}) []string {
+	var args []string
+	switch raw := serverConfig["args"].(type) {
+	case []interface{}:
+		for _, arg := range raw {
+			if s, ok := arg.(string); ok {
+				args = append(args, s)
+			}
+		}
+	case []string:
+		args = raw
+	}
+	return args
+}
@@ -1,12 +1,23 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"os/exec"
 	"strings"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
 )
 
+// ErrCommandNotFound marks a stdio server whose command is not installed on this
+// machine. It is reported with the severity set in validation.missing_commands.
+var ErrCommandNotFound = errors.New("not found in PATH")
+
+// errUnresolvedVariable marks a command or URL that can only be checked once the
+// variables it refers to are known, e.g. ${workspace} when writing a project file
+var errUnresolvedVariable = errors.New("refers to a variable set when client files are written and is checked then")
+
 // TransportType represents the type of MCP server transport
 type TransportType int
 
@@ -83,7 +94,7 @@ func (v *ValidatorService) validateTransportValue(transportType TransportType, v
 	switch transportType {
 	case TransportCommand:
 		if !v.IsCommandAvailable(value) {
-			return fmt.Errorf("command '%s' not found in PATH", value)
+			return fmt.Errorf("command '%s' %w", value, ErrCommandNotFound)
 		}
 	case TransportURL, TransportHTTP:
 		if err := v.validateURL(value); err != nil {
@@ -129,34 +140,125 @@ func validateEnvironmentVariables(serverConfig map[string]interface{}) error {
 	return nil
 }
 
-// ValidateMCPServerConfig validates a server configuration map
+// ValidateMCPServerConfig validates a server configuration map for a server being
+// added, including the command policy. Only problems of error severity are
+// returned; a missing command is one only when validation.missing_commands says
+// so. A command outside the allowlist returns an error wrapping
+// ErrConfirmationRequired.
 func (v *ValidatorService) ValidateMCPServerConfig(serverName string, serverConfig map[string]interface{}) error {
+	if err := v.validateServerConfig(serverName, serverConfig); err != nil {
+		return err
+	}
+	return v.checkServerPolicy(serverConfig)
+}
+
+// checkServerPolicy applies the command policy to the command and arguments of
+// a server config as written to clients
+func (v *ValidatorService) checkServerPolicy(serverConfig map[string]interface{}) error {
+	serverConfig = interpolateServerConfig(serverConfig, newVariables(v.vars, ""))
+	transportType, transportValue, _ := detectTransportType(serverConfig)
+	if transportType == TransportCommand {
+		return checkCommandPolicy(v.policy, transportValue, serverConfig)
+	}
+	return nil
+}
+
+// validateServerConfig returns the first problem of error severity in a server
+// configuration map
+func (v *ValidatorService) validateServerConfig(serverName string, serverConfig map[string]interface{}) error {
+	v = v.forErrors()
+	for _, err := range v.checkServerConfig(serverName, serverConfig) {
+		if v.severity(err) == models.SeverityError {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkServerConfig returns every problem in the structure of a server
+// configuration map; see severity for how serious each is
+func (v *ValidatorService) checkServerConfig(serverName string, serverConfig map[string]interface{}) []error {
 	if strings.TrimSpace(serverName) == "" {
-		return fmt.Errorf("server name cannot be empty")
+		return []error{fmt.Errorf("server name cannot be empty")}
 	}
 
+	var problems []error
+
 	// Detect and validate transport type
 	transportType, transportValue, err := detectTransportType(serverConfig)
 	if err != nil {
-		return err
-	}
-
-	if err := v.validateTransportValue(transportType, transportValue); err != nil {
-		return err
+		problems = append(problems, err)
+	} else {
+		// Values that still refer to a variable (e.g. ${workspace} outside a
+		// project) can only be checked once written
+		transportValue = newVariables(v.vars, "").expandPath(transportValue)
+		if variablePattern.MatchString(transportValue) {
+			problems = append(problems, fmt.Errorf("'%s' %w", transportValue, errUnresolvedVariable))
+		} else if err := v.validateTransportValue(transportType, transportValue); err != nil {
+			if transportType == TransportCommand && v.deepChecksEnabled() && strings.ContainsAny(transportValue, `/\`) {
+				if pathErr := checkExecutable(transportValue); pathErr != nil {
+					err = pathErr
+				}
+			}
+			problems = append(problems, err)
+		} else if transportType == TransportCommand && v.deepChecksEnabled() {
+			args := extractArgs(interpolateServerConfig(serverConfig, newVariables(v.vars, "")))
+			problems = append(problems, checkCommandDetails(transportValue, args)...)
+		}
 	}
 
 	// Validate optional fields
 	if err := validateTimeout(serverConfig); err != nil {
-		return err
+		problems = append(problems, err)
 	}
 
 	if err := validateEnvironmentVariables(serverConfig); err != nil {
-		return err
+		problems = append(problems, err)
 	}
 
+	return problems
+}
+
+// validateServerOAuth checks that OAuth is only set for remote servers and that
+// its endpoints are URLs
+func (v *ValidatorService) validateServerOAuth(server models.MCPServer) error {
+	if server.OAuth == nil {
+		return nil
+	}
+	if serverURL(server.Config) == "" {
+		return fmt.Errorf("invalid MCP server '%s': oauth requires a url or httpUrl transport", server.Name)
+	}
+	if issuer := server.OAuth.AuthorizationServer; issuer != "" {
+		if err := v.validateURL(issuer); err != nil {
+			return fmt.Errorf("invalid MCP server '%s': invalid oauth authorization_server '%s': %w", server.Name, issuer, err)
+		}
+	}
+	if server.OAuth.ClientSecret != "" && server.OAuth.ClientID == "" {
+		return fmt.Errorf("invalid MCP server '%s': oauth client_secret requires client_id", server.Name)
+	}
 	return nil
 }
 
+// severity classifies a problem found by checkServerConfig: a missing command
+// depends on the machine and is a warning unless validation.missing_commands
+// says otherwise; an unchecked command or URL is an info; deep checks report
+// their own severity
+func (v *ValidatorService) severity(err error) string {
+	var finding *commandFinding
+	switch {
+	case errors.As(err, &finding):
+		return finding.severity
+	case errors.Is(err, ErrCommandNotFound):
+		if v.validation != nil && v.validation.MissingCommands != "" {
+			return v.validation.MissingCommands
+		}
+		return models.SeverityWarning
+	case errors.Is(err, errUnresolvedVariable):
+		return models.SeverityInfo
+	}
+	return models.SeverityError
+}
+
 // IsCommandAvailable checks if a command is available in PATH
 func (v *ValidatorService) IsCommandAvailable(command string) bool {
 	_, err := exec.LookPath(command)
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// templateParamPattern limits parameter names to identifiers usable as {{.name}}
+var templateParamPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// typedPlaceholderPattern matches a value that is nothing but one parameter,
+// which then keeps the parameter's type instead of becoming a string
+var typedPlaceholderPattern = regexp.MustCompile(`^\{\{\s*\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}$`)
+
+// validateTemplates checks every template and that servers only refer to existing ones
+func validateTemplates(templates map[string]*models.ServerTemplate, servers []models.MCPServer) error {
+	for name, tmpl := range templates {
+		if err := ValidateTemplate(name, tmpl); err != nil {
+			return err
+		}
+	}
+
+	for _, server := range servers {
+		if server.Template != nil {
+			if _, exists := templates[server.Template.Name]; !exists {
+				return fmt.Errorf("server '%s' refers to unknown template '%s'", server.Name, server.Template.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateTemplate checks a template's parameters, their defaults and the
+// template syntax of its config values
+func ValidateTemplate(name string, tmpl *models.ServerTemplate) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("template name cannot be empty")
+	}
+	if tmpl == nil || len(tmpl.Config) == 0 {
+		return fmt.Errorf("template '%s' has no config", name)
+	}
+
+	seen := make(map[string]bool)
+	for _, param := range tmpl.Params {
+		if !templateParamPattern.MatchString(param.Name) {
+			return fmt.Errorf("template '%s': invalid parameter name '%s'", name, param.Name)
+		}
+		if seen[param.Name] {
+			return fmt.Errorf("template '%s': duplicate parameter '%s'", name, param.Name)
+		}
+		seen[param.Name] = true
+
+		switch param.Type {
+		case "", models.ParamTypeString, models.ParamTypeInt, models.ParamTypeBool, models.ParamTypePath:
+		case models.ParamTypeEnum:
+			if len(param.Values) == 0 {
+				return fmt.Errorf("template '%s': enum parameter '%s' needs values", name, param.Name)
+			}
+		default:
+			return fmt.Errorf("template '%s': parameter '%s' has unknown type '%s'", name, param.Name, param.Type)
+		}
+
+		if param.Default != "" {
+			if _, err := convertParam(param, param.Default); err != nil {
+				return fmt.Errorf("template '%s': invalid default: %w", name, err)
+			}
+		}
+	}
+
+	_, err := walkTemplateValues(tmpl.Config, func(value string) (interface{}, error) {
+		_, err := template.New(name).Parse(value)
+		return value, err
+	})
+	if err != nil {
+		return fmt.Errorf("template '%s': %w", name, err)
+	}
+	return nil
+}
+
+// RenderServerTemplate renders a template with the given parameter values and
+// validates the result as the config of serverName, including the command
+// policy. Unless confirmed, a command outside the allowlist returns an error
+// wrapping ErrConfirmationRequired.
+func (v *ValidatorService) RenderServerTemplate(serverName, templateName string, tmpl *models.ServerTemplate, params map[string]string, confirmed bool) (map[string]interface{}, error) {
+	rendered, err := renderTemplate(templateName, tmpl, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.validateServerConfig(serverName, rendered); err != nil {
+		return nil, fmt.Errorf("server '%s' rendered from template '%s' is invalid: %w", serverName, templateName, err)
+	}
+	if err := v.checkServerPolicy(rendered); err != nil && (!confirmed || !errors.Is(err, ErrConfirmationRequired)) {
+		return nil, fmt.Errorf("server '%s' rendered from template '%s': %w", serverName, templateName, err)
+	}
+	return rendered, nil
+}
+
+// renderTemplate fills in a copy of the template config. Omitted parameters take
+// their default; values must match the parameter type.
+func renderTemplate(name string, tmpl *models.ServerTemplate, params map[string]string) (map[string]interface{}, error) {
+	data := make(map[string]interface{}, len(tmpl.Params))
+	for _, param := range tmpl.Params {
+		raw := strings.TrimSpace(params[param.Name])
+		if raw == "" {
+			raw = param.Default
+		}
+		if raw == "" && param.Required {
+			return nil, fmt.Errorf("template '%s': parameter '%s' is required", name, param.Name)
+		}
+
+		value, err := convertParam(param, raw)
+		if err != nil {
+			return nil, fmt.Errorf("template '%s': %w", name, err)
+		}
+		data[param.Name] = value
+	}
+	for key := range params {
+		if _, declared := data[key]; !declared {
+			return nil, fmt.Errorf("template '%s' has no parameter '%s'", name, key)
+		}
+	}
+
+	rendered, err := walkTemplateValues(tmpl.Config, func(value string) (interface{}, error) {
+		if match := typedPlaceholderPattern.FindStringSubmatch(strings.TrimSpace(value)); match != nil {
+			if typed, declared := data[match[1]]; declared {
+				return typed, nil
+			}
+		}
+
+		parsed, err := template.New(name).Option("missingkey=error").Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		if err := parsed.Execute(&out, data); err != nil {
+			return nil, err
+		}
+		return out.String(), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("template '%s': %w", name, err)
+	}
+	return rendered.(map[string]interface{}), nil
+}
+
+// convertParam checks a parameter value against its type; empty optional values
+// become the type's zero value
+func convertParam(param models.TemplateParam, raw string) (interface{}, error) {
+	switch param.Type {
+	case models.ParamTypeInt:
+		if raw == "" {
+			return 0, nil
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parameter '%s' must be an integer, got '%s'", param.Name, raw)
+		}
+		return value, nil
+	case models.ParamTypeBool:
+		if raw == "" {
+			return false, nil
+		}
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parameter '%s' must be true or false, got '%s'", param.Name, raw)
+		}
+		return value, nil
+	case models.ParamTypePath:
+		return config.ExpandPath(raw), nil
+	case models.ParamTypeEnum:
+		if raw != "" && !contains(param.Values, raw) {
+			return nil, fmt.Errorf("parameter '%s' must be one of %s, got '%s'", param.Name, strings.Join(param.Values, ", "), raw)
+		}
+		return raw, nil
+	default:
+		return raw, nil
+	}
+}
+
+// walkTemplateValues returns a deep copy of a decoded YAML/JSON value with every
+// string containing template actions replaced by fn's result
+func walkTemplateValues(value interface{}, fn func(string) (interface{}, error)) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			walked, err := walkTemplateValues(item, fn)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			copied[key] = walked
+		}
+		return copied, nil
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			walked, err := walkTemplateValues(item, fn)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			copied[i] = walked
+		}
+		return copied, nil
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+		return fn(v)
+	default:
+		return v, nil
+	}
+}
@@ -1,6 +1,10 @@
 package services
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/vlazic/mcp-server-manager/internal/models"
@@ -163,11 +167,11 @@ func TestValidateMCPServerConfig(t *testing.T) {
 			errContains: testutil.ErrExactlyOneTransport,
 		},
 		{
-			name:        "Command not in PATH",
-			serverName:  "invalid-cmd",
-			config:      map[string]interface{}{"command": "nonexistent-command-xyz123"},
-			wantErr:     true,
-			errContains: "not found in PATH",
+			// Only a warning by default; see TestCheckConfig_Severities
+			name:       "Command not in PATH",
+			serverName: "invalid-cmd",
+			config:     map[string]interface{}{"command": "nonexistent-command-xyz123"},
+			wantErr:    false,
 		},
 
 		// Invalid URLs
@@ -330,6 +334,19 @@ func TestValidateClient(t *testing.T) {
 			wantErr:     true,
 			errContains: "config path cannot be empty",
 		},
+		{
+			name:       "JSONC format",
+			clientName: "vscode",
+			client:     &models.Client{ConfigPath: testutil.TestClientPath, Format: models.ClientFormatJSONC},
+			wantErr:    false,
+		},
+		{
+			name:        "Unsupported format",
+			clientName:  "test",
+			client:      &models.Client{ConfigPath: testutil.TestClientPath, Format: "toml"},
+			wantErr:     true,
+			errContains: "unsupported client format",
+		},
 	}
 
 	for _, tt := range tests {
@@ -567,4 +584,361 @@ func TestIsCommandAvailable(t *testing.T) {
 			t.Error("Expected nonexistent command to be unavailable")
 		}
 	})
-}
\ No newline at end of file
+}
+func TestValidateListenConfig(t *testing.T) {
+	validator := NewValidatorService()
+	token := "0123456789abcdef0123"
+
+	tests := []struct {
+		name        string
+		bind        string
+		auth        *models.AuthConfig
+		tls         *models.TLSConfig
+		socket      *models.UnixSocketConfig
+		publicURL   string
+		wantErr     bool
+		errContains string
+	}{
+		{name: "Default loopback without auth", bind: "127.0.0.1"},
+		{name: "Localhost without auth", bind: "localhost"},
+		{name: "IPv6 loopback without auth", bind: "::1"},
+		{name: "All interfaces without auth", bind: "0.0.0.0", wantErr: true, errContains: "reachable from the network"},
+		{name: "LAN address with empty auth", bind: "192.168.1.10", auth: &models.AuthConfig{}, wantErr: true, errContains: "configure auth"},
+		{name: "All interfaces with token", bind: "0.0.0.0", auth: &models.AuthConfig{Tokens: []string{token}}},
+		{name: "All interfaces with password", bind: "0.0.0.0", auth: &models.AuthConfig{Password: "secret"}},
+		{name: "Short token", bind: "127.0.0.1", auth: &models.AuthConfig{Tokens: []string{"short"}}, wantErr: true, errContains: "at least"},
+		{name: "Invalid password hash", bind: "127.0.0.1", auth: &models.AuthConfig{PasswordHash: "plain"}, wantErr: true, errContains: "bcrypt"},
+		{name: "Unix socket without auth", bind: "0.0.0.0", socket: &models.UnixSocketConfig{Path: "/tmp/mcp.sock"}},
+		{name: "Unix socket without path", bind: "127.0.0.1", socket: &models.UnixSocketConfig{}, wantErr: true, errContains: "path cannot be empty"},
+		{name: "Unix socket with invalid mode", bind: "127.0.0.1", socket: &models.UnixSocketConfig{Path: "/tmp/mcp.sock", Mode: "rwx"}, wantErr: true, errContains: "octal"},
+		{name: "TLS with self-signed cert", bind: "127.0.0.1", tls: &models.TLSConfig{Enabled: true}},
+		{name: "TLS with cert but no key", bind: "127.0.0.1", tls: &models.TLSConfig{Enabled: true, CertFile: "cert.pem"}, wantErr: true, errContains: "set together"},
+		{name: "Public URL", bind: "127.0.0.1", publicURL: "https://mcp.example.com/"},
+		{name: "Relative public URL", bind: "127.0.0.1", publicURL: "mcp.example.com", wantErr: true, errContains: "absolute http or https URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateListenConfig(&models.Config{BindAddress: tt.bind, Auth: tt.auth, TLS: tt.tls, UnixSocket: tt.socket, PublicURL: tt.publicURL})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateListenConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && tt.errContains != "" && err != nil {
+				testutil.AssertErrorContains(t, err, tt.errContains)
+			}
+		})
+	}
+}
+
+func TestValidateMCPServerConfig_Policy(t *testing.T) {
+	validator := NewValidatorService()
+	validator.SetPolicy(&models.PolicyConfig{
+		AllowedCommands: []string{"echo"},
+		DeniedArgs:      []string{`^--privileged$`, `docker\.sock`},
+	})
+
+	tests := []struct {
+		name             string
+		config           map[string]interface{}
+		wantErr          bool
+		wantConfirmation bool
+		errContains      string
+	}{
+		{name: "Allowed command", config: map[string]interface{}{"command": "echo", "args": []interface{}{"hello"}}},
+		{name: "HTTP server is not affected", config: map[string]interface{}{"url": testutil.TestContext7URL}},
+		{
+			name:             "Command outside allowlist",
+			config:           map[string]interface{}{"command": "sh"},
+			wantErr:          true,
+			wantConfirmation: true,
+			errContains:      "not in the policy allowlist (echo)",
+		},
+		{
+			name:        "Denied argument",
+			config:      map[string]interface{}{"command": "echo", "args": []interface{}{"-v", "/var/run/docker.sock:/sock"}},
+			wantErr:     true,
+			errContains: "matches denied pattern 'docker\\.sock'",
+		},
+		{
+			name:        "Denied env value",
+			config:      map[string]interface{}{"command": "echo", "env": map[string]interface{}{"SOCK": "/var/run/docker.sock"}},
+			wantErr:     true,
+			errContains: "env 'SOCK' is blocked by the policy",
+		},
+		{
+			name:        "Denied cwd",
+			config:      map[string]interface{}{"command": "echo", "cwd": "/var/run/docker.sock.d"},
+			wantErr:     true,
+			errContains: "cwd '/var/run/docker.sock.d' is blocked by the policy",
+		},
+		{
+			name:             "Code-loading env on an allowlisted command",
+			config:           map[string]interface{}{"command": "echo", "env": map[string]interface{}{"NODE_OPTIONS": "--require /tmp/x.js"}},
+			wantErr:          true,
+			wantConfirmation: true,
+			errContains:      "env 'NODE_OPTIONS' makes 'echo' load extra code",
+		},
+		{
+			name:             "Code-loading env prefix",
+			config:           map[string]interface{}{"command": "echo", "env": map[string]interface{}{"DYLD_INSERT_LIBRARIES": "/tmp/x.dylib"}},
+			wantErr:          true,
+			wantConfirmation: true,
+			errContains:      "env 'DYLD_INSERT_LIBRARIES'",
+		},
+		{
+			name:        "Denied argument wins over confirmation",
+			config:      map[string]interface{}{"command": "sh", "args": []interface{}{"--privileged"}},
+			wantErr:     true,
+			errContains: "blocked by the policy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateMCPServerConfig("policy-test", tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateMCPServerConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrConfirmationRequired) != tt.wantConfirmation {
+				t.Errorf("Expected confirmation required = %v, got error %v", tt.wantConfirmation, err)
+			}
+			if tt.errContains != "" && err != nil {
+				testutil.AssertErrorContains(t, err, tt.errContains)
+			}
+		})
+	}
+
+	// Servers already in config.yaml are trusted; only the patterns are checked
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{{Name: "shell", Config: map[string]interface{}{"command": "sh"}}},
+		Clients:    map[string]*models.Client{"client": {ConfigPath: "/tmp/client.json"}},
+		Policy:     &models.PolicyConfig{AllowedCommands: []string{"echo"}},
+	}
+	if err := validator.ValidateConfig(cfg); err != nil {
+		t.Errorf("Expected existing servers to pass validation, got %v", err)
+	}
+
+	cfg.Policy.DeniedArgs = []string{"("}
+	if err := validator.ValidateConfig(cfg); err == nil {
+		t.Error("Expected error for invalid denied_args pattern")
+	} else {
+		testutil.AssertErrorContains(t, err, "invalid policy denied_args pattern")
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{
+			{Name: "missing", Config: map[string]interface{}{"command": "nonexistent-command-xyz"}},
+			{Name: "web", Config: map[string]interface{}{"url": "ftp://example.com"}},
+		},
+		Clients: map[string]*models.Client{
+			"a": {ConfigPath: "~/.a.json", Enabled: []string{"gone"}},
+			"b": {ConfigPath: ""},
+		},
+		Watch: &models.WatchConfig{Mode: "sometimes"},
+	}
+
+	validator := NewValidatorService()
+	problems := validator.CheckConfig(cfg)
+
+	var got []string
+	for _, problem := range problems {
+		got = append(got, problem.Severity+" "+problem.Path)
+	}
+	want := []string{
+		"warning mcpServers.missing",
+		"error mcpServers.web",
+		"error clients.a.enabled",
+		"error clients.b",
+		"error watch",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected problems:\n got %v\nwant %v", got, want)
+	}
+
+	// ValidateConfig reports the first error
+	if err := validator.ValidateConfig(cfg); err == nil || err.Error() != problems[1].Err.Error() {
+		t.Errorf("Expected the first error, got %v", err)
+	}
+}
+
+func TestCheckConfig_Severities(t *testing.T) {
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{
+			{Name: "missing", Config: map[string]interface{}{"command": "nonexistent-command-xyz"}},
+			{Name: "project", Config: map[string]interface{}{"command": "${workspace}/run.sh"}},
+		},
+		Clients: map[string]*models.Client{"a": {ConfigPath: "~/.a.json", Enabled: []string{"missing"}}},
+	}
+	validator := NewValidatorService()
+
+	// A missing command is a warning and does not fail validation
+	if err := validator.ValidateConfig(cfg); err != nil {
+		t.Errorf("Expected warnings only, got %v", err)
+	}
+	problems := validator.CheckConfig(cfg)
+	if len(problems) != 2 || problems[0].Severity != models.SeverityWarning || problems[1].Severity != models.SeverityInfo {
+		t.Fatalf("Expected a warning and an info, got %+v", problems)
+	}
+	testutil.AssertErrorContains(t, problems[0].Err, "command 'nonexistent-command-xyz' not found in PATH")
+	if !errors.Is(problems[0].Err, ErrCommandNotFound) {
+		t.Errorf("Expected ErrCommandNotFound, got %v", problems[0].Err)
+	}
+
+	// validation.missing_commands makes it an error again
+	cfg.Validation = &models.ValidationConfig{MissingCommands: models.SeverityError}
+	testutil.AssertErrorContains(t, validator.ValidateConfig(cfg), "invalid MCP server 'missing'")
+
+	cfg.Validation.MissingCommands = "fatal"
+	testutil.AssertErrorContains(t, validator.ValidateConfig(cfg), "invalid validation missing_commands 'fatal'")
+}
+
+func TestValidateConfigFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), testutil.TestConfigYAML)
+	testutil.WriteTestFile(t, configPath, `server_port: 6543
+mcpServers:
+  echo:
+    command: echo
+    timeout: -5
+  missing:
+    command: nonexistent-command-xyz
+clients:
+  claude:
+    config_path: "~/.claude.json"
+    enabled: [echo, gone]
+`)
+
+	diagnostics, err := NewValidatorService().ValidateConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("ValidateConfigFile failed: %v", err)
+	}
+
+	type location struct {
+		path      string
+		line, col int
+	}
+	var got []location
+	for _, diagnostic := range diagnostics {
+		if diagnostic.File != configPath {
+			t.Errorf("Unexpected file %s", diagnostic.File)
+		}
+		got = append(got, location{diagnostic.Path, diagnostic.Line, diagnostic.Column})
+	}
+	want := []location{
+		{"mcpServers.echo.timeout", 5, 14}, // From the schema; not repeated for the server
+		{"mcpServers.missing", 6, 3},
+		{"clients.claude.enabled", 11, 5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected diagnostics:\n got %v\nwant %v\n%v", got, want, diagnostics)
+	} else if diagnostics[1].Severity != models.SeverityWarning || diagnostics[2].Severity != models.SeverityError {
+		t.Errorf("Expected the missing command as a warning, got %v", diagnostics)
+	}
+
+	testutil.WriteTestFile(t, configPath, "server_port: 6543\nmcpServers:\n  echo:\n    command: echo\nclients:\n  claude:\n    config_path: \"~/.claude.json\"\n")
+	if diagnostics, _ := NewValidatorService().ValidateConfigFile(configPath); len(diagnostics) != 0 {
+		t.Errorf("Expected a valid config, got %v", diagnostics)
+	}
+}
+func TestCheckConfig_DeepChecks(t *testing.T) {
+	// Stand-in runners, so only the packages and images are checked
+	binDir := t.TempDir()
+	for _, runner := range []string{"npx", "uvx", "docker"} {
+		testutil.WriteTestFile(t, filepath.Join(binDir, runner), "#!/bin/sh\n")
+		if err := os.Chmod(filepath.Join(binDir, runner), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	notExecutable := filepath.Join(binDir, "server.sh")
+	testutil.WriteTestFile(t, notExecutable, "#!/bin/sh\n")
+	t.Setenv("PATH", binDir)
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("npm_config_cache", "")
+	t.Setenv("UV_CACHE_DIR", filepath.Join(home, "uv-cache"))
+	t.Setenv("UV_TOOL_DIR", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	testutil.WriteTestFile(t, filepath.Join(home, ".npm", "_npx", "a1b2", "node_modules", "@modelcontextprotocol", "server-filesystem", "package.json"),
+		`{"name": "@modelcontextprotocol/server-filesystem", "version": "1.2.0"}`)
+	testutil.WriteTestFile(t, filepath.Join(home, "uv-cache", "archive-v0", "x1", "mcp_server_fetch-0.6.2.dist-info", "METADATA"), "")
+
+	originalImageExists := dockerImageExists
+	t.Cleanup(func() { dockerImageExists = originalImageExists })
+	dockerImageExists = func(image string) (bool, error) {
+		return image == "mcp/github:latest", nil
+	}
+	deepCheckCache.Lock()
+	deepCheckCache.entries = make(map[string]deepCheckEntry)
+	deepCheckCache.Unlock()
+
+	server := func(name, command string, args ...interface{}) models.MCPServer {
+		return models.MCPServer{Name: name, Config: map[string]interface{}{"command": command, "args": args}}
+	}
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{
+			server("fs", "npx", "-y", "@modelcontextprotocol/server-filesystem", "/tmp"),
+			server("fs-pinned", "npx", "--yes", "@modelcontextprotocol/server-filesystem@2.0.0"),
+			server("npm-bad", "npx", "-y", "Not A Package"),
+			server("npm-new", "npx", "--package=mcp-remote", "mcp-remote", "https://example.com"),
+			server("fetch", "uvx", "mcp-server-fetch"),
+			server("git", "uvx", "--from", "mcp-server-git==1.0", "mcp-server-git"),
+			server("py-bad", "uvx", "bad name!"),
+			server("github", "docker", "run", "-i", "--rm", "-e", "TOKEN", "mcp/github:latest"),
+			server("pulled", "docker", "run", "--pull=never", "--name", "x", "mcp/time"),
+			server("local", notExecutable),
+			server("gone", filepath.Join(binDir, "missing")),
+		},
+		Clients:    map[string]*models.Client{"a": {ConfigPath: "~/.a.json"}},
+		Validation: &models.ValidationConfig{DeepChecks: true},
+	}
+
+	got := map[string]string{}
+	for _, problem := range NewValidatorService().CheckConfig(cfg) {
+		got[problem.Path] = problem.Severity + ": " + problem.Err.Error()
+	}
+	want := map[string]string{
+		"mcpServers.fs-pinned": "info: npm package '@modelcontextprotocol/server-filesystem' is cached in version 1.2.0 only; npx downloads 2.0.0 on first start",
+		"mcpServers.npm-bad":   "warning: npm package 'Not A Package' is not a valid package name",
+		"mcpServers.npm-new":   "info: npm package 'mcp-remote' is not in the npx cache; npx downloads it on first start",
+		"mcpServers.git":       "info: Python package 'mcp-server-git==1.0' is not in the uv cache; uvx downloads it on first start",
+		"mcpServers.py-bad":    "warning: Python package 'bad name!' is not a valid requirement",
+		"mcpServers.pulled":    "warning: docker image 'mcp/time' is not available locally and --pull=never prevents pulling it",
+		"mcpServers.local":     "warning: command '" + notExecutable + "' is not executable (mode 0644)",
+		"mcpServers.gone":      "warning: command '" + filepath.Join(binDir, "missing") + "' does not exist",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected deep check problems:\n got %v\nwant %v", got, want)
+	}
+
+	// Without deep checks only the runners themselves are checked
+	cfg.Validation = nil
+	for _, problem := range NewValidatorService().CheckConfig(cfg) {
+		if problem.Path != "mcpServers.local" && problem.Path != "mcpServers.gone" {
+			t.Errorf("Unexpected problem without deep checks: %v", problem.Err)
+		}
+	}
+
+	// Saving only looks for errors, which deep checks never find, so it does
+	// not wait for docker while the manager is locked
+	deepCheckCache.Lock()
+	deepCheckCache.entries = make(map[string]deepCheckEntry)
+	deepCheckCache.Unlock()
+	dockerImageExists = func(image string) (bool, error) {
+		t.Errorf("Unexpected docker lookup of '%s' while validating for errors", image)
+		return false, nil
+	}
+	validator := NewValidatorService()
+	validator.SetDeepChecks(true)
+	if err := validator.ValidateConfig(cfg); err != nil {
+		t.Errorf("ValidateConfig failed: %v", err)
+	}
+	if err := validator.ValidateMCPServerConfig("new", cfg.MCPServers[7].Config); err != nil {
+		t.Errorf("ValidateMCPServerConfig failed: %v", err)
+	}
+}
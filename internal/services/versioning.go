@@ -0,0 +1,418 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// Identity used for commits when neither config.yaml nor git provides one
+const (
+	defaultGitAuthorName  = "MCP Server Manager"
+	defaultGitAuthorEmail = "mcp-server-manager@localhost"
+)
+
+// ErrVersioningDisabled is returned by history operations without git versioning
+var ErrVersioningDisabled = errors.New("git versioning is not enabled in config.yaml")
+
+// maxCommitSubjectParts limits how many changes are spelled out in a commit subject
+const maxCommitSubjectParts = 3
+
+// revisionPattern accepts abbreviated or full commit hashes only, so a revision
+// can never be mistaken for a git option or another kind of revision expression
+var revisionPattern = regexp.MustCompile(`^[0-9a-fA-F]{4,40}$`)
+
+// ConfigRevision is one commit of config.yaml
+type ConfigRevision struct {
+	Rev     string    `json:"rev"`
+	Author  string    `json:"author"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// configRepo commits config.yaml to the git repository containing it
+type configRepo struct {
+	dir      string
+	file     string   // config.yaml name relative to dir
+	identity []string // "-c user.name=..." arguments when git has no identity
+	last     map[string]interface{}
+}
+
+// openConfigRepo prepares versioning for configPath: the directory becomes a git
+// repository unless it already is inside one, and any uncommitted state of the
+// file (first use, or edits made outside the manager) is committed
+func openConfigRepo(configPath string, gitCfg *models.GitConfig, cfg *models.Config) (*configRepo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git versioning is enabled but git is not installed")
+	}
+
+	r := &configRepo{
+		dir:  filepath.Dir(configPath),
+		file: filepath.Base(configPath),
+		last: configSnapshot(cfg),
+	}
+
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if _, err := r.git("rev-parse", "--is-inside-work-tree"); err != nil {
+		if _, err := r.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+	}
+
+	r.identity = gitIdentity(r, gitCfg)
+
+	if _, err := os.Stat(configPath); err == nil {
+		message := "update " + r.file + " outside the manager"
+		if out, _ := r.git("log", "-1", "--format=%H", "--", r.file); out == "" {
+			message = "track " + r.file
+		}
+		if err := r.commit(message); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// gitIdentity returns -c arguments that set the commit identity from config.yaml,
+// or a default one when git itself has none configured
+func gitIdentity(r *configRepo, gitCfg *models.GitConfig) []string {
+	name, email := gitCfg.AuthorName, gitCfg.AuthorEmail
+	if name == "" {
+		if configured, _ := r.git("config", "user.name"); configured == "" {
+			name = defaultGitAuthorName
+		}
+	}
+	if email == "" {
+		if configured, _ := r.git("config", "user.email"); configured == "" {
+			email = defaultGitAuthorEmail
+		}
+	}
+
+	var identity []string
+	if name != "" {
+		identity = append(identity, "-c", "user.name="+name)
+	}
+	if email != "" {
+		identity = append(identity, "-c", "user.email="+email)
+	}
+	return identity
+}
+
+// git runs a git command in the config directory and returns its trimmed output
+func (r *configRepo) git(args ...string) (string, error) {
+	output, err := r.gitOutput(args...)
+	return strings.TrimSpace(string(output)), err
+}
+
+// gitOutput runs git in the repository and returns its output unchanged
+func (r *configRepo) gitOutput(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append(append([]string{"-C", r.dir}, r.identity...), args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// commit records the current config file; nothing happens when it is unchanged.
+// Only config.yaml is committed, so other files in a shared repository are untouched.
+func (r *configRepo) commit(message string) error {
+	if _, err := r.git("add", "--", r.file); err != nil {
+		return err
+	}
+	if _, err := r.git("diff", "--cached", "--quiet", "--", r.file); err == nil {
+		return nil
+	}
+	_, err := r.git("commit", "--quiet", "-m", message, "--", r.file)
+	return err
+}
+
+// commitChange commits config.yaml with a message describing how cfg differs
+// from the last commit, unless message is given
+func (r *configRepo) commitChange(cfg *models.Config, message string) error {
+	current := configSnapshot(cfg)
+	if message == "" {
+		message = describeConfigChange(r.last, current)
+	}
+	r.last = current
+	return r.commit(message)
+}
+
+// history lists the commits of config.yaml, newest first
+func (r *configRepo) history(limit int) ([]ConfigRevision, error) {
+	args := []string{"log", "--format=%H%x1f%an%x1f%aI%x1f%s"}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", limit))
+	}
+
+	out, err := r.git(append(args, "--", r.file)...)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []ConfigRevision{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		commitTime, _ := time.Parse(time.RFC3339, fields[2])
+		revisions = append(revisions, ConfigRevision{Rev: fields[0], Author: fields[1], Time: commitTime, Message: fields[3]})
+	}
+	return revisions, nil
+}
+
+// show returns config.yaml as of a revision, with its full hash and subject
+func (r *configRepo) show(rev string) ([]byte, *ConfigRevision, error) {
+	if !revisionPattern.MatchString(rev) {
+		return nil, nil, fmt.Errorf("invalid revision '%s': expected a commit hash", rev)
+	}
+
+	info, err := r.git("log", "-1", "--format=%H%x1f%s", rev+"^{commit}", "--")
+	if err != nil {
+		return nil, nil, fmt.Errorf("revision '%s' not found", rev)
+	}
+	fields := strings.SplitN(info, "\x1f", 2)
+	revision := &ConfigRevision{Rev: fields[0]}
+	if len(fields) == 2 {
+		revision.Message = fields[1]
+	}
+
+	// The content is returned byte for byte, so a revert restores the file exactly
+	content, err := r.gitOutput("show", revision.Rev+":./"+r.file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s does not exist in revision '%s'", r.file, rev)
+	}
+	return content, revision, nil
+}
+
+// describeConfigChange summarizes the difference between two config snapshots,
+// e.g. "enable n8n-mcp for gemini_cli"
+func describeConfigChange(before, after map[string]interface{}) string {
+	var parts []string
+
+	parts = append(parts, describeEnabledChanges(before["clients"], after["clients"], "client", "")...)
+	parts = append(parts, describeEntryChanges(before["mcpServers"], after["mcpServers"], "server")...)
+	if sameItems(before["serverOrder"], after["serverOrder"]) && !reflect.DeepEqual(before["serverOrder"], after["serverOrder"]) {
+		parts = append(parts, "reorder servers")
+	}
+	parts = append(parts, describeEnabledChanges(before["projects"], after["projects"], "project", "project ")...)
+	parts = append(parts, describeEntryChanges(before["templates"], after["templates"], "template")...)
+
+	if profile, ok := after["active_profile"].(string); ok && !reflect.DeepEqual(before["active_profile"], after["active_profile"]) {
+		parts = append(parts, "activate profile "+profile)
+	}
+
+	handled := map[string]bool{"clients": true, "mcpServers": true, "serverOrder": true, "projects": true, "templates": true, "active_profile": true}
+	for _, key := range sortedKeys(before, after) {
+		if !handled[key] && !reflect.DeepEqual(before[key], after[key]) {
+			parts = append(parts, "update "+key)
+		}
+	}
+
+	switch {
+	case len(parts) == 0:
+		return "update config.yaml"
+	case len(parts) > maxCommitSubjectParts:
+		return fmt.Sprintf("%s and %d more", strings.Join(parts[:maxCommitSubjectParts], "; "), len(parts)-maxCommitSubjectParts)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// describeEnabledChanges describes added and removed entries of a section keyed by
+// name (clients, projects) and the servers enabled or disabled for each entry
+func describeEnabledChanges(before, after interface{}, kind, prefix string) []string {
+	beforeMap, _ := before.(map[string]interface{})
+	afterMap, _ := after.(map[string]interface{})
+
+	var parts []string
+	for _, name := range sortedKeys(beforeMap, afterMap) {
+		oldEntry, hadEntry := beforeMap[name].(map[string]interface{})
+		newEntry, hasEntry := afterMap[name].(map[string]interface{})
+
+		switch {
+		case !hadEntry && hasEntry:
+			parts = append(parts, "add "+kind+" "+name)
+			continue
+		case hadEntry && !hasEntry:
+			parts = append(parts, "remove "+kind+" "+name)
+			continue
+		case reflect.DeepEqual(oldEntry, newEntry):
+			continue
+		}
+
+		oldEnabled, newEnabled := toStrings(oldEntry["enabled"]), toStrings(newEntry["enabled"])
+		if added := missingFrom(newEnabled, oldEnabled); len(added) > 0 {
+			parts = append(parts, fmt.Sprintf("enable %s for %s%s", strings.Join(added, ", "), prefix, name))
+		}
+		if removed := missingFrom(oldEnabled, newEnabled); len(removed) > 0 {
+			parts = append(parts, fmt.Sprintf("disable %s for %s%s", strings.Join(removed, ", "), prefix, name))
+		}
+
+		if !reflect.DeepEqual(withoutKey(oldEntry, "enabled"), withoutKey(newEntry, "enabled")) {
+			parts = append(parts, "update "+kind+" "+name)
+		}
+	}
+	return parts
+}
+
+// describeEntryChanges describes added, removed and modified entries of a section keyed by name
+func describeEntryChanges(before, after interface{}, kind string) []string {
+	beforeMap, _ := before.(map[string]interface{})
+	afterMap, _ := after.(map[string]interface{})
+
+	var parts []string
+	for _, name := range sortedKeys(beforeMap, afterMap) {
+		oldEntry, hadEntry := beforeMap[name]
+		newEntry, hasEntry := afterMap[name]
+
+		switch {
+		case !hadEntry:
+			parts = append(parts, "add "+kind+" "+name)
+		case !hasEntry:
+			parts = append(parts, "remove "+kind+" "+name)
+		case !reflect.DeepEqual(oldEntry, newEntry):
+			parts = append(parts, "update "+kind+" "+name)
+		}
+	}
+	return parts
+}
+
+func sortedKeys(maps ...map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// withoutKey returns a copy of m without key
+func withoutKey(m map[string]interface{}, key string) map[string]interface{} {
+	copied := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if k != key {
+			copied[k] = v
+		}
+	}
+	return copied
+}
+
+func toStrings(value interface{}) []string {
+	items, _ := value.([]interface{})
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// missingFrom returns the items of a that are not in b
+func missingFrom(a, b []string) []string {
+	var missing []string
+	for _, item := range a {
+		if !contains(b, item) {
+			missing = append(missing, item)
+		}
+	}
+	return missing
+}
+
+// sameItems reports whether two lists hold the same items in any order
+func sameItems(a, b interface{}) bool {
+	left, right := toStrings(a), toStrings(b)
+	sort.Strings(left)
+	sort.Strings(right)
+	return reflect.DeepEqual(left, right)
+}
+
+// GetConfigHistory returns up to limit commits of config.yaml, newest first
+// (all when limit <= 0)
+func (s *MCPManagerService) GetConfigHistory(limit int) ([]ConfigRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.repo == nil {
+		return nil, ErrVersioningDisabled
+	}
+	return s.repo.history(limit)
+}
+
+// RevertConfig restores config.yaml as it was in a revision, commits the result
+// as a new revision and syncs all clients. Listener and auth settings from the
+// revision take effect after a restart.
+func (s *MCPManagerService) RevertConfig(rev string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.audit("revert", rev, &err)
+	defer s.publish(EventConfigReloaded, ConfigReloadedData{Reason: "revert"}, &err)
+
+	if s.repo == nil {
+		return ErrVersioningDisabled
+	}
+
+	content, revision, err := s.repo.show(rev)
+	if err != nil {
+		return err
+	}
+
+	// Only the base file is versioned; this machine's overlays still apply
+	merged, err := config.MergeOverlays(content, s.configPath)
+	if err != nil {
+		return err
+	}
+
+	reverted, err := config.ParseConfig(merged)
+	if err != nil {
+		return fmt.Errorf("failed to parse config from revision '%s': %w", rev, err)
+	}
+	if err := s.validator.ValidateConfig(reverted); err != nil {
+		return fmt.Errorf("config from revision '%s' is invalid: %w", rev, err)
+	}
+
+	// Replace the contents rather than the pointer: the client config service
+	// and the handlers hold on to it
+	*s.config = *reverted
+	s.validator.SetPolicy(s.config.Policy)
+	s.validator.SetVars(s.config.Vars)
+	s.validator.SetValidation(s.config.Validation)
+
+	message := fmt.Sprintf("revert to %.7s (%s)", revision.Rev, revision.Message)
+	if bytes.Equal(merged, content) {
+		// Without overlays the committed file is written back as it is,
+		// comments and layout included
+		err = s.saveConfigData(content, message)
+	} else {
+		err = s.saveConfigWithMessage(message)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.syncAllClients()
+}
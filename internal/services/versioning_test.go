@@ -0,0 +1,193 @@
+package services
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+func TestConfigVersioning(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, testutil.TestConfigYAML)
+
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{
+			{Name: "server-a", Config: map[string]interface{}{"command": "echo"}},
+		},
+		Clients: map[string]*models.Client{
+			testutil.TestClientName: {ConfigPath: filepath.Join(tempDir, testutil.TestClientJSON)},
+		},
+		Git: &models.GitConfig{Enabled: true, AuthorName: "Tester", AuthorEmail: "tester@example.com"},
+	}
+	if err := config.SaveConfig(cfg, configPath); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	// A hand-edited file: a comment and trailing blank lines must survive a revert
+	saved, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	original := append([]byte("# Edited by hand\n"), append(saved, "\n\n"...)...)
+	testutil.WriteTestFile(t, configPath, string(original))
+
+	service := NewMCPManagerService(cfg, configPath)
+
+	if err := service.ToggleClientMCPServer(testutil.TestClientName, "server-a", true); err != nil {
+		t.Fatalf("ToggleClientMCPServer failed: %v", err)
+	}
+	if err := service.AddServer("server-b", map[string]interface{}{"command": "echo"}); err != nil {
+		t.Fatalf(testutil.ErrAddServerFailedFmt, err)
+	}
+
+	revisions, err := service.GetConfigHistory(0)
+	if err != nil {
+		t.Fatalf("GetConfigHistory failed: %v", err)
+	}
+
+	want := []string{
+		"add server server-b",
+		"enable server-a for " + testutil.TestClientName,
+		"track " + testutil.TestConfigYAML,
+	}
+	if len(revisions) != len(want) {
+		t.Fatalf("Expected %d revisions, got %+v", len(want), revisions)
+	}
+	for i, message := range want {
+		if revisions[i].Message != message {
+			t.Errorf("Revision[%d]: expected message %q, got %q", i, message, revisions[i].Message)
+		}
+	}
+	if revisions[0].Author != "Tester" {
+		t.Errorf("Expected configured author, got %q", revisions[0].Author)
+	}
+
+	t.Run("Revert restores an earlier revision", func(t *testing.T) {
+		first := revisions[2].Rev
+		if err := service.RevertConfig(first[:8]); err != nil {
+			t.Fatalf("RevertConfig failed: %v", err)
+		}
+
+		if len(cfg.MCPServers) != 1 || len(cfg.Clients[testutil.TestClientName].Enabled) != 0 {
+			t.Errorf("Expected in-memory config from the first revision, got %+v", cfg)
+		}
+
+		reloaded, _, err := config.LoadConfig(configPath)
+		if err != nil {
+			t.Fatalf("Failed to reload config: %v", err)
+		}
+		if len(reloaded.MCPServers) != 1 {
+			t.Errorf("Expected 1 server after revert, got %d", len(reloaded.MCPServers))
+		}
+		if data, _ := os.ReadFile(configPath); string(data) != string(original) {
+			t.Errorf("Expected the committed bytes back:\n got %q\nwant %q", data, original)
+		}
+
+		latest, err := service.GetConfigHistory(1)
+		if err != nil || len(latest) != 1 {
+			t.Fatalf("GetConfigHistory failed: %v", err)
+		}
+		if !strings.HasPrefix(latest[0].Message, "revert to "+first[:7]) {
+			t.Errorf("Expected revert commit, got %q", latest[0].Message)
+		}
+	})
+
+	t.Run("Rejects invalid revisions", func(t *testing.T) {
+		for _, rev := range []string{"HEAD~1", "--all", "0000000"} {
+			if err := service.RevertConfig(rev); err == nil {
+				t.Errorf("Expected error for revision %q", rev)
+			}
+		}
+	})
+}
+
+func TestConfigVersioning_Disabled(t *testing.T) {
+	service, _, _ := setupTagTest(t)
+
+	if _, err := service.GetConfigHistory(0); err != ErrVersioningDisabled {
+		t.Errorf("Expected ErrVersioningDisabled, got %v", err)
+	}
+}
+
+func TestDescribeConfigChange(t *testing.T) {
+	base := func() *models.Config {
+		return &models.Config{
+			MCPServers: []models.MCPServer{
+				{Name: "a", Config: map[string]interface{}{"command": "echo"}},
+				{Name: "b", Config: map[string]interface{}{"command": "echo"}},
+			},
+			Clients: map[string]*models.Client{
+				"gemini_cli": {ConfigPath: "/tmp/gemini.json", Enabled: []string{"a"}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(cfg *models.Config)
+		want   string
+	}{
+		{name: "No change", change: func(cfg *models.Config) {}, want: "update config.yaml"},
+		{
+			name:   "Enable",
+			change: func(cfg *models.Config) { cfg.Clients["gemini_cli"].Enabled = []string{"a", "b"} },
+			want:   "enable b for gemini_cli",
+		},
+		{
+			name:   "Disable",
+			change: func(cfg *models.Config) { cfg.Clients["gemini_cli"].Enabled = nil },
+			want:   "disable a for gemini_cli",
+		},
+		{
+			name: "Reorder",
+			change: func(cfg *models.Config) {
+				cfg.MCPServers[0], cfg.MCPServers[1] = cfg.MCPServers[1], cfg.MCPServers[0]
+			},
+			want: "reorder servers",
+		},
+		{
+			name:   "Update server",
+			change: func(cfg *models.Config) { cfg.MCPServers[0].Tags = []string{"docs"} },
+			want:   "update server a",
+		},
+		{
+			name: "Profile",
+			change: func(cfg *models.Config) {
+				cfg.ActiveProfile = "work"
+				cfg.Clients["gemini_cli"].Enabled = []string{"b"}
+			},
+			want: "enable b for gemini_cli; disable a for gemini_cli; activate profile work",
+		},
+		{
+			name: "Many changes",
+			change: func(cfg *models.Config) {
+				cfg.Clients["gemini_cli"].Enabled = []string{"b"}
+				cfg.MCPServers = cfg.MCPServers[1:]
+				cfg.ServerPort = 7000
+			},
+			want: "enable b for gemini_cli; disable a for gemini_cli; remove server a and 1 more",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := configSnapshot(base())
+			cfg := base()
+			tt.change(cfg)
+
+			if got := describeConfigChange(before, configSnapshot(cfg)); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/vlazic/mcp-server-manager/internal/config"
+	"github.com/vlazic/mcp-server-manager/internal/models"
+)
+
+// Watch modes
+const (
+	WatchModeAutoHeal = "auto_heal" // Re-apply the desired state when drift is detected
+	WatchModeNotify   = "notify"    // Only record drift events
+)
+
+const (
+	defaultWatchInterval = 2 * time.Second
+	defaultWatchDebounce = 1500 * time.Millisecond
+)
+
+// fileState is the cheap fingerprint used to notice client file changes
+type fileState struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// pendingChange tracks a client file that changed and is waiting out the debounce period
+type pendingChange struct {
+	state fileState
+	since time.Time
+}
+
+// ClientWatcher polls every client config file and reacts to drift of managed entries.
+// It also reloads config.yaml when it is edited outside the manager.
+//
+// Polling keeps the binary dependency-free and copes with editors that replace files
+// via rename, which inode-based watchers tend to lose track of.
+type ClientWatcher struct {
+	manager  *MCPManagerService
+	mode     string
+	interval time.Duration
+	debounce time.Duration
+
+	states  map[string]fileState
+	pending map[string]pendingChange
+
+	configPath    string
+	configState   fileState
+	configPending *pendingChange
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewClientWatcher creates a watcher using the manager's watch settings
+func NewClientWatcher(manager *MCPManagerService, watchCfg *models.WatchConfig) *ClientWatcher {
+	w := &ClientWatcher{
+		manager:  manager.WithActor(Actor{Source: SourceWatch}),
+		mode:     WatchModeNotify,
+		interval: defaultWatchInterval,
+		debounce: defaultWatchDebounce,
+		states:   make(map[string]fileState),
+		pending:  make(map[string]pendingChange),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+
+		configPath: manager.configPath,
+	}
+	if w.configPath == "" {
+		w.configPath = config.DefaultConfigPath
+	}
+
+	if watchCfg != nil {
+		if watchCfg.Mode != "" {
+			w.mode = watchCfg.Mode
+		}
+		if watchCfg.IntervalMs > 0 {
+			w.interval = time.Duration(watchCfg.IntervalMs) * time.Millisecond
+		}
+		if watchCfg.DebounceMs > 0 {
+			w.debounce = time.Duration(watchCfg.DebounceMs) * time.Millisecond
+		}
+	}
+
+	return w
+}
+
+// Start begins polling in the background. The current file states are taken
+// as the baseline, so only changes made after startup are examined.
+func (w *ClientWatcher) Start() {
+	for name, path := range w.clientPaths() {
+		w.states[name] = statFile(path)
+	}
+	w.configState = w.statConfig()
+
+	go w.run()
+}
+
+// Stop halts polling and waits for the background goroutine to exit
+func (w *ClientWatcher) Stop() {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+}
+
+func (w *ClientWatcher) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case now := <-ticker.C:
+			w.poll(now)
+		}
+	}
+}
+
+// poll checks every client file once. A changed file is only examined after it has
+// stayed unchanged for the debounce period, so we don't fight a client mid-write.
+func (w *ClientWatcher) poll(now time.Time) {
+	w.pollConfig(now)
+
+	for name, path := range w.clientPaths() {
+		current := statFile(path)
+
+		if current != w.states[name] {
+			w.states[name] = current
+			w.pending[name] = pendingChange{state: current, since: now}
+			continue
+		}
+
+		change, waiting := w.pending[name]
+		if !waiting || now.Sub(change.since) < w.debounce {
+			continue
+		}
+
+		delete(w.pending, name)
+		w.checkClient(name)
+
+		// Healing rewrites the file; take the result as the new baseline
+		w.states[name] = statFile(path)
+	}
+}
+
+// statConfig fingerprints config.yaml together with its overlays. The drop-in
+// directory changes when a drop-in is added or removed.
+func (w *ClientWatcher) statConfig() fileState {
+	state := statFile(w.configPath)
+
+	paths, err := config.LayerPaths(w.configPath)
+	if err != nil {
+		return state
+	}
+	for _, path := range append(paths[1:], config.DropInDir(w.configPath)) {
+		overlay := statFile(path)
+		if overlay.modTime.After(state.modTime) {
+			state.modTime = overlay.modTime
+		}
+		state.size += overlay.size
+	}
+	return state
+}
+
+// pollConfig reloads config.yaml once it has settled after a change. The manager's
+// own saves match what it last wrote and are ignored.
+func (w *ClientWatcher) pollConfig(now time.Time) {
+	current := w.statConfig()
+	if current != w.configState {
+		w.configState = current
+		w.configPending = &pendingChange{state: current, since: now}
+		return
+	}
+
+	if w.configPending == nil || now.Sub(w.configPending.since) < w.debounce {
+		return
+	}
+	w.configPending = nil
+	if !current.exists {
+		return
+	}
+
+	reloaded, err := w.manager.ReloadConfig()
+	if err != nil {
+		w.manager.logger().Warn("Failed to reload config", "path", w.configPath, "error", err)
+		return
+	}
+	if reloaded {
+		w.manager.logger().Info("Reloaded config after an outside change", "path", w.configPath)
+	}
+}
+
+func (w *ClientWatcher) checkClient(clientName string) {
+	events, err := w.manager.DetectClientDrift(clientName)
+	if err != nil {
+		w.manager.logger().Warn("Failed to check client for drift", "client", clientName, "error", err)
+		return
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	if w.mode != WatchModeAutoHeal {
+		w.manager.logger().Warn("Detected drift in client config", "client", clientName, "entries", len(events))
+		w.manager.RecordDrift(events)
+		return
+	}
+
+	if err := w.manager.HealClientDrift(clientName, events); err != nil {
+		w.manager.logger().Error("Failed to heal client config", "client", clientName, "error", err)
+		w.manager.RecordDrift(events)
+		return
+	}
+	w.manager.logger().Info("Healed drift in client config", "client", clientName, "entries", len(events))
+}
+
+// clientPaths returns the expanded config path of every client
+func (w *ClientWatcher) clientPaths() map[string]string {
+	w.manager.mu.Lock()
+	defer w.manager.mu.Unlock()
+
+	paths := make(map[string]string, len(w.manager.config.Clients))
+	for name, client := range w.manager.config.Clients {
+		paths[name] = config.ExpandPath(client.ConfigPath)
+	}
+	return paths
+}
+
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size(), exists: true}
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vlazic/mcp-server-manager/internal/models"
+	"github.com/vlazic/mcp-server-manager/internal/services/testutil"
+)
+
+// Client watcher and drift tests
+//
+// Drift is any difference between a managed server entry in a client file and the
+// desired state in config.yaml: an enabled server missing or changed, or a disabled
+// server present. Unmanaged entries (not in mcpServers) are never reported.
+
+// setupDriftTest creates a manager whose client has the test server enabled and synced
+func setupDriftTest(t *testing.T) (*MCPManagerService, string) {
+	t.Helper()
+	tempDir := t.TempDir()
+	clientConfigPath := filepath.Join(tempDir, testutil.TestClientJSON)
+
+	cfg := &models.Config{
+		ServerPort: 6543,
+		MCPServers: []models.MCPServer{
+			{Name: testutil.TestServerName, Config: map[string]interface{}{"command": "echo", "timeout": 30000}},
+			{Name: "disabled-server", Config: map[string]interface{}{"command": "echo"}},
+		},
+		Clients: map[string]*models.Client{
+			testutil.TestClientName: {ConfigPath: clientConfigPath, Enabled: []string{testutil.TestServerName}},
+		},
+	}
+
+	service := NewMCPManagerService(cfg, filepath.Join(tempDir, testutil.TestConfigYAML))
+	if err := service.SyncAllClients(); err != nil {
+		t.Fatalf("SyncAllClients failed: %v", err)
+	}
+	return service, clientConfigPath
+}
+
+// writeClientServers replaces the mcpServers section of a client file, keeping an unmanaged entry
+func writeClientServers(t *testing.T, path string, servers map[string]interface{}) {
+	t.Helper()
+	servers["unmanaged"] = map[string]interface{}{"command": "other"}
+	data, _ := json.MarshalIndent(map[string]interface{}{"mcpServers": servers}, "", "  ")
+	testutil.WriteTestFile(t, path, string(data))
+}
+
+func TestDetectClientDrift(t *testing.T) {
+	tests := []struct {
+		name     string
+		servers  map[string]interface{}
+		wantKind string
+	}{
+		{
+			name: "No drift after sync",
+			servers: map[string]interface{}{
+				testutil.TestServerName: map[string]interface{}{"command": "echo", "timeout": 30000},
+			},
+		},
+		{
+			name:     "Enabled server removed",
+			servers:  map[string]interface{}{},
+			wantKind: DriftMissing,
+		},
+		{
+			name: "Enabled server changed",
+			servers: map[string]interface{}{
+				testutil.TestServerName: map[string]interface{}{"command": "cat"},
+			},
+			wantKind: DriftChanged,
+		},
+		{
+			name: "Disabled server added",
+			servers: map[string]interface{}{
+				testutil.TestServerName: map[string]interface{}{"command": "echo", "timeout": 30000},
+				"disabled-server":       map[string]interface{}{"command": "echo"},
+			},
+			wantKind: DriftUnexpected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, clientConfigPath := setupDriftTest(t)
+			writeClientServers(t, clientConfigPath, tt.servers)
+
+			events, err := service.DetectClientDrift(testutil.TestClientName)
+			if err != nil {
+				t.Fatalf("DetectClientDrift failed: %v", err)
+			}
+
+			if tt.wantKind == "" {
+				if len(events) != 0 {
+					t.Errorf("Expected no drift, got %+v", events)
+				}
+				return
+			}
+
+			if len(events) != 1 {
+				t.Fatalf("Expected 1 drift event, got %+v", events)
+			}
+			if events[0].Kind != tt.wantKind {
+				t.Errorf("Expected drift kind '%s', got '%s'", tt.wantKind, events[0].Kind)
+			}
+		})
+	}
+}
+
+func TestHealClientDrift(t *testing.T) {
+	service, clientConfigPath := setupDriftTest(t)
+	writeClientServers(t, clientConfigPath, map[string]interface{}{
+		"disabled-server": map[string]interface{}{"command": "echo"},
+	})
+
+	events, err := service.DetectClientDrift(testutil.TestClientName)
+	if err != nil {
+		t.Fatalf("DetectClientDrift failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 drift events, got %+v", events)
+	}
+
+	if err := service.HealClientDrift(testutil.TestClientName, events); err != nil {
+		t.Fatalf("HealClientDrift failed: %v", err)
+	}
+
+	remaining, _ := service.DetectClientDrift(testutil.TestClientName)
+	if len(remaining) != 0 {
+		t.Errorf("Expected no drift after healing, got %+v", remaining)
+	}
+
+	rawConfig, _ := service.clientConfigService.ReadClientConfig(testutil.TestClientName)
+	if _, exists := rawConfig["mcpServers"].(map[string]interface{})["unmanaged"]; !exists {
+		t.Error("Healing removed an unmanaged entry")
+	}
+
+	recorded := service.GetDriftEvents()
+	if len(recorded) != 2 || !recorded[0].Healed {
+		t.Errorf("Expected 2 healed events to be recorded, got %+v", recorded)
+	}
+}
+
+func TestClientWatcher(t *testing.T) {
+	t.Run("Auto-heal after debounce", func(t *testing.T) {
+		service, clientConfigPath := setupDriftTest(t)
+		watcher := NewClientWatcher(service, &models.WatchConfig{Mode: WatchModeAutoHeal, IntervalMs: 10, DebounceMs: 30})
+		watcher.Start()
+		defer watcher.Stop()
+
+		writeClientServers(t, clientConfigPath, map[string]interface{}{})
+
+		waitFor(t, func() bool { return len(service.GetDriftEvents()) > 0 })
+
+		events, _ := service.DetectClientDrift(testutil.TestClientName)
+		if len(events) != 0 {
+			t.Errorf("Expected client file to be healed, got drift %+v", events)
+		}
+	})
+
+	t.Run("Notify leaves file untouched", func(t *testing.T) {
+		service, clientConfigPath := setupDriftTest(t)
+		watcher := NewClientWatcher(service, &models.WatchConfig{Mode: WatchModeNotify, IntervalMs: 10, DebounceMs: 30})
+		watcher.Start()
+		defer watcher.Stop()
+
+		writeClientServers(t, clientConfigPath, map[string]interface{}{})
+
+		waitFor(t, func() bool { return len(service.GetDriftEvents()) > 0 })
+
+		recorded := service.GetDriftEvents()
+		if recorded[0].Kind != DriftMissing || recorded[0].Healed {
+			t.Errorf("Expected unhealed missing drift, got %+v", recorded[0])
+		}
+
+		events, _ := service.DetectClientDrift(testutil.TestClientName)
+		if len(events) != 1 {
+			t.Errorf("Expected drift to remain in notify mode, got %+v", events)
+		}
+	})
+
+	t.Run("Debounce waits for quiet file", func(t *testing.T) {
+		service, clientConfigPath := setupDriftTest(t)
+		watcher := NewClientWatcher(service, &models.WatchConfig{Mode: WatchModeNotify, IntervalMs: 10, DebounceMs: 300})
+		watcher.Start()
+		defer watcher.Stop()
+
+		writeClientServers(t, clientConfigPath, map[string]interface{}{})
+		time.Sleep(100 * time.Millisecond)
+
+		if len(service.GetDriftEvents()) != 0 {
+			t.Error("Watcher reacted before the debounce period elapsed")
+		}
+
+		// Restore the desired state before the debounce elapses: nothing to report
+		writeClientServers(t, clientConfigPath, map[string]interface{}{
+			testutil.TestServerName: map[string]interface{}{"command": "echo", "timeout": 30000},
+		})
+		time.Sleep(500 * time.Millisecond)
+
+		if events := service.GetDriftEvents(); len(events) != 0 {
+			t.Errorf("Expected no drift for a transient change, got %+v", events)
+		}
+	})
+
+	t.Run("Reloads edited config.yaml", func(t *testing.T) {
+		service, _ := setupDriftTest(t)
+		if err := service.ToggleClientMCPServer(testutil.TestClientName, "disabled-server", true); err != nil {
+			t.Fatalf("ToggleClientMCPServer failed: %v", err)
+		}
+		events, unsubscribe := service.Events().Subscribe()
+		defer unsubscribe()
+		watcher := NewClientWatcher(service, &models.WatchConfig{Mode: WatchModeNotify, IntervalMs: 10, DebounceMs: 30})
+		watcher.Start()
+		defer watcher.Stop()
+
+		data, _ := os.ReadFile(service.configPath)
+		testutil.WriteTestFile(t, service.configPath, strings.Replace(string(data), "- disabled-server", "", 1))
+
+		if event := nextEvent(t, events); event.Type != EventConfigReloaded {
+			t.Fatalf("Expected %s, got %s", EventConfigReloaded, event.Type)
+		}
+		if enabled := service.GetClients()[testutil.TestClientName].Enabled; contains(enabled, "disabled-server") {
+			t.Errorf("Expected the edit to be reloaded, got %v", enabled)
+		}
+	})
+}
+
+// waitFor polls cond until it returns true or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for condition")
+}
+
+func TestValidateWatchConfig(t *testing.T) {
+	if err := validateWatchConfig(&models.WatchConfig{Mode: "sometimes"}); err == nil {
+		t.Error("Expected error for invalid watch mode")
+	}
+	if err := validateWatchConfig(&models.WatchConfig{Mode: WatchModeAutoHeal}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := validateWatchConfig(nil); err != nil {
+		t.Errorf("Unexpected error for missing watch config: %v", err)
+	}
+}
+
+// Files written within the same mtime granularity may look unchanged; make sure
+// the size component of the fingerprint catches such rewrites.
+func TestStatFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), testutil.TestClientJSON)
+	if statFile(path).exists {
+		t.Error("Expected missing file to report exists=false")
+	}
+
+	testutil.WriteTestFile(t, path, "{}")
+	first := statFile(path)
+	if err := os.WriteFile(path, []byte(`{"a": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if statFile(path) == first {
+		t.Error("Expected fingerprint to change after rewrite")
+	}
+}